@@ -2,18 +2,23 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"API-GO/internal/config"
 	"API-GO/internal/database"
 	"API-GO/internal/logger"
+	"API-GO/internal/mailer"
 	"API-GO/internal/middleware"
+	"API-GO/internal/oidc"
 	"API-GO/internal/repository"
 	"API-GO/internal/router"
 	"API-GO/internal/services"
 	"API-GO/internal/utils"
+	"API-GO/internal/webauthn"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -28,15 +33,37 @@ func main(){
 	}
 	// Configure logging level
 	logger.SetLevelFromString(cfg.LogLevel)
+	// Regiunea pentru numerele de telefon fără prefix de țară
+	if err := utils.SetDefaultPhoneRegion(cfg.PhoneDefaultRegion); err != nil {
+		panic(err)
+	}
 
 	db, err := database.Connect(cfg.MongoURI)
 	if err != nil {
 		panic(err)
 	}
+	// Emailurile sunt aduse la litere mici înaintea indexului unic case-insensitive
+	if report, err := database.NormalizeUserEmails(db); err != nil {
+		log.Printf("Warning: failed to normalise emails: %v", err)
+	} else {
+		if report.Updated > 0 {
+			log.Printf("Emails normalised to lowercase: %d users", report.Updated)
+		}
+		for _, c := range report.Collisions {
+			log.Printf("Warning: users %v share the email %q (ignoring case); resolve this before the unique index can be created", c.UserIDs, c.Email)
+		}
+	}
 	// Creează indecși unici
 	if err := database.CreateIndexes(db); err != nil {
     	log.Printf("Warning: failed to create indexes: %v", err)
 	}
+	// Telefoanele salvate înainte de normalizare sunt aduse la E.164
+	if report, err := database.NormalizeUserPhones(db); err != nil {
+		log.Printf("Warning: failed to normalise phone numbers: %v", err)
+	} else if report.Updated > 0 || len(report.Invalid) > 0 || len(report.Conflicts) > 0 {
+		log.Printf("Phone numbers normalised: %d of %d updated; invalid (left as is): %v; conflicts (left as is): %v",
+			report.Updated, report.Scanned, report.Invalid, report.Conflicts)
+	}
 	defer func (){
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
@@ -52,20 +79,134 @@ func main(){
 
 	// Repositories & services
 	userRepo := repository.NewMongoUserRepository(db)
-	jwtManager := &utils.JWTManager{Secret: []byte(cfg.JWTSecret), AccessTTL: time.Duration(cfg.JWTTTLMinutes) * time.Minute, CookieName: cfg.CookieName, SecureCookies: cfg.CookieSecure}
-	authSvc := services.NewAuthService(userRepo, jwtManager)
+	refreshRepo := repository.NewMongoRefreshTokenRepository(db)
+	jwtManager := &utils.JWTManager{
+		Secret:            []byte(cfg.JWTSecret),
+		AccessTTL:         time.Duration(cfg.JWTTTLMinutes) * time.Minute,
+		CookieName:        cfg.CookieName,
+		SecureCookies:     cfg.CookieSecure,
+		RefreshTTL:        time.Duration(cfg.RefreshTTLHours) * time.Hour,
+		RefreshCookieName: cfg.RefreshCookieName,
+		CSRFCookieName:    cfg.CSRFCookieName,
+	}
+	revocationRepo := repository.NewMongoTokenRevocationRepository(db)
+	if err := loadJWTKeys(cfg, jwtManager); err != nil {
+		panic(err)
+	}
+	authSvc := services.NewAuthService(userRepo, refreshRepo, revocationRepo, jwtManager)
+	passwords, err := utils.NewPasswordHasher(cfg.PasswordHashAlgorithm,
+		utils.BcryptHasher{Cost: cfg.BcryptCost},
+		utils.NewArgon2idHasher(uint32(cfg.Argon2MemoryKiB), uint32(cfg.Argon2Iterations), uint8(cfg.Argon2Parallelism)))
+	if err != nil {
+		panic(err)
+	}
+	authSvc.Passwords = passwords
+	authSvc.PasswordPolicy = cfg.PasswordPolicy
+	if cfg.BreachedPasswordsPath != "" {
+		breached, err := utils.OpenBreachedPasswordFile(cfg.BreachedPasswordsPath)
+		if err != nil {
+			panic(err)
+		}
+		authSvc.BreachedPasswords = breached
+	}
+	authMW := middleware.NewAuthenticator(jwtManager, revocationRepo)
+	mail := newMailer(cfg)
+	otTokenRepo := repository.NewMongoOneTimeTokenRepository(db)
+	resetSvc := services.NewPasswordResetService(userRepo, otTokenRepo, mail, authSvc, cfg.AppBaseURL, time.Duration(cfg.PasswordResetTTLMinutes)*time.Minute)
+	resetSvc.InviteTTL = time.Duration(cfg.InviteTTLHours) * time.Hour
+	// Conturile suspendate sau dezactivate sunt refuzate și de middleware, nu doar la login
+	userAdminSvc := services.NewUserAdminService(userRepo, authSvc, resetSvc)
+	userAdminSvc.TemporaryPasswordTTL = time.Duration(cfg.TemporaryPasswordTTLHours) * time.Hour
+	authMW.Accounts = userAdminSvc
+	magicLinkSvc := services.NewMagicLinkService(userRepo, otTokenRepo, mail, authSvc, cfg.AppBaseURL, time.Duration(cfg.MagicLinkTTLMinutes)*time.Minute)
+	verifySvc := services.NewEmailVerificationService(userRepo, otTokenRepo, mail, cfg.AppBaseURL, time.Duration(cfg.EmailVerificationTTLHours)*time.Hour, cfg.EmailVerificationPolicy)
+	authSvc.EmailVerification = verifySvc
+	settingsRepo := repository.NewMongoSettingsRepository(db)
+	twoFactorSvc := services.NewTwoFactorService(userRepo, settingsRepo, authSvc, cfg.TOTPIssuer)
+	authSvc.TwoFactor = twoFactorSvc
+	// Contoare de login eșuat: în Mongo sunt partajate între instanțe
+	var attemptRepo repository.LoginAttemptRepository = repository.NewMongoLoginAttemptRepository(db)
+	if cfg.LoginAttemptStore == "memory" {
+		attemptRepo = repository.NewMemoryLoginAttemptRepository()
+	}
+	authSvc.Throttle = services.NewLoginThrottle(attemptRepo, cfg.LoginMaxFailures, cfg.LoginIPMaxFailures,
+		time.Duration(cfg.LoginFailureWindowMinutes)*time.Minute, time.Duration(cfg.LoginLockoutMinutes)*time.Minute)
+	authMW.EmailPolicy = cfg.EmailVerificationPolicy
+	authMW.TrustProxyHeaders = cfg.TrustProxyHeaders
+	impersonationAudit := repository.NewMongoImpersonationAuditRepository(db)
+	authMW.Audit = impersonationAudit
+	impersonationSvc := services.NewImpersonationService(userRepo, revocationRepo, impersonationAudit, jwtManager,
+		time.Duration(cfg.ImpersonationTTLMinutes)*time.Minute)
+	apiKeySvc := services.NewAPIKeyService(repository.NewMongoAPIKeyRepository(db), userRepo)
+	apiKeySvc.TwoFactor = twoFactorSvc
+	authMW.APIKeys = apiKeySvc
+	rp := webauthn.New(webauthn.Config{
+		RPID:             cfg.WebAuthnRPID,
+		RPName:           cfg.WebAuthnRPName,
+		Origins:          cfg.WebAuthnOrigins,
+		UserVerification: cfg.WebAuthnUserVerification,
+	})
+	webAuthnSvc := services.NewWebAuthnService(rp, repository.NewMongoWebAuthnCredentialRepository(db),
+		repository.NewMongoWebAuthnChallengeRepository(db), userRepo, authSvc)
+	var oidcProviders []*oidc.Provider
+	for _, p := range cfg.OIDCProviders {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+			AllowSignup:  p.AllowSignup,
+		}, nil))
+	}
+	oidcSvc := services.NewOIDCService(repository.NewMongoOIDCStateRepository(db), userRepo, authSvc, oidcProviders...)
+	// Bootstrap primul admin din ADMIN_EMAIL / ADMIN_PASSWORD
+	{
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		created, err := authSvc.BootstrapAdmin(ctx, cfg.AdminName, cfg.AdminEmail, cfg.AdminPassword)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: failed to bootstrap admin: %v", err)
+		} else if created {
+			log.Printf("Bootstrap admin ready: %s", cfg.AdminEmail)
+		}
+	}
 
 	// Routere
-	userRouter := router.NewUsersRouter(userRepo) // CRUD users prin repository
+	userRouter := router.NewUsersRouter(userRepo, userAdminSvc, authMW) // CRUD users prin repository
 	// Books repository & router
 	bookRepo := repository.NewMongoBookRepository(db)
-	bookRouter := router.NewBooksRouter(bookRepo)
-	authRouter := router.NewAuthRouter(authSvc, cfg.CookieName, cfg.CookieSecure)
+	bookRouter := router.NewBooksRouter(bookRepo, authMW)
+	// Golește coșul (useri și cărți șterse soft) după SOFT_DELETE_PURGE_DAYS
+	if cfg.SoftDeletePurgeDays > 0 {
+		purger := services.NewTrashPurger(time.Duration(cfg.SoftDeletePurgeDays) * 24 * time.Hour).
+			Add("users", userRepo).
+			Add("books", bookRepo)
+		go purger.Run(context.Background())
+	}
+	authRouter := router.NewAuthRouter(router.AuthRouterDeps{
+		Auth:          authSvc,
+		PasswordReset: resetSvc,
+		EmailVerification: verifySvc,
+		TwoFactor:         twoFactorSvc,
+		APIKeys:           apiKeySvc,
+		OIDC:              oidcSvc,
+		MagicLink:         magicLinkSvc,
+		WebAuthn:          webAuthnSvc,
+		Impersonation:     impersonationSvc,
+		Authenticator: authMW,
+		CookieName:    cfg.CookieName,
+		SecureCookies: cfg.CookieSecure,
+		TrustProxyHeaders: cfg.TrustProxyHeaders,
+	})
 
 	// Montează distinct pentru a evita conflictul dintre două PathPrefix identice
 	root.PathPrefix("/api-go/v1/users").Handler(http.StripPrefix("/api-go/v1", userRouter))
 	root.PathPrefix("/api-go/v1/books").Handler(http.StripPrefix("/api-go/v1", bookRouter))
 	root.PathPrefix("/api-go/v1/auth").Handler(http.StripPrefix("/api-go/v1", authRouter))
+	// Chei publice pentru verificarea token-urilor de către alte servicii
+	root.PathPrefix("/.well-known").Handler(router.NewWellKnownRouter(jwtManager))
 
     // Pentru viitor - alte routere
     // productRouter := productRouter.New(db)
@@ -76,4 +217,40 @@ func main(){
         log.Fatalf("server error: %v", err)
     }
 
+}
+
+// loadJWTKeys încarcă cheia de semnare asimetrică și cheile de verificare din config (dacă sunt setate)
+func loadJWTKeys(cfg *config.Config, m *utils.JWTManager) error {
+	if cfg.JWTSigningKeyFile != "" {
+		key, err := utils.LoadJWTKeyFile(cfg.JWTSigningKeyID, cfg.JWTSigningKeyFile)
+		if err != nil {
+			return fmt.Errorf("load JWT signing key: %w", err)
+		}
+		if key.Private == nil {
+			return fmt.Errorf("JWT signing key %s must contain a private key", cfg.JWTSigningKeyFile)
+		}
+		m.SigningKey = key
+		log.Printf("JWT signing with %s (kid %s)", key.Method.Alg(), key.ID)
+	}
+	for _, entry := range cfg.JWTVerifyKeyFiles {
+		kid, path := "", entry
+		if i := strings.Index(entry, "="); i > 0 {
+			kid, path = entry[:i], entry[i+1:]
+		}
+		key, err := utils.LoadJWTKeyFile(kid, path)
+		if err != nil {
+			return fmt.Errorf("load JWT verification key: %w", err)
+		}
+		key.Private = nil
+		m.VerificationKeys = append(m.VerificationKeys, key)
+	}
+	return nil
+}
+
+// newMailer alege SMTP când e configurat, altfel scrie emailurile în MAIL_DIR (sau doar le loghează)
+func newMailer(cfg *config.Config) mailer.Mailer {
+	if cfg.SMTPHost != "" {
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	}
+	return mailer.NewFileMailer(cfg.MailDir)
 }
\ No newline at end of file
@@ -2,18 +2,97 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"strings"
+
+	"API-GO/internal/models"
+	"API-GO/internal/utils"
 )
 
+// OIDCProvider descrie un furnizor OpenID Connect extern, configurat prin OIDC_<NUME>_*
+type OIDCProvider struct {
+    Name         string
+    Issuer       string
+    ClientID     string
+    ClientSecret string
+    RedirectURL  string
+    Scopes       []string
+    AllowSignup  bool
+}
+
 type Config struct {
     Port     string
     MongoURI string
     JWTSecret string
+    // Semnare asimetrică (opțional): cheia privată activă și chei publice acceptate în continuare
+    JWTSigningKeyFile string
+    JWTSigningKeyID   string
+    JWTVerifyKeyFiles []string
     JWTTTLMinutes int
     CookieName string
     CookieSecure bool
+    RefreshTTLHours int
+    RefreshCookieName string
+    // Cookie-ul pentru token-ul CSRF (double-submit); gol când CSRF_PROTECTION=false
+    CSRFCookieName string
     LogLevel string
+    // Protecție brute-force la login: prag per cont / per IP, fereastra de numărare și durata blocării
+    LoginMaxFailures          int
+    LoginIPMaxFailures        int
+    LoginFailureWindowMinutes int
+    LoginLockoutMinutes       int
+    // Unde se țin contoarele: mongo (partajat între instanțe) sau memory
+    LoginAttemptStore string
+    // X-Forwarded-For e luat în calcul doar în spatele unui proxy de încredere
+    TrustProxyHeaders bool
+    // Hash-ul parolelor: algoritmul pentru hash-uri noi (argon2id | bcrypt) și parametrii lui
+    PasswordHashAlgorithm string
+    BcryptCost            int
+    Argon2MemoryKiB       int
+    Argon2Iterations      int
+    Argon2Parallelism     int
+    // Reguli pentru parolele noi și fișierul/directorul opțional cu hash-uri de parole compromise
+    PasswordPolicy        utils.PasswordPolicy
+    BreachedPasswordsPath string
+    // Login prin furnizori externi (OIDC_PROVIDERS)
+    OIDCProviders []OIDCProvider
+    // Passkey-uri (WebAuthn): domeniul (RP ID), numele afișat, originile acceptate și cerința de verificare a userului
+    WebAuthnRPID             string
+    WebAuthnRPName           string
+    WebAuthnOrigins          []string
+    WebAuthnUserVerification string
+    // Emitentul afișat în aplicațiile de autentificare (TOTP)
+    TOTPIssuer string
+    // Regiunea (cod ISO, ex. RO) în care se citesc numerele de telefon scrise fără prefix de țară
+    PhoneDefaultRegion string
+    // URL-ul public al aplicației, folosit în linkurile trimise pe email
+    AppBaseURL string
+    PasswordResetTTLMinutes int
+    MagicLinkTTLMinutes     int
+    // Valabilitatea invitației trimise unui cont creat de admin
+    InviteTTLHours int
+    // Valabilitatea parolei temporare date de admin la crearea contului
+    TemporaryPasswordTTLHours int
+    // Durata maximă a unui token de impersonare (admin)
+    ImpersonationTTLMinutes int
+    // Documentele din coș (useri, cărți) se șterg definitiv după atâtea zile; 0 = niciodată
+    SoftDeletePurgeDays int
+    // Verificare email: allow | restrict | block
+    EmailVerificationPolicy   string
+    EmailVerificationTTLHours int
+    // Email: SMTP dacă SMTPHost e setat, altfel fișiere în MailDir (sau doar log)
+    SMTPHost     string
+    SMTPPort     int
+    SMTPUsername string
+    SMTPPassword string
+    MailFrom     string
+    MailDir      string
+    // Primul admin (opțional): promovat sau creat la pornire dacă nu există niciun admin
+    AdminName     string
+    AdminEmail    string
+    AdminPassword string
 }
 
 func Load() (*Config, error) {
@@ -34,13 +113,21 @@ func Load() (*Config, error) {
         port = ":" + port
     }
 
-    // JWT secret
+    // JWT: HS256 cu JWT_SECRET sau cheie asimetrică din JWT_SIGNING_KEY_FILE (RS256/ES256/EdDSA)
     jwtSecret := os.Getenv("JWT_SECRET")
-    if jwtSecret == "" {
-        return nil, fmt.Errorf("JWT_SECRET environment variable is not set")
+    signingKeyFile := os.Getenv("JWT_SIGNING_KEY_FILE")
+    if jwtSecret == "" && signingKeyFile == "" {
+        return nil, fmt.Errorf("either JWT_SECRET or JWT_SIGNING_KEY_FILE environment variable must be set")
+    }
+    // Listă separată prin virgulă: "path" sau "kid=path"
+    var verifyKeyFiles []string
+    for _, f := range strings.Split(os.Getenv("JWT_VERIFY_KEY_FILES"), ",") {
+        if f = strings.TrimSpace(f); f != "" {
+            verifyKeyFiles = append(verifyKeyFiles, f)
+        }
     }
-    // TTL (minutes), default 60
-    jwtTTL := 60
+    // TTL (minutes), default 15 - access token-ul e de scurtă durată, sesiunea continuă prin refresh token
+    jwtTTL := 15
     if v := os.Getenv("JWT_TTL_MINUTES"); v != "" {
         var parsed int
         fmt.Sscanf(v, "%d", &parsed)
@@ -48,25 +135,382 @@ func Load() (*Config, error) {
             jwtTTL = parsed
         }
     }
+    // Refresh token TTL (hours), default 720 (30 zile)
+    refreshTTL := 720
+    if v := os.Getenv("REFRESH_TTL_HOURS"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            refreshTTL = parsed
+        }
+    }
+    refreshCookieName := os.Getenv("REFRESH_COOKIE_NAME")
+    if refreshCookieName == "" {
+        refreshCookieName = "refresh_token"
+    }
     // Cookie name default "access_token"
     cookieName := os.Getenv("COOKIE_NAME")
     if cookieName == "" {
         cookieName = "access_token"
     }
+    csrfCookieName := os.Getenv("CSRF_COOKIE_NAME")
+    if csrfCookieName == "" {
+        csrfCookieName = "csrf_token"
+    }
+    if v := strings.ToLower(os.Getenv("CSRF_PROTECTION")); v == "false" || v == "0" {
+        csrfCookieName = ""
+    }
     // Cookie secure flag (default false for local dev)
     cookieSecure := false
     if v := os.Getenv("COOKIE_SECURE"); strings.ToLower(v) == "true" || v == "1" {
         cookieSecure = true
     }
 
+    appBaseURL := os.Getenv("APP_BASE_URL")
+    if appBaseURL == "" {
+        appBaseURL = "http://localhost" + port
+    }
+    oidcProviders, err := loadOIDCProviders(appBaseURL)
+    if err != nil {
+        return nil, err
+    }
+    webAuthnRPID, webAuthnOrigins, err := loadWebAuthnOrigins(appBaseURL)
+    if err != nil {
+        return nil, err
+    }
+    webAuthnRPName := os.Getenv("WEBAUTHN_RP_NAME")
+    if webAuthnRPName == "" {
+        webAuthnRPName = "API-GO"
+    }
+    webAuthnUV := strings.ToLower(strings.TrimSpace(os.Getenv("WEBAUTHN_USER_VERIFICATION")))
+    if webAuthnUV == "" {
+        webAuthnUV = "preferred"
+    }
+    if webAuthnUV != "required" && webAuthnUV != "preferred" && webAuthnUV != "discouraged" {
+        return nil, fmt.Errorf("WEBAUTHN_USER_VERIFICATION must be required, preferred or discouraged")
+    }
+    resetTTL := 30
+    if v := os.Getenv("PASSWORD_RESET_TTL_MINUTES"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            resetTTL = parsed
+        }
+    }
+    magicLinkTTL := 15
+    if v := os.Getenv("MAGIC_LINK_TTL_MINUTES"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            magicLinkTTL = parsed
+        }
+    }
+    inviteTTL := 72
+    if v := os.Getenv("INVITE_TTL_HOURS"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            inviteTTL = parsed
+        }
+    }
+    temporaryPasswordTTL := 72
+    if v := os.Getenv("TEMPORARY_PASSWORD_TTL_HOURS"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            temporaryPasswordTTL = parsed
+        }
+    }
+    impersonationTTL := 30
+    if v := os.Getenv("IMPERSONATION_TTL_MINUTES"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            impersonationTTL = parsed
+        }
+    }
+    purgeDays := 30
+    if v := os.Getenv("SOFT_DELETE_PURGE_DAYS"); v != "" {
+        var parsed int
+        if _, err := fmt.Sscanf(v, "%d", &parsed); err != nil || parsed < 0 {
+            return nil, fmt.Errorf("SOFT_DELETE_PURGE_DAYS must be a non-negative number of days")
+        }
+        purgeDays = parsed
+    }
+    emailPolicy := strings.ToLower(strings.TrimSpace(os.Getenv("EMAIL_VERIFICATION_POLICY")))
+    if emailPolicy == "" {
+        emailPolicy = models.EmailPolicyAllow
+    }
+    if !models.IsValidEmailPolicy(emailPolicy) {
+        return nil, fmt.Errorf("EMAIL_VERIFICATION_POLICY must be allow, restrict or block")
+    }
+    verifyTTL := 24
+    if v := os.Getenv("EMAIL_VERIFICATION_TTL_HOURS"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            verifyTTL = parsed
+        }
+    }
+    smtpPort := 587
+    if v := os.Getenv("SMTP_PORT"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            smtpPort = parsed
+        }
+    }
+    loginMaxFailures := 10
+    if v := os.Getenv("LOGIN_MAX_FAILURES"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            loginMaxFailures = parsed
+        }
+    }
+    loginIPMaxFailures := 100
+    if v := os.Getenv("LOGIN_IP_MAX_FAILURES"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            loginIPMaxFailures = parsed
+        }
+    }
+    loginWindow := 15
+    if v := os.Getenv("LOGIN_FAILURE_WINDOW_MINUTES"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            loginWindow = parsed
+        }
+    }
+    loginLockout := 15
+    if v := os.Getenv("LOGIN_LOCKOUT_MINUTES"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            loginLockout = parsed
+        }
+    }
+    loginStore := strings.ToLower(strings.TrimSpace(os.Getenv("LOGIN_ATTEMPT_STORE")))
+    if loginStore == "" {
+        loginStore = "mongo"
+    }
+    if loginStore != "mongo" && loginStore != "memory" {
+        return nil, fmt.Errorf("LOGIN_ATTEMPT_STORE must be mongo or memory")
+    }
+    trustProxy := false
+    if v := os.Getenv("TRUST_PROXY_HEADERS"); strings.ToLower(v) == "true" || v == "1" {
+        trustProxy = true
+    }
+    hashAlgorithm := strings.ToLower(strings.TrimSpace(os.Getenv("PASSWORD_HASH_ALGORITHM")))
+    if hashAlgorithm == "" {
+        hashAlgorithm = "argon2id"
+    }
+    if hashAlgorithm != "argon2id" && hashAlgorithm != "bcrypt" {
+        return nil, fmt.Errorf("PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt")
+    }
+    bcryptCost := 10
+    if v := os.Getenv("BCRYPT_COST"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed < 4 || parsed > 31 {
+            return nil, fmt.Errorf("BCRYPT_COST must be between 4 and 31")
+        }
+        bcryptCost = parsed
+    }
+    // Argon2id: implicit recomandarea OWASP (19 MiB, 2 iterații, 1 fir)
+    argonMemory := 19 * 1024
+    if v := os.Getenv("ARGON2_MEMORY_KIB"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            argonMemory = parsed
+        }
+    }
+    argonIterations := 2
+    if v := os.Getenv("ARGON2_ITERATIONS"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 {
+            argonIterations = parsed
+        }
+    }
+    argonParallelism := 1
+    if v := os.Getenv("ARGON2_PARALLELISM"); v != "" {
+        var parsed int
+        fmt.Sscanf(v, "%d", &parsed)
+        if parsed > 0 && parsed < 256 {
+            argonParallelism = parsed
+        }
+    }
+    passwordPolicy, err := loadPasswordPolicy()
+    if err != nil {
+        return nil, err
+    }
+    totpIssuer := os.Getenv("TOTP_ISSUER")
+    if totpIssuer == "" {
+        totpIssuer = "API-GO"
+    }
+    phoneRegion := strings.ToUpper(strings.TrimSpace(os.Getenv("PHONE_DEFAULT_REGION")))
+    if phoneRegion == "" {
+        phoneRegion = "RO"
+    }
+    if !slices.Contains(utils.PhoneRegions(), phoneRegion) {
+        return nil, fmt.Errorf("PHONE_DEFAULT_REGION must be one of %s", strings.Join(utils.PhoneRegions(), ", "))
+    }
+    mailFrom := os.Getenv("MAIL_FROM")
+    if mailFrom == "" {
+        mailFrom = "no-reply@localhost"
+    }
+
     uri = strings.Replace(uri, "<db_password>", password, 1)
     return &Config{
         Port:     port,
         MongoURI: uri,
         JWTSecret: jwtSecret,
+        JWTSigningKeyFile: signingKeyFile,
+        JWTSigningKeyID: os.Getenv("JWT_SIGNING_KEY_ID"),
+        JWTVerifyKeyFiles: verifyKeyFiles,
         JWTTTLMinutes: jwtTTL,
         CookieName: cookieName,
         CookieSecure: cookieSecure,
+        RefreshTTLHours: refreshTTL,
+        RefreshCookieName: refreshCookieName,
+        CSRFCookieName: csrfCookieName,
         LogLevel: os.Getenv("LOG_LEVEL"),
+        LoginMaxFailures: loginMaxFailures,
+        LoginIPMaxFailures: loginIPMaxFailures,
+        LoginFailureWindowMinutes: loginWindow,
+        LoginLockoutMinutes: loginLockout,
+        LoginAttemptStore: loginStore,
+        TrustProxyHeaders: trustProxy,
+        PasswordHashAlgorithm: hashAlgorithm,
+        BcryptCost: bcryptCost,
+        Argon2MemoryKiB: argonMemory,
+        Argon2Iterations: argonIterations,
+        Argon2Parallelism: argonParallelism,
+        PasswordPolicy: passwordPolicy,
+        BreachedPasswordsPath: os.Getenv("BREACHED_PASSWORDS_PATH"),
+        AppBaseURL: appBaseURL,
+        OIDCProviders: oidcProviders,
+        WebAuthnRPID: webAuthnRPID,
+        WebAuthnRPName: webAuthnRPName,
+        WebAuthnOrigins: webAuthnOrigins,
+        WebAuthnUserVerification: webAuthnUV,
+        TOTPIssuer: totpIssuer,
+        PhoneDefaultRegion: phoneRegion,
+        PasswordResetTTLMinutes: resetTTL,
+        MagicLinkTTLMinutes: magicLinkTTL,
+        InviteTTLHours: inviteTTL,
+        TemporaryPasswordTTLHours: temporaryPasswordTTL,
+        ImpersonationTTLMinutes: impersonationTTL,
+        SoftDeletePurgeDays: purgeDays,
+        EmailVerificationPolicy: emailPolicy,
+        EmailVerificationTTLHours: verifyTTL,
+        SMTPHost: os.Getenv("SMTP_HOST"),
+        SMTPPort: smtpPort,
+        SMTPUsername: os.Getenv("SMTP_USERNAME"),
+        SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+        MailFrom: mailFrom,
+        MailDir: os.Getenv("MAIL_DIR"),
+        AdminName: os.Getenv("ADMIN_NAME"),
+        AdminEmail: os.Getenv("ADMIN_EMAIL"),
+        AdminPassword: os.Getenv("ADMIN_PASSWORD"),
     }, nil
-}
\ No newline at end of file
+}
+
+// loadOIDCProviders citește OIDC_PROVIDERS (ex. "company,google") și, pentru fiecare nume,
+// OIDC_<NUME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _SCOPES, _REDIRECT_URL, _ALLOW_SIGNUP.
+func loadOIDCProviders(appBaseURL string) ([]OIDCProvider, error) {
+    var out []OIDCProvider
+    for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+        name = strings.ToLower(strings.TrimSpace(name))
+        if name == "" {
+            continue
+        }
+        prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+        p := OIDCProvider{
+            Name:         name,
+            Issuer:       os.Getenv(prefix + "ISSUER"),
+            ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+            ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+            RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+            Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+        }
+        if p.Issuer == "" || p.ClientID == "" {
+            return nil, fmt.Errorf("%sISSUER and %sCLIENT_ID must be set for OIDC provider %q", prefix, prefix, name)
+        }
+        if p.RedirectURL == "" {
+            p.RedirectURL = strings.TrimRight(appBaseURL, "/") + "/api-go/v1/auth/oidc/" + name + "/callback"
+        }
+        if v := os.Getenv(prefix + "ALLOW_SIGNUP"); strings.ToLower(v) == "true" || v == "1" {
+            p.AllowSignup = true
+        }
+        out = append(out, p)
+    }
+    return out, nil
+}
+
+// loadWebAuthnOrigins citește WEBAUTHN_RP_ID și WEBAUTHN_ORIGINS (separate prin virgulă);
+// implicit ambele se deduc din APP_BASE_URL.
+func loadWebAuthnOrigins(appBaseURL string) (string, []string, error) {
+    base, err := url.Parse(appBaseURL)
+    if err != nil || base.Host == "" {
+        return "", nil, fmt.Errorf("APP_BASE_URL must be an absolute URL")
+    }
+    rpID := os.Getenv("WEBAUTHN_RP_ID")
+    if rpID == "" {
+        rpID = base.Hostname()
+    }
+    var origins []string
+    for _, o := range strings.Split(os.Getenv("WEBAUTHN_ORIGINS"), ",") {
+        if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+            origins = append(origins, o)
+        }
+    }
+    if len(origins) == 0 {
+        origins = []string{base.Scheme + "://" + base.Host}
+    }
+    return rpID, origins, nil
+}
+
+// loadPasswordPolicy pornește de la utils.DefaultPasswordPolicy și aplică variabilele PASSWORD_*.
+func loadPasswordPolicy() (utils.PasswordPolicy, error) {
+    p := utils.DefaultPasswordPolicy()
+    ints := map[string]*int{
+        "PASSWORD_MIN_LENGTH":   &p.MinLength,
+        "PASSWORD_MAX_LENGTH":   &p.MaxLength,
+        "PASSWORD_MAX_REPEATED": &p.MaxRepeated,
+    }
+    for name, dst := range ints {
+        if v := os.Getenv(name); v != "" {
+            var parsed int
+            if _, err := fmt.Sscanf(v, "%d", &parsed); err != nil || parsed < 0 {
+                return p, fmt.Errorf("%s must be a non-negative integer", name)
+            }
+            *dst = parsed
+        }
+    }
+    bools := map[string]*bool{
+        "PASSWORD_REQUIRE_UPPER":          &p.RequireUpper,
+        "PASSWORD_REQUIRE_LOWER":          &p.RequireLower,
+        "PASSWORD_REQUIRE_DIGIT":          &p.RequireDigit,
+        "PASSWORD_REQUIRE_SYMBOL":         &p.RequireSymbol,
+        "PASSWORD_DISALLOW_PERSONAL_INFO": &p.DisallowPersonalInfo,
+    }
+    for name, dst := range bools {
+        if v := strings.ToLower(os.Getenv(name)); v != "" {
+            *dst = v == "true" || v == "1"
+        }
+    }
+    if v := os.Getenv("PASSWORD_MIN_ENTROPY_BITS"); v != "" {
+        if _, err := fmt.Sscanf(v, "%g", &p.MinEntropyBits); err != nil || p.MinEntropyBits < 0 {
+            return p, fmt.Errorf("PASSWORD_MIN_ENTROPY_BITS must be a non-negative number")
+        }
+    }
+    if p.MaxLength > 0 && p.MaxLength < p.MinLength {
+        return p, fmt.Errorf("PASSWORD_MAX_LENGTH must not be lower than PASSWORD_MIN_LENGTH")
+    }
+    return p, nil
+}
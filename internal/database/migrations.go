@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PhoneMigrationReport descrie rezultatul NormalizeUserPhones.
+type PhoneMigrationReport struct {
+    Scanned int
+    Updated int
+    // ID-urile userilor al căror telefon nu e valid în nicio regiune; rămân neschimbați
+    Invalid []string
+    // ID-urile userilor al căror număr normalizat aparține deja altui user; rămân neschimbați
+    Conflicts []string
+}
+
+// NormalizeUserPhones rescrie telefoanele salvate în format E.164 (utils.NormalizePhone, cu regiunea
+// implicită). Documentele deja în E.164 nu sunt citite, deci migrarea poate rula la fiecare pornire.
+func NormalizeUserPhones(client *mongo.Client) (*PhoneMigrationReport, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+    defer cancel()
+
+    coll := UserCollection(client)
+    filter := bson.M{"phone": bson.M{
+        "$exists": true,
+        "$ne":     "",
+        "$not":    primitive.Regex{Pattern: `^\+[1-9][0-9]{6,14}$`},
+    }}
+    cur, err := coll.Find(ctx, filter, options.Find().SetProjection(bson.M{"phone": 1}))
+    if err != nil {
+        return nil, err
+    }
+    defer cur.Close(ctx)
+
+    report := &PhoneMigrationReport{}
+    for cur.Next(ctx) {
+        var doc struct {
+            ID    primitive.ObjectID `bson:"_id"`
+            Phone string             `bson:"phone"`
+        }
+        if err := cur.Decode(&doc); err != nil {
+            return report, err
+        }
+        report.Scanned++
+        phone, err := utils.NormalizePhone(doc.Phone)
+        if err != nil {
+            report.Invalid = append(report.Invalid, doc.ID.Hex())
+            continue
+        }
+        // Indexul unic pe phone prinde numerele care, normalizate, se suprapun cu ale altui user
+        _, err = coll.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": bson.M{"phone": phone}})
+        if mongo.IsDuplicateKeyError(err) {
+            report.Conflicts = append(report.Conflicts, doc.ID.Hex())
+            continue
+        }
+        if err != nil {
+            return report, err
+        }
+        report.Updated++
+    }
+    return report, cur.Err()
+}
+
+// EmailCollision grupează userii ale căror emailuri diferă doar prin majuscule (și au același deletedAt,
+// deci s-ar ciocni în indexul unic).
+type EmailCollision struct {
+    Email     string     `bson:"email"`
+    DeletedAt *time.Time `bson:"deletedAt,omitempty"`
+    UserIDs   []string   `bson:"userIds"`
+}
+
+// EmailMigrationReport descrie rezultatul NormalizeUserEmails.
+type EmailMigrationReport struct {
+    Updated int64
+    // Coliziunile trebuie rezolvate manual (ștergere, redenumire); userii implicați rămân neschimbați
+    Collisions []EmailCollision
+}
+
+// NormalizeUserEmails aduce emailurile salvate la litere mici și raportează conturile care diferă doar
+// prin majuscule. Cât timp există coliziuni, indexul unic case-insensitive din CreateIndexes nu poate fi creat.
+func NormalizeUserEmails(client *mongo.Client) (*EmailMigrationReport, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+    defer cancel()
+
+    coll := UserCollection(client)
+    pipeline := mongo.Pipeline{
+        {{Key: "$group", Value: bson.M{
+            "_id":   bson.M{"email": bson.M{"$toLower": "$email"}, "deletedAt": "$deletedAt"},
+            "ids":   bson.M{"$push": "$_id"},
+            "count": bson.M{"$sum": 1},
+        }}},
+        {{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+    }
+    cur, err := coll.Aggregate(ctx, pipeline)
+    if err != nil {
+        return nil, err
+    }
+    defer cur.Close(ctx)
+
+    report := &EmailMigrationReport{}
+    var skip []primitive.ObjectID
+    for cur.Next(ctx) {
+        var group struct {
+            Key struct {
+                Email     string     `bson:"email"`
+                DeletedAt *time.Time `bson:"deletedAt"`
+            } `bson:"_id"`
+            IDs []primitive.ObjectID `bson:"ids"`
+        }
+        if err := cur.Decode(&group); err != nil {
+            return nil, err
+        }
+        c := EmailCollision{Email: group.Key.Email, DeletedAt: group.Key.DeletedAt}
+        for _, id := range group.IDs {
+            c.UserIDs = append(c.UserIDs, id.Hex())
+        }
+        report.Collisions = append(report.Collisions, c)
+        skip = append(skip, group.IDs...)
+    }
+    if err := cur.Err(); err != nil {
+        return nil, err
+    }
+
+    filter := bson.M{"email": primitive.Regex{Pattern: "[A-Z]"}}
+    if len(skip) > 0 {
+        filter["_id"] = bson.M{"$nin": skip}
+    }
+    lower := mongo.Pipeline{{{Key: "$set", Value: bson.M{"email": bson.M{"$toLower": "$email"}}}}}
+    res, err := coll.UpdateMany(ctx, filter, lower)
+    if err != nil {
+        return report, err
+    }
+    report.Updated = res.ModifiedCount
+    return report, nil
+}
+
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -9,6 +10,10 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// EmailCollation compară emailurile fără să țină cont de majuscule (strength 2). Indexul unic pe email
+// o folosește, iar căutările după email trebuie să o ceară ca să-l poată folosi.
+var EmailCollation = &options.Collation{Locale: "en", Strength: 2}
+
 // Connect deschide conexiunea la Mongo şi rulează un ping.
 func Connect(uri string) (*mongo.Client, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -34,6 +39,61 @@ func BookCollection(client *mongo.Client) *mongo.Collection {
     return client.Database("API-GO").Collection("books")
 }
 
+// RefreshTokenCollection returns a handle to the "refresh_tokens" collection.
+func RefreshTokenCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("refresh_tokens")
+}
+
+// RevokedTokenCollection returns a handle to the "revoked_tokens" collection (keyed by jti).
+func RevokedTokenCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("revoked_tokens")
+}
+
+// TokenCutoffCollection returns a handle to the "token_cutoffs" collection (keyed by user ID).
+func TokenCutoffCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("token_cutoffs")
+}
+
+// OneTimeTokenCollection returns a handle to the "one_time_tokens" collection.
+func OneTimeTokenCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("one_time_tokens")
+}
+
+// SettingsCollection returns a handle to the "settings" collection (one document per settings group).
+func SettingsCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("settings")
+}
+
+// LoginAttemptCollection returns a handle to the "login_attempts" collection (keyed by account or IP).
+func LoginAttemptCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("login_attempts")
+}
+
+// APIKeyCollection returns a handle to the "api_keys" collection.
+func APIKeyCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("api_keys")
+}
+
+// OIDCStateCollection returns a handle to the "oidc_states" collection (pending external logins).
+func OIDCStateCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("oidc_states")
+}
+
+// WebAuthnCredentialCollection returns a handle to the "webauthn_credentials" collection (passkeys).
+func WebAuthnCredentialCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("webauthn_credentials")
+}
+
+// WebAuthnChallengeCollection returns a handle to the "webauthn_challenges" collection (pending ceremonies).
+func WebAuthnChallengeCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("webauthn_challenges")
+}
+
+// ImpersonationAuditCollection returns a handle to the "impersonation_audit" collection (admin impersonation log).
+func ImpersonationAuditCollection(client *mongo.Client) *mongo.Collection {
+    return client.Database("API-GO").Collection("impersonation_audit")
+}
+
 // CreateIndexes creează indecși unici pentru email și phone
 func CreateIndexes(client *mongo.Client) error {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -42,19 +102,47 @@ func CreateIndexes(client *mongo.Client) error {
     // Users indexes
     coll := UserCollection(client)
     
-    // Index unic pentru email
+    // Email și phone sunt unice doar printre userii activi: deletedAt lipsește la ei (null în index),
+    // iar userii din coș au fiecare momentul lor de ștergere. Indecșii vechi, doar pe email/phone,
+    // ar bloca refolosirea adresei unui cont șters, deci sunt înlocuiți, dar doar după ce înlocuitorii
+    // lor au fost creați, ca unicitatea să fie impusă tot timpul.
+    // Unicitatea emailului nu ține cont de majuscule. Indecșii vechi de email (email_1 și
+    // email_1_deletedAt_1, case-sensitive) se șterg doar după ce noul index a fost creat: dacă există
+    // coliziuni (vezi NormalizeUserEmails), crearea eșuează și rămâne măcar vechea protecție;
+    // ceilalți indecși se creează oricum.
     emailIndex := mongo.IndexModel{
-        Keys:    bson.M{"email": 1},
-        Options: options.Index().SetUnique(true),
+        Keys:    bson.D{{Key: "email", Value: 1}, {Key: "deletedAt", Value: 1}},
+        Options: options.Index().SetName("email_ci_deletedAt").SetUnique(true).SetCollation(EmailCollation),
     }
-    
+    var emailErr error
+    if _, err := coll.Indexes().CreateOne(ctx, emailIndex); err != nil {
+        emailErr = fmt.Errorf("case-insensitive email index: %w", err)
+    } else {
+        for _, old := range []string{"email_1", "email_1_deletedAt_1"} {
+            if err := dropIndexIfExists(ctx, coll, old); err != nil {
+                return err
+            }
+        }
+    }
+
     // Index unic pentru phone (doar dacă nu e null/empty)
     phoneIndex := mongo.IndexModel{
-        Keys: bson.M{"phone": 1},
+        Keys: bson.D{{Key: "phone", Value: 1}, {Key: "deletedAt", Value: 1}},
         Options: options.Index().SetUnique(true).SetSparse(true), // sparse pentru câmpuri opționale
     }
+    if _, err := coll.Indexes().CreateOne(ctx, phoneIndex); err != nil {
+        return err
+    }
+    if err := dropIndexIfExists(ctx, coll, "phone_1"); err != nil {
+        return err
+    }
 
-    if _, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{emailIndex, phoneIndex}); err != nil {
+    // Coșul și purjarea caută după deletedAt
+    trashIndex := mongo.IndexModel{
+        Keys:    bson.M{"deletedAt": 1},
+        Options: options.Index().SetSparse(true),
+    }
+    if _, err := coll.Indexes().CreateOne(ctx, trashIndex); err != nil {
         return err
     }
 
@@ -63,9 +151,121 @@ func CreateIndexes(client *mongo.Client) error {
     bookIndexes := []mongo.IndexModel{
         {Keys: bson.M{"title": 1}},
         {Keys: bson.M{"author": 1}},
+        {Keys: bson.M{"deletedAt": 1}, Options: options.Index().SetSparse(true)},
         //{Keys: bson.M{"genre": 1}},
         //{Keys: bson.M{"yearPublished": 1}},
     }
-    _, err := bcoll.Indexes().CreateMany(ctx, bookIndexes)
-    return err
-}
\ No newline at end of file
+    if _, err := bcoll.Indexes().CreateMany(ctx, bookIndexes); err != nil {
+        return err
+    }
+
+    // Refresh tokens: lookup după hash, revocare pe familie, ștergere automată după expirare (TTL)
+    rcoll := RefreshTokenCollection(client)
+    refreshIndexes := []mongo.IndexModel{
+        {Keys: bson.M{"tokenHash": 1}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.M{"familyId": 1}},
+        {Keys: bson.M{"expiresAt": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+    }
+    if _, err := rcoll.Indexes().CreateMany(ctx, refreshIndexes); err != nil {
+        return err
+    }
+
+    // Token-uri revocate: Mongo le șterge singur după ce ar fi expirat oricum
+    revokedIndex := mongo.IndexModel{
+        Keys:    bson.M{"expiresAt": 1},
+        Options: options.Index().SetExpireAfterSeconds(0),
+    }
+    if _, err := RevokedTokenCollection(client).Indexes().CreateOne(ctx, revokedIndex); err != nil {
+        return err
+    }
+
+    // Token-uri de unică folosință (reset parolă etc.)
+    otIndexes := []mongo.IndexModel{
+        {Keys: bson.M{"tokenHash": 1}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "userId", Value: 1}, {Key: "purpose", Value: 1}}},
+        {Keys: bson.M{"expiresAt": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+    }
+    if _, err := OneTimeTokenCollection(client).Indexes().CreateMany(ctx, otIndexes); err != nil {
+        return err
+    }
+
+    // Contoare de login eșuat: expiră după fereastra de numărare / blocare
+    attemptIndex := mongo.IndexModel{
+        Keys:    bson.M{"expiresAt": 1},
+        Options: options.Index().SetExpireAfterSeconds(0),
+    }
+    if _, err := LoginAttemptCollection(client).Indexes().CreateOne(ctx, attemptIndex); err != nil {
+        return err
+    }
+
+    // Chei API: căutare după hash la fiecare request, listare per user
+    apiKeyIndexes := []mongo.IndexModel{
+        {Keys: bson.M{"keyHash": 1}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+    }
+    if _, err := APIKeyCollection(client).Indexes().CreateMany(ctx, apiKeyIndexes); err != nil {
+        return err
+    }
+
+    // O identitate externă poate fi legată de un singur user; indexul parțial ignoră userii fără identități
+    identityIndex := mongo.IndexModel{
+        Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+        Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+    }
+    if _, err := coll.Indexes().CreateOne(ctx, identityIndex); err != nil {
+        return err
+    }
+
+    // Login-uri OIDC în curs: expiră singure dacă userul nu se mai întoarce din callback
+    stateIndex := mongo.IndexModel{
+        Keys:    bson.M{"expiresAt": 1},
+        Options: options.Index().SetExpireAfterSeconds(0),
+    }
+    if _, err := OIDCStateCollection(client).Indexes().CreateOne(ctx, stateIndex); err != nil {
+        return err
+    }
+
+    // Passkeys: id-ul dat de autentificator e unic global, listare per user
+    credentialIndexes := []mongo.IndexModel{
+        {Keys: bson.M{"credentialId": 1}, Options: options.Index().SetUnique(true)},
+        {Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}}},
+    }
+    if _, err := WebAuthnCredentialCollection(client).Indexes().CreateMany(ctx, credentialIndexes); err != nil {
+        return err
+    }
+
+    // Ceremonii WebAuthn neterminate: expiră singure
+    challengeIndex := mongo.IndexModel{
+        Keys:    bson.M{"expiresAt": 1},
+        Options: options.Index().SetExpireAfterSeconds(0),
+    }
+    if _, err := WebAuthnChallengeCollection(client).Indexes().CreateOne(ctx, challengeIndex); err != nil {
+        return err
+    }
+
+    // Jurnalul de impersonare se păstrează (fără TTL); căutare după admin, user sau token
+    auditIndexes := []mongo.IndexModel{
+        {Keys: bson.D{{Key: "actorId", Value: 1}, {Key: "at", Value: -1}}},
+        {Keys: bson.D{{Key: "subjectId", Value: 1}, {Key: "at", Value: -1}}},
+        {Keys: bson.M{"tokenId": 1}},
+    }
+    if _, err := ImpersonationAuditCollection(client).Indexes().CreateMany(ctx, auditIndexes); err != nil {
+        return err
+    }
+    return emailErr
+}
+
+// dropIndexIfExists removes an index created by an earlier version of CreateIndexes.
+func dropIndexIfExists(ctx context.Context, coll *mongo.Collection, name string) error {
+    specs, err := coll.Indexes().ListSpecifications(ctx)
+    if err != nil {
+        return err
+    }
+    for _, spec := range specs {
+        if spec.Name == name {
+            _, err := coll.Indexes().DropOne(ctx, name)
+            return err
+        }
+    }
+    return nil
+}
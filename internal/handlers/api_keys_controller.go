@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"API-GO/internal/middleware"
+	"API-GO/internal/models"
+	"API-GO/internal/services"
+	"API-GO/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// APIKeysHandler expune CRUD-ul cheilor API ale userului autentificat
+type APIKeysHandler struct {
+    Svc *services.APIKeyService
+}
+
+func NewAPIKeysHandler(svc *services.APIKeyService) *APIKeysHandler {
+    return &APIKeysHandler{Svc: svc}
+}
+
+// GetAll listează cheile userului curent
+func (h *APIKeysHandler) GetAll() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        keys, err := h.Svc.List(ctx, middleware.UserIDFrom(r.Context()))
+        if err != nil {
+            utils.WriteInternalServerError(w, "failed to fetch api keys", err.Error())
+            return
+        }
+        utils.WriteSuccess(w, "api keys retrieved successfully", keys)
+    }
+}
+
+// GetOne returnează o cheie a userului curent
+func (h *APIKeysHandler) GetOne() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        key, err := h.Svc.Get(ctx, middleware.UserIDFrom(r.Context()), mux.Vars(r)["id"])
+        if err != nil {
+            writeAPIKeyError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "api key retrieved successfully", key)
+    }
+}
+
+// Create generează o cheie nouă; valoarea în clar apare doar în acest răspuns
+func (h *APIKeysHandler) Create() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.APIKeyInput
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        created, err := h.Svc.Create(ctx, middleware.UserIDFrom(r.Context()), in)
+        if err != nil {
+            writeAPIKeyError(w, err)
+            return
+        }
+        utils.WriteCreated(w, "api key created; store it now, it will not be shown again", created)
+    }
+}
+
+// Update modifică numele, scope-urile sau expirarea unei chei
+func (h *APIKeysHandler) Update() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.APIKeyInput
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        key, err := h.Svc.Update(ctx, middleware.UserIDFrom(r.Context()), mux.Vars(r)["id"], in)
+        if err != nil {
+            writeAPIKeyError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "api key updated successfully", key)
+    }
+}
+
+// Delete revocă o cheie
+func (h *APIKeysHandler) Delete() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        if err := h.Svc.Delete(ctx, middleware.UserIDFrom(r.Context()), mux.Vars(r)["id"]); err != nil {
+            writeAPIKeyError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "api key deleted successfully", nil)
+    }
+}
+
+func writeAPIKeyError(w http.ResponseWriter, err error) {
+    var ve *services.ValidationError
+    switch {
+    case errors.As(err, &ve):
+        utils.WriteBadRequest(w, err.Error())
+    case errors.Is(err, services.ErrAPIKeyNotFound):
+        utils.WriteNotFound(w, err.Error())
+    default:
+        utils.WriteInternalServerError(w, "api key operation failed", err.Error())
+    }
+}
@@ -3,23 +3,43 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"API-GO/internal/logger"
+	"API-GO/internal/middleware"
 	"API-GO/internal/models"
 	"API-GO/internal/services"
 	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// refreshCookiePath limitează cookie-ul de refresh la rutele de auth
+const refreshCookiePath = "/api-go/v1/auth"
+
 type AuthHandler struct {
     Svc *services.AuthService
+    PasswordReset *services.PasswordResetService
+    EmailVerification *services.EmailVerificationService
+    TwoFactor *services.TwoFactorService
+    OIDC *services.OIDCService
+    MagicLink *services.MagicLinkService
+    WebAuthn *services.WebAuthnService
+    Impersonation *services.ImpersonationService
+    // Ia IP-ul clientului din X-Forwarded-For (doar în spatele unui proxy de încredere)
+    TrustProxyHeaders bool
     CookieName    string
+    RefreshCookieName string
+    // Cookie-ul CSRF (double-submit) emis odată cu sesiunea; gol = fără protecție CSRF
+    CSRFCookieName string
     SecureCookies bool
 }
 
 func NewAuthHandler(svc *services.AuthService, cookieName string, secure bool) *AuthHandler {
-    return &AuthHandler{Svc: svc, CookieName: cookieName, SecureCookies: secure}
+    return &AuthHandler{Svc: svc, CookieName: cookieName, RefreshCookieName: svc.JWT.RefreshCookieName, CSRFCookieName: svc.JWT.CSRFCookieName, SecureCookies: secure}
 }
 
 func (h *AuthHandler) Signup() http.HandlerFunc {
@@ -31,9 +51,14 @@ func (h *AuthHandler) Signup() http.HandlerFunc {
         }
         ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
         defer cancel()
-        user, token, exp, err := h.Svc.SignUp(ctx, in)
+        user, tokens, err := h.Svc.SignUp(ctx, in, h.client(r))
         if err != nil {
-            logger.Warnf("signup_failed", logger.Fields{"error": err.Error(), "email": in.Email})
+            logger.Warnf("signup_failed", logger.Fields{"error": err.Error(), "email_hash": utils.EmailLogHash(in.Email)})
+            var ve *services.ValidationError
+            if errors.As(err, &ve) {
+                utils.WriteBadRequest(w, err.Error())
+                return
+            }
             switch err.Error() {
             case "email already exists", "phone number already exists":
                 utils.WriteConflict(w, err.Error())
@@ -44,8 +69,12 @@ func (h *AuthHandler) Signup() http.HandlerFunc {
             }
             return
         }
-        setAuthCookie(w, h.CookieName, token, exp, h.SecureCookies)
         logger.Infof("signup_success", logger.Fields{"user_id": user.ID, "email": user.Email})
+        if tokens == nil {
+            utils.WriteCreated(w, "signed up successfully; please verify your email before logging in", user)
+            return
+        }
+        h.setAuthCookies(w, tokens)
         utils.WriteCreated(w, "signed up successfully", user)
     }
 }
@@ -59,42 +88,341 @@ func (h *AuthHandler) Login() http.HandlerFunc {
         }
         ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
         defer cancel()
-        user, token, exp, err := h.Svc.Login(ctx, in)
+        user, tokens, err := h.Svc.Login(ctx, in, h.client(r))
+        var challenge *services.TwoFactorRequiredError
+        if errors.As(err, &challenge) {
+            logger.Infof("login_2fa_challenge", logger.Fields{"email_hash": utils.EmailLogHash(in.Email)})
+            utils.WriteSuccess(w, "two-factor authentication required", challenge.Challenge)
+            return
+        }
         if err != nil {
-            logger.Warnf("login_failed", logger.Fields{"error": err.Error(), "email": in.Email})
+            logger.Warnf("login_failed", logger.Fields{"error": err.Error(), "email_hash": utils.EmailLogHash(in.Email)})
+            var rl *services.RateLimitError
+            if errors.As(err, &rl) {
+                writeRateLimited(w, rl)
+                return
+            }
+            if isAccountBlocked(err) {
+                utils.WriteForbidden(w, err.Error())
+                return
+            }
+            if errors.Is(err, services.ErrEmailNotVerified) {
+                utils.WriteForbidden(w, "email not verified; a new verification link has been sent")
+                return
+            }
+            if errors.Is(err, services.ErrTemporaryPasswordExpired) {
+                utils.WriteUnauthorized(w, "temporary password has expired; use forgot password to choose a new one")
+                return
+            }
             utils.WriteUnauthorized(w, "invalid credentials")
             return
         }
-        setAuthCookie(w, h.CookieName, token, exp, h.SecureCookies)
+        h.setAuthCookies(w, tokens)
         logger.Infof("login_success", logger.Fields{"user_id": user.ID, "email": user.Email})
         utils.WriteSuccess(w, "logged in successfully", user)
     }
 }
 
+// Refresh schimbă refresh token-ul (din cookie sau body) pe o pereche nouă de token-uri
+func (h *AuthHandler) Refresh() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        raw := h.refreshTokenFrom(r)
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        user, tokens, err := h.Svc.Refresh(ctx, raw, h.client(r))
+        if err != nil {
+            logger.Warnf("refresh_failed", logger.Fields{"error": err.Error(), "request_id": logger.RequestIDFrom(r.Context())})
+            if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+                h.clearAuthCookies(w)
+                utils.WriteUnauthorized(w, err.Error())
+                return
+            }
+            if isAccountBlocked(err) {
+                h.clearAuthCookies(w)
+                utils.WriteForbidden(w, err.Error())
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to refresh session", err.Error())
+            return
+        }
+        h.setAuthCookies(w, tokens)
+        utils.WriteSuccess(w, "session refreshed successfully", user)
+    }
+}
+
 func (h *AuthHandler) Logout() http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-        // Clear cookie
-        http.SetCookie(w, &http.Cookie{
-            Name:     h.CookieName,
-            Value:    "",
-            Path:     "/",
-            Expires:  time.Unix(0, 0),
-            MaxAge:   -1,
-            HttpOnly: true,
-            Secure:   h.SecureCookies,
-            SameSite: http.SameSiteLaxMode,
-        })
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        access := middleware.AccessTokenFrom(r, h.CookieName)
+        if err := h.Svc.Logout(ctx, access, h.refreshTokenFrom(r)); err != nil {
+            logger.Warnf("logout_revoke_failed", logger.Fields{"error": err.Error()})
+        }
+        h.clearAuthCookies(w)
         logger.Infof("logout", logger.Fields{})
         utils.WriteNoContent(w)
     }
 }
 
-func setAuthCookie(w http.ResponseWriter, name, token string, exp time.Time, secure bool) {
+// LogoutAll invalidează toate sesiunile userului autentificat; body opțional { before } (RFC3339)
+func (h *AuthHandler) LogoutAll() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.LogoutAllRequest
+        if r.ContentLength > 0 {
+            if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+                utils.WriteBadRequest(w, "invalid request body", err.Error())
+                return
+            }
+        }
+        userID := middleware.UserIDFrom(r.Context())
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        if err := h.Svc.LogoutEverywhere(ctx, userID, in.Before); err != nil {
+            utils.WriteInternalServerError(w, "failed to revoke sessions", err.Error())
+            return
+        }
+        h.clearAuthCookies(w)
+        utils.WriteNoContent(w)
+    }
+}
+
+// ChangePassword schimbă parola userului autentificat și reemite cookie-urile sesiunii curente
+func (h *AuthHandler) ChangePassword() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.ChangePasswordRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        user, tokens, err := h.Svc.ChangePassword(ctx, middleware.UserIDFrom(r.Context()), in, h.client(r))
+        if err != nil {
+            var ve *services.ValidationError
+            if errors.As(err, &ve) {
+                utils.WriteBadRequest(w, err.Error())
+                return
+            }
+            var rl *services.RateLimitError
+            if errors.As(err, &rl) {
+                writeRateLimited(w, rl)
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to change password", err.Error())
+            return
+        }
+        h.setAuthCookies(w, tokens)
+        utils.WriteSuccess(w, "password changed successfully; other sessions were signed out", user)
+    }
+}
+
+// ForgotPassword trimite (asincron) un link de resetare; răspunsul e mereu același ca să nu dezvăluie conturile existente
+func (h *AuthHandler) ForgotPassword() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.ForgotPasswordRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        if !utils.IsValidEmail(in.Email) {
+            utils.WriteBadRequest(w, "invalid email format")
+            return
+        }
+        // Limitarea se aplică oricărui email, deci 429 nu dezvăluie dacă acel cont există
+        if err := h.PasswordReset.CheckRequest(r.Context(), in.Email, h.client(r).IP); err != nil {
+            var rl *services.RateLimitError
+            if errors.As(err, &rl) {
+                logger.Warnf("password_reset_throttled", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "email_hash": utils.EmailLogHash(in.Email)})
+                writeRateLimited(w, rl)
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to request a password reset", err.Error())
+            return
+        }
+        rid := logger.RequestIDFrom(r.Context())
+        go func(email string) {
+            ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+            defer cancel()
+            if err := h.PasswordReset.RequestReset(ctx, email); err != nil {
+                logger.Errorf("password_reset_request_failed", logger.Fields{"request_id": rid, "email_hash": utils.EmailLogHash(email), "error": err.Error()})
+            }
+        }(in.Email)
+        utils.WriteAccepted(w, "if the email is registered, a reset link has been sent", nil)
+    }
+}
+
+// ResetPassword setează parola nouă pe baza token-ului din email
+func (h *AuthHandler) ResetPassword() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.ResetPasswordRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        if err := h.PasswordReset.ResetPassword(ctx, in); err != nil {
+            var ve *services.ValidationError
+            switch {
+            case errors.Is(err, services.ErrInvalidResetToken), errors.As(err, &ve):
+                utils.WriteBadRequest(w, err.Error())
+            default:
+                utils.WriteInternalServerError(w, "failed to reset password", err.Error())
+            }
+            return
+        }
+        h.clearAuthCookies(w)
+        utils.WriteSuccess(w, "password reset successfully; please log in again", nil)
+    }
+}
+
+// VerifyEmail confirmă adresa pe baza token-ului din link (GET ?token= sau POST { token })
+func (h *AuthHandler) VerifyEmail() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        token := r.URL.Query().Get("token")
+        if token == "" && r.Method == http.MethodPost {
+            var in models.VerifyEmailRequest
+            if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+                utils.WriteBadRequest(w, "invalid request body", err.Error())
+                return
+            }
+            token = in.Token
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        u, err := h.EmailVerification.Verify(ctx, token)
+        if err != nil {
+            if errors.Is(err, services.ErrInvalidVerificationToken) {
+                utils.WriteBadRequest(w, err.Error())
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to verify email", err.Error())
+            return
+        }
+        // Token-urile existente au încă email_verified=false; clientul obține unele noi prin /auth/refresh
+        utils.WriteSuccess(w, "email verified successfully", map[string]interface{}{"id": u.ID.Hex(), "email": u.Email, "emailVerified": true})
+    }
+}
+
+// ResendVerification retrimite linkul de verificare userului autentificat
+func (h *AuthHandler) ResendVerification() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        oid, err := primitive.ObjectIDFromHex(middleware.UserIDFrom(r.Context()))
+        if err != nil {
+            utils.WriteUnauthorized(w, "authentication required")
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        u, err := h.Svc.Users.GetByID(ctx, oid)
+        if err != nil {
+            utils.WriteNotFound(w, "user not found")
+            return
+        }
+        if err := h.EmailVerification.Resend(ctx, u); err != nil {
+            var rl *services.RateLimitError
+            switch {
+            case errors.As(err, &rl):
+                writeRateLimited(w, rl)
+            case errors.Is(err, services.ErrEmailAlreadyVerified):
+                utils.WriteConflict(w, err.Error())
+            default:
+                utils.WriteInternalServerError(w, "failed to send verification email", err.Error())
+            }
+            return
+        }
+        utils.WriteAccepted(w, "verification email sent", nil)
+    }
+}
+
+// writeRateLimited răspunde 429 cu headerul Retry-After (secunde, rotunjit în sus)
+func writeRateLimited(w http.ResponseWriter, e *services.RateLimitError) {
+    secs := int((e.RetryAfter + time.Second - 1) / time.Second)
+    if secs < 1 {
+        secs = 1
+    }
+    w.Header().Set("Retry-After", strconv.Itoa(secs))
+    utils.WriteTooManyRequests(w, e.Msg)
+}
+
+// isAccountBlocked spune dacă eroarea vine de la un cont suspendat sau dezactivat (răspuns 403)
+func isAccountBlocked(err error) bool {
+    return errors.Is(err, services.ErrAccountSuspended) || errors.Is(err, services.ErrAccountDisabled)
+}
+
+// client descrie clientul cererii (IP, User-Agent) pentru limitări și lista de sesiuni
+func (h *AuthHandler) client(r *http.Request) models.ClientInfo {
+    return models.ClientInfo{IP: middleware.ClientIP(r, h.TrustProxyHeaders), UserAgent: r.UserAgent()}
+}
+
+// refreshTokenFrom citește refresh token-ul din cookie sau, pentru clienți fără cookie, din body
+func (h *AuthHandler) refreshTokenFrom(r *http.Request) string {
+    if c, err := r.Cookie(h.RefreshCookieName); err == nil && c.Value != "" {
+        return c.Value
+    }
+    var in models.RefreshRequest
+    if r.Body != nil && json.NewDecoder(r.Body).Decode(&in) == nil {
+        return in.RefreshToken
+    }
+    return ""
+}
+
+func (h *AuthHandler) setAuthCookies(w http.ResponseWriter, t *models.TokenPair) {
+    setAuthCookie(w, h.CookieName, "/", t.AccessToken, t.AccessExpiresAt, h.SecureCookies)
+    setAuthCookie(w, h.RefreshCookieName, refreshCookiePath, t.RefreshToken, t.RefreshExpiresAt, h.SecureCookies)
+    h.setCSRFCookie(w, t.RefreshExpiresAt)
+}
+
+func (h *AuthHandler) clearAuthCookies(w http.ResponseWriter) {
+    clearCookie(w, h.CookieName, "/", h.SecureCookies)
+    clearCookie(w, h.RefreshCookieName, refreshCookiePath, h.SecureCookies)
+    if h.CSRFCookieName != "" {
+        clearCookie(w, h.CSRFCookieName, "/", h.SecureCookies)
+    }
+}
+
+// setCSRFCookie emite un token CSRF nou la fiecare sesiune emisă. Cookie-ul nu e HttpOnly:
+// clientul îl citește și îl trimite înapoi în headerul X-CSRF-Token (îl primește și în răspuns).
+func (h *AuthHandler) setCSRFCookie(w http.ResponseWriter, exp time.Time) {
+    if h.CSRFCookieName == "" {
+        return
+    }
+    token, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        logger.Errorf("csrf_token_failed", logger.Fields{"error": err.Error()})
+        return
+    }
     http.SetCookie(w, &http.Cookie{
-        Name:     name,
+        Name:     h.CSRFCookieName,
         Value:    token,
         Path:     "/",
         Expires:  exp,
+        HttpOnly: false,
+        Secure:   h.SecureCookies,
+        SameSite: http.SameSiteLaxMode,
+    })
+    w.Header().Set(middleware.CSRFHeader, token)
+}
+
+func setAuthCookie(w http.ResponseWriter, name, path, token string, exp time.Time, secure bool) {
+    http.SetCookie(w, &http.Cookie{
+        Name:     name,
+        Value:    token,
+        Path:     path,
+        Expires:  exp,
+        HttpOnly: true,
+        Secure:   secure,
+        SameSite: http.SameSiteLaxMode,
+    })
+}
+
+func clearCookie(w http.ResponseWriter, name, path string, secure bool) {
+    http.SetCookie(w, &http.Cookie{
+        Name:     name,
+        Value:    "",
+        Path:     path,
+        Expires:  time.Unix(0, 0),
+        MaxAge:   -1,
         HttpOnly: true,
         Secure:   secure,
         SameSite: http.SameSiteLaxMode,
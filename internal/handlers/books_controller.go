@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"API-GO/internal/middleware"
 	"API-GO/internal/models"
 	"API-GO/internal/repository"
 	"API-GO/internal/utils"
@@ -90,6 +91,9 @@ func (h *BooksHandler) Update() http.HandlerFunc {
             }
         }
         delete(payload, "id")
+        // Coșul se gestionează doar prin DELETE și restore
+        delete(payload, "deletedAt")
+        delete(payload, "deletedBy")
         if len(payload) == 0 { utils.WriteBadRequest(w, "no valid fields to update"); return }
         ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
         defer cancel()
@@ -107,9 +111,49 @@ func (h *BooksHandler) Delete() http.HandlerFunc {
         if err != nil { utils.WriteBadRequest(w, "invalid book ID format"); return }
         ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
         defer cancel()
-        ok, err := h.Repo.DeleteByID(ctx, oid)
+        ok, err := h.Repo.SoftDelete(ctx, oid, middleware.UserIDFrom(r.Context()), time.Now())
         if err != nil { utils.WriteInternalServerError(w, "failed to delete book", err.Error()); return }
         if !ok { utils.WriteNotFound(w, "book not found"); return }
         utils.WriteSuccess(w, "book deleted successfully", nil)
     }
 }
+
+// GetTrash lists deleted books, with the same query parameters as GetAll.
+func (h *BooksHandler) GetTrash() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        allowed := map[string]string{
+            "title": "string",
+            "author": "string",
+            "genre": "string",
+            "yearPublished": "int",
+            "deletedBy": "string",
+        }
+        allowedSort := map[string]bool{ "title": true, "author": true, "deletedAt": true }
+        q := utils.ParseListQuery(r, allowed, allowedSort, "-deletedAt", 20, 100)
+        items, total, err := h.Repo.ListDeleted(ctx, q)
+        if err != nil { utils.WriteInternalServerError(w, "failed to fetch deleted books", err.Error()); return }
+        resp := map[string]interface{}{
+            "items": items,
+            "page":  q.Page,
+            "limit": q.Limit,
+            "total": total,
+        }
+        utils.WriteSuccess(w, "deleted books retrieved successfully", resp)
+    }
+}
+
+func (h *BooksHandler) Restore() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        idParam := mux.Vars(r)["id"]
+        oid, err := primitive.ObjectIDFromHex(idParam)
+        if err != nil { utils.WriteBadRequest(w, "invalid book ID format"); return }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        ok, err := h.Repo.Restore(ctx, oid)
+        if err != nil { utils.WriteInternalServerError(w, "failed to restore book", err.Error()); return }
+        if !ok { utils.WriteNotFound(w, "deleted book not found"); return }
+        utils.WriteSuccess(w, "book restored successfully", nil)
+    }
+}
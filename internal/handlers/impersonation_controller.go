@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"API-GO/internal/middleware"
+	"API-GO/internal/models"
+	"API-GO/internal/services"
+	"API-GO/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// StartImpersonation emite un token de impersonare pentru userul {id} (doar admin).
+// Token-ul se întoarce în body, nu în cookie, ca sesiunea adminului să rămână neatinsă.
+func (h *AuthHandler) StartImpersonation() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.ImpersonationRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        claims, _ := middleware.ClaimsFrom(r.Context())
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        tok, err := h.Impersonation.Start(ctx, claims, mux.Vars(r)["id"], in, h.client(r))
+        var ve *services.ValidationError
+        switch {
+        case err == nil:
+            utils.WriteCreated(w, "impersonation started", tok)
+        case errors.As(err, &ve):
+            utils.WriteBadRequest(w, err.Error())
+        case errors.Is(err, services.ErrImpersonationTargetNotFound):
+            utils.WriteNotFound(w, err.Error())
+        case errors.Is(err, services.ErrImpersonationNotAllowed), isAccountBlocked(err):
+            utils.WriteForbidden(w, err.Error())
+        default:
+            utils.WriteInternalServerError(w, "failed to start impersonation", err.Error())
+        }
+    }
+}
+
+// StopImpersonation revocă token-ul de impersonare folosit la request
+func (h *AuthHandler) StopImpersonation() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        claims, _ := middleware.ClaimsFrom(r.Context())
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        if err := h.Impersonation.Stop(ctx, claims, h.client(r)); err != nil {
+            if errors.Is(err, services.ErrNotImpersonating) {
+                utils.WriteBadRequest(w, err.Error())
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to stop impersonation", err.Error())
+            return
+        }
+        utils.WriteSuccess(w, "impersonation stopped", nil)
+    }
+}
+
+// ListImpersonationAudit listează jurnalul de impersonare (doar admin)
+func (h *AuthHandler) ListImpersonationAudit() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        allowed := map[string]string{
+            "event":     "string",
+            "actorId":   "string",
+            "subjectId": "string",
+            "tokenId":   "string",
+            "method":    "string",
+            "path":      "string",
+            "status":    "int",
+        }
+        allowedSort := map[string]bool{"at": true, "status": true}
+        q := utils.ParseListQuery(r, allowed, allowedSort, "-at", 50, 200)
+        items, total, err := h.Impersonation.ListAudit(ctx, q)
+        if err != nil {
+            utils.WriteInternalServerError(w, "failed to fetch impersonation audit", err.Error())
+            return
+        }
+        resp := map[string]interface{}{
+            "items": items,
+            "page":  q.Page,
+            "limit": q.Limit,
+            "total": total,
+        }
+        utils.WriteSuccess(w, "impersonation audit retrieved successfully", resp)
+    }
+}
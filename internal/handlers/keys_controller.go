@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"API-GO/internal/utils"
+)
+
+// KeysHandler publishes the public keys used to verify our access tokens.
+type KeysHandler struct {
+    JWT *utils.JWTManager
+}
+
+func NewKeysHandler(jwt *utils.JWTManager) *KeysHandler {
+    return &KeysHandler{JWT: jwt}
+}
+
+// JWKS serves the raw JWK Set (RFC 7517), not wrapped in APIResponse, so standard JWT libraries can consume it.
+// With HS256 only, the set is empty since a shared secret is never published.
+func (h *KeysHandler) JWKS() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        set, err := h.JWT.JWKS()
+        if err != nil {
+            utils.WriteInternalServerError(w, "failed to build JWKS", err.Error())
+            return
+        }
+        w.Header().Set("Cache-Control", "public, max-age=300")
+        utils.WriteJSON(w, http.StatusOK, set)
+    }
+}
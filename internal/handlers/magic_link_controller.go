@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/models"
+	"API-GO/internal/services"
+	"API-GO/internal/utils"
+)
+
+// RequestMagicLink trimite (asincron) un link de login; răspunsul e mereu același ca să nu dezvăluie conturile existente
+func (h *AuthHandler) RequestMagicLink() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.MagicLinkRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        if !utils.IsValidEmail(in.Email) {
+            utils.WriteBadRequest(w, "invalid email format")
+            return
+        }
+        // Limitarea se aplică oricărui email, deci 429 nu dezvăluie dacă acel cont există
+        if err := h.MagicLink.CheckRequest(r.Context(), in.Email, h.client(r).IP); err != nil {
+            var rl *services.RateLimitError
+            if errors.As(err, &rl) {
+                logger.Warnf("magic_link_request_throttled", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "email_hash": utils.EmailLogHash(in.Email)})
+                writeRateLimited(w, rl)
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to request a login link", err.Error())
+            return
+        }
+        rid := logger.RequestIDFrom(r.Context())
+        go func(email string) {
+            ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+            defer cancel()
+            if err := h.MagicLink.RequestLink(ctx, email); err != nil {
+                var rl *services.RateLimitError
+                if errors.As(err, &rl) {
+                    logger.Warnf("magic_link_throttled", logger.Fields{"request_id": rid, "email_hash": utils.EmailLogHash(email), "reason": rl.Msg})
+                    return
+                }
+                logger.Errorf("magic_link_request_failed", logger.Fields{"request_id": rid, "email_hash": utils.EmailLogHash(email), "error": err.Error()})
+            }
+        }(in.Email)
+        utils.WriteAccepted(w, "if the email is registered, a login link has been sent", nil)
+    }
+}
+
+// ConsumeMagicLink schimbă token-ul din link pe cookie-urile de sesiune
+func (h *AuthHandler) ConsumeMagicLink() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.MagicLinkConsumeRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        user, tokens, err := h.MagicLink.Consume(ctx, in.Token, h.client(r))
+        var challenge *services.TwoFactorRequiredError
+        if errors.As(err, &challenge) {
+            utils.WriteSuccess(w, "two-factor authentication required", challenge.Challenge)
+            return
+        }
+        if err != nil {
+            if errors.Is(err, services.ErrInvalidMagicLink) {
+                utils.WriteUnauthorized(w, err.Error())
+                return
+            }
+            if isAccountBlocked(err) {
+                utils.WriteForbidden(w, err.Error())
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to log in", err.Error())
+            return
+        }
+        h.setAuthCookies(w, tokens)
+        logger.Infof("login_success", logger.Fields{"user_id": user.ID, "email": user.Email, "method": "magic_link"})
+        utils.WriteSuccess(w, "logged in successfully", user)
+    }
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/services"
+	"API-GO/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// Cookie-ul care leagă state-ul OIDC de browserul care a pornit login-ul (anti login-CSRF)
+const (
+    oidcStateCookie     = "oidc_state"
+    oidcStateCookiePath = "/api-go/v1/auth/oidc"
+)
+
+// OIDCLogin redirecționează către furnizorul extern
+func (h *AuthHandler) OIDCLogin() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        authURL, state, err := h.OIDC.BeginLogin(ctx, mux.Vars(r)["provider"])
+        if err != nil {
+            if errors.Is(err, services.ErrUnknownProvider) {
+                utils.WriteNotFound(w, err.Error())
+                return
+            }
+            utils.WriteError(w, http.StatusBadGateway, "failed to start external login", err.Error())
+            return
+        }
+        setAuthCookie(w, oidcStateCookie, oidcStateCookiePath, state, time.Now().Add(h.OIDC.StateTTL), h.SecureCookies)
+        http.Redirect(w, r, authURL, http.StatusFound)
+    }
+}
+
+// OIDCCallback finalizează login-ul extern și setează cookie-urile de sesiune
+func (h *AuthHandler) OIDCCallback() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        provider := mux.Vars(r)["provider"]
+        q := r.URL.Query()
+        clearCookie(w, oidcStateCookie, oidcStateCookiePath, h.SecureCookies)
+        if e := q.Get("error"); e != "" {
+            logger.Warnf("oidc_provider_error", logger.Fields{"provider": provider, "error": e, "description": q.Get("error_description")})
+            utils.WriteUnauthorized(w, "external login was cancelled or denied", e)
+            return
+        }
+        state := q.Get("state")
+        c, err := r.Cookie(oidcStateCookie)
+        if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(c.Value), []byte(state)) != 1 {
+            utils.WriteBadRequest(w, services.ErrInvalidOIDCState.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        user, tokens, err := h.OIDC.CompleteLogin(ctx, provider, state, q.Get("code"), h.client(r))
+        var challenge *services.TwoFactorRequiredError
+        if errors.As(err, &challenge) {
+            utils.WriteSuccess(w, "two-factor authentication required", challenge.Challenge)
+            return
+        }
+        if err != nil {
+            switch {
+            case errors.Is(err, services.ErrUnknownProvider):
+                utils.WriteNotFound(w, err.Error())
+            case errors.Is(err, services.ErrInvalidOIDCState):
+                utils.WriteBadRequest(w, err.Error())
+            case errors.Is(err, services.ErrOIDCLoginFailed), errors.Is(err, services.ErrOIDCEmailNotVerified):
+                utils.WriteUnauthorized(w, err.Error())
+            case errors.Is(err, services.ErrNoLinkedAccount), isAccountBlocked(err):
+                utils.WriteForbidden(w, err.Error())
+            case errors.Is(err, services.ErrIdentityConflict):
+                utils.WriteConflict(w, err.Error())
+            default:
+                utils.WriteInternalServerError(w, "failed to complete external login", err.Error())
+            }
+            return
+        }
+        h.setAuthCookies(w, tokens)
+        logger.Infof("login_success", logger.Fields{"user_id": user.ID, "email": user.Email, "provider": provider})
+        utils.WriteSuccess(w, "logged in successfully", user)
+    }
+}
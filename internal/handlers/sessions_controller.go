@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"API-GO/internal/middleware"
+	"API-GO/internal/services"
+	"API-GO/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// Me întoarce userul autentificat plus rolurile și expirarea din access token
+func (h *AuthHandler) Me() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        claims, _ := middleware.ClaimsFrom(r.Context())
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        me, err := h.Svc.Me(ctx, claims)
+        if err != nil {
+            utils.WriteNotFound(w, "user not found")
+            return
+        }
+        utils.WriteSuccess(w, "current user retrieved successfully", me)
+    }
+}
+
+// ListSessions listează sesiunile active ale userului (dispozitiv, IP, user agent, creare / ultima activitate)
+func (h *AuthHandler) ListSessions() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        claims, _ := middleware.ClaimsFrom(r.Context())
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        sessions, err := h.Svc.ListSessions(ctx, claims.UserID, claims.SessionID)
+        if err != nil {
+            utils.WriteInternalServerError(w, "failed to fetch sessions", err.Error())
+            return
+        }
+        utils.WriteSuccess(w, "sessions retrieved successfully", sessions)
+    }
+}
+
+// RevokeSession închide o sesiune; pentru sesiunea curentă șterge și cookie-urile
+func (h *AuthHandler) RevokeSession() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        claims, _ := middleware.ClaimsFrom(r.Context())
+        id := mux.Vars(r)["id"]
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        if err := h.Svc.RevokeSession(ctx, claims.UserID, id); err != nil {
+            if errors.Is(err, services.ErrSessionNotFound) {
+                utils.WriteNotFound(w, err.Error())
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to revoke session", err.Error())
+            return
+        }
+        if id == claims.SessionID {
+            h.clearAuthCookies(w)
+        }
+        utils.WriteSuccess(w, "session revoked successfully", nil)
+    }
+}
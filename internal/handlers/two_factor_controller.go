@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"API-GO/internal/middleware"
+	"API-GO/internal/models"
+	"API-GO/internal/services"
+	"API-GO/internal/utils"
+)
+
+// LoginTwoFactor e al doilea pas de login: schimbă challenge token-ul + codul TOTP pe sesiunea normală
+func (h *AuthHandler) LoginTwoFactor() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.TwoFactorLoginRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        user, tokens, err := h.TwoFactor.CompleteLogin(ctx, in, h.client(r))
+        if err != nil {
+            var rl *services.RateLimitError
+            if errors.As(err, &rl) {
+                writeRateLimited(w, rl)
+                return
+            }
+            if errors.Is(err, services.ErrInvalidChallenge) || errors.Is(err, services.ErrInvalidTwoFactorCode) {
+                utils.WriteUnauthorized(w, err.Error())
+                return
+            }
+            if isAccountBlocked(err) {
+                utils.WriteForbidden(w, err.Error())
+                return
+            }
+            utils.WriteInternalServerError(w, "failed to complete login", err.Error())
+            return
+        }
+        h.setAuthCookies(w, tokens)
+        utils.WriteSuccess(w, "logged in successfully", user)
+    }
+}
+
+// SetupTwoFactor pornește înrolarea TOTP
+func (h *AuthHandler) SetupTwoFactor() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        setup, err := h.TwoFactor.BeginSetup(ctx, middleware.UserIDFrom(r.Context()))
+        if err != nil {
+            writeTwoFactorError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "scan the QR code and confirm with a code", setup)
+    }
+}
+
+// ConfirmTwoFactor activează 2FA și întoarce codurile de recuperare (afișate o singură dată)
+func (h *AuthHandler) ConfirmTwoFactor() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.TwoFactorCodeRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        codes, err := h.TwoFactor.ConfirmSetup(ctx, middleware.UserIDFrom(r.Context()), in.Code)
+        if err != nil {
+            writeTwoFactorError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "two-factor authentication enabled; store the recovery codes safely", models.RecoveryCodesResponse{RecoveryCodes: codes})
+    }
+}
+
+// DisableTwoFactor dezactivează 2FA (parolă + cod)
+func (h *AuthHandler) DisableTwoFactor() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.TwoFactorDisableRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        if err := h.TwoFactor.Disable(ctx, middleware.UserIDFrom(r.Context()), in, h.client(r)); err != nil {
+            writeTwoFactorError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "two-factor authentication disabled", nil)
+    }
+}
+
+// RegenerateRecoveryCodes înlocuiește codurile de recuperare
+func (h *AuthHandler) RegenerateRecoveryCodes() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.TwoFactorCodeRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        codes, err := h.TwoFactor.RegenerateRecoveryCodes(ctx, middleware.UserIDFrom(r.Context()), in.Code, h.client(r))
+        if err != nil {
+            writeTwoFactorError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "recovery codes regenerated", models.RecoveryCodesResponse{RecoveryCodes: codes})
+    }
+}
+
+// GetTwoFactorPolicy întoarce rolurile pentru care 2FA e obligatoriu (admin)
+func (h *AuthHandler) GetTwoFactorPolicy() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        settings, err := h.TwoFactor.Policy(ctx)
+        if err != nil {
+            utils.WriteInternalServerError(w, "failed to load security settings", err.Error())
+            return
+        }
+        utils.WriteSuccess(w, "two-factor policy retrieved successfully", settings)
+    }
+}
+
+// SetTwoFactorPolicy setează rolurile pentru care 2FA e obligatoriu (admin), body { roles: [...] }
+func (h *AuthHandler) SetTwoFactorPolicy() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.RolesInput
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        settings, err := h.TwoFactor.SetRequiredRoles(ctx, in.Roles, middleware.UserIDFrom(r.Context()))
+        if err != nil {
+            writeTwoFactorError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "two-factor policy updated successfully", settings)
+    }
+}
+
+func writeTwoFactorError(w http.ResponseWriter, err error) {
+    var ve *services.ValidationError
+    var rl *services.RateLimitError
+    switch {
+    case errors.As(err, &rl):
+        writeRateLimited(w, rl)
+    case errors.As(err, &ve), errors.Is(err, services.ErrInvalidTwoFactorCode):
+        utils.WriteBadRequest(w, err.Error())
+    case errors.Is(err, services.ErrTwoFactorEnabled), errors.Is(err, services.ErrTwoFactorNotEnabled):
+        utils.WriteConflict(w, err.Error())
+    default:
+        utils.WriteInternalServerError(w, "two-factor operation failed", err.Error())
+    }
+}
@@ -3,12 +3,17 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"API-GO/internal/logger"
+	"API-GO/internal/middleware"
 	"API-GO/internal/models"
 	"API-GO/internal/repository"
+	"API-GO/internal/services"
 	"API-GO/internal/utils"
 
 	"github.com/gorilla/mux"
@@ -18,38 +23,56 @@ import (
 // UsersHandler lucrează prin repository pentru consistență și testabilitate
 type UsersHandler struct {
     Repo repository.UserRepository
+    // Opțional: la ștergere, token-urile userului sunt revocate imediat
+    Revocations repository.TokenRevocationRepository
+    // Crearea de useri de către admin și schimbarea stării conturilor
+    Admin *services.UserAdminService
 }
 
 func NewUsersHandler(repo repository.UserRepository) *UsersHandler {
     return &UsersHandler{Repo: repo}
 }
 
-// GetAllUsers returnează toți userii
+// GetAllUsers returnează userii cu filtrare, sortare și paginare (ca la cărți)
 func (h *UsersHandler) GetAllUsers() http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
         ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
         defer cancel()
-        users, err := h.Repo.List(ctx)
+        allowed := map[string]string{
+            "name":  "string",
+            "email": "string",
+            "phone": "string",
+        }
+        allowedSort := map[string]bool{"name": true, "email": true}
+        q := utils.ParseListQueryNormalized(r, allowed, allowedSort, "name", 20, 100, userFilterNormalizers)
+        users, total, err := h.Repo.ListWithQuery(ctx, q)
         if err != nil {
             utils.WriteInternalServerError(w, "failed to fetch users", err.Error())
             return
         }
-        for i := range users {
-            users[i].Password = ""
+        resp := map[string]interface{}{
+            "items": users,
+            "page":  q.Page,
+            "limit": q.Limit,
+            "total": total,
         }
-        utils.WriteSuccess(w, "users retrieved successfully", users)
+        utils.WriteSuccess(w, "users retrieved successfully", resp)
     }
 }
 
-// GetUser returnează un user după ID
+// GetUser returnează un user după ID ("me" = userul autentificat)
 func (h *UsersHandler) GetUser() http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-        idParam := mux.Vars(r)["id"]
-        objID, err := primitive.ObjectIDFromHex(idParam)
+        objID, err := pathUserID(r)
         if err != nil {
             utils.WriteBadRequest(w, "invalid user ID format")
             return
         }
+        // Profilul complet (istoricul stării, identitățile externe) e vizibil doar proprietarului și adminilor
+        if !canModifyUser(r, objID) {
+            utils.WriteForbidden(w, "you can only view your own account")
+            return
+        }
         ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
         defer cancel()
         user, err := h.Repo.GetByID(ctx, objID)
@@ -62,27 +85,74 @@ func (h *UsersHandler) GetUser() http.HandlerFunc {
     }
 }
 
-// CreateUser a fost dezactivat: crearea de utilizatori se face doar prin /auth/signup
+// CreateUser creează un cont (doar admin), cu invitație pe email sau cu parolă temporară.
+// Utilizatorii obișnuiți își fac cont prin /auth/signup.
 func (h *UsersHandler) CreateUser() http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-    utils.WriteMethodNotAllowed(w, "creating users via this endpoint is disabled; use /auth/signup")
+        var in models.AdminCreateUserRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        created, err := h.Admin.Create(ctx, middleware.UserIDFrom(r.Context()), in)
+        if err != nil {
+            writeUserAdminError(w, err, "failed to create user")
+            return
+        }
+        if in.TemporaryPassword {
+            utils.WriteCreated(w, "user created; share the temporary password securely, it is not shown again", created)
+            return
+        }
+        if !created.InviteSent {
+            utils.WriteCreated(w, "user created, but the invitation email could not be sent", created)
+            return
+        }
+        utils.WriteCreated(w, "user created; an invitation has been sent", created)
+    }
+}
+
+// SetStatus schimbă starea unui cont (doar admin); motivul e obligatoriu și rămâne în istoricul userului
+func (h *UsersHandler) SetStatus(status string) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.StatusChangeRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        change, err := h.Admin.SetStatus(ctx, middleware.UserIDFrom(r.Context()), mux.Vars(r)["id"], status, in)
+        if err != nil {
+            writeUserAdminError(w, err, "failed to change account status")
+            return
+        }
+        utils.WriteSuccess(w, "account status updated successfully", change)
     }
 }
 
-// UpdateUser modifică un user existent
+// UpdateUser modifică un user existent (doar proprietarul contului sau un admin)
 func (h *UsersHandler) UpdateUser() http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-        idParam := mux.Vars(r)["id"]
-        objID, err := primitive.ObjectIDFromHex(idParam)
+        objID, err := pathUserID(r)
         if err != nil {
             utils.WriteBadRequest(w, "invalid user ID format")
             return
         }
+        if !canModifyUser(r, objID) {
+            utils.WriteForbidden(w, "you can only modify your own account")
+            return
+        }
         var update models.UserInput
         if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
             utils.WriteBadRequest(w, "invalid request body", err.Error())
             return
         }
+        if update.Password != "" || update.PasswordConfirm != "" {
+            utils.WriteBadRequest(w, "password cannot be changed here; use /auth/change-password")
+            return
+        }
         fields := map[string]interface{}{}
         // Validare + verificări în paralel pentru email și telefon
         type chk struct{ exists bool; err error }
@@ -90,6 +160,12 @@ func (h *UsersHandler) UpdateUser() http.HandlerFunc {
         var wgV sync.WaitGroup
         // Email
         if update.Email != "" {
+            // Emailul e folosit la resetarea parolei; nu poate fi schimbat în numele userului
+            if claims, _ := middleware.ClaimsFrom(r.Context()); claims != nil && claims.Impersonated() {
+                utils.WriteForbidden(w, "email cannot be changed while impersonating a user")
+                return
+            }
+            update.Email = utils.NormalizeEmail(update.Email)
             if !utils.IsValidEmail(update.Email) {
                 utils.WriteBadRequest(w, "invalid email format")
                 return
@@ -104,10 +180,12 @@ func (h *UsersHandler) UpdateUser() http.HandlerFunc {
         }
         // Phone
         if update.Phone != "" {
-            if !utils.IsValidPhone(update.Phone) {
-                utils.WriteBadRequest(w, "invalid phone format (use +40xxxxxxxxx or 07xxxxxxxx)")
+            phone, err := utils.NormalizePhone(update.Phone)
+            if err != nil {
+                utils.WriteBadRequest(w, utils.PhoneFormatHint)
                 return
             }
+            update.Phone = phone
             wgV.Add(1)
             go func(phone string) {
                 defer wgV.Done()
@@ -151,6 +229,12 @@ func (h *UsersHandler) UpdateUser() http.HandlerFunc {
         }
         ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
         defer cancel()
+        // O adresă nouă de email trebuie confirmată din nou
+        if update.Email != "" {
+            if current, err := h.Repo.GetByID(ctx, objID); err == nil && !strings.EqualFold(current.Email, update.Email) {
+                fields["emailVerified"] = false
+            }
+        }
         ok, err := h.Repo.UpdateFields(ctx, objID, fields)
         if err != nil {
             utils.WriteInternalServerError(w, "failed to update user", err.Error())
@@ -164,8 +248,8 @@ func (h *UsersHandler) UpdateUser() http.HandlerFunc {
     }
 }
 
-// DeleteUser șterge un user după ID
-func (h *UsersHandler) DeleteUser() http.HandlerFunc {
+// SetRoles înlocuiește rolurile unui user (doar admin)
+func (h *UsersHandler) SetRoles() http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
         idParam := mux.Vars(r)["id"]
         objID, err := primitive.ObjectIDFromHex(idParam)
@@ -173,9 +257,60 @@ func (h *UsersHandler) DeleteUser() http.HandlerFunc {
             utils.WriteBadRequest(w, "invalid user ID format")
             return
         }
+        var in models.RolesInput
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        if len(in.Roles) == 0 {
+            utils.WriteBadRequest(w, "at least one role is required")
+            return
+        }
+        seen := map[string]bool{}
+        roles := make([]string, 0, len(in.Roles))
+        for _, role := range in.Roles {
+            if !models.IsValidRole(role) {
+                utils.WriteBadRequest(w, "invalid role: "+role)
+                return
+            }
+            if !seen[role] {
+                seen[role] = true
+                roles = append(roles, role)
+            }
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        ok, err := h.Repo.UpdateFields(ctx, objID, map[string]interface{}{"roles": roles})
+        if err != nil {
+            utils.WriteInternalServerError(w, "failed to update roles", err.Error())
+            return
+        }
+        if !ok {
+            utils.WriteNotFound(w, "user not found")
+            return
+        }
+        logger.Infof("user_roles_updated", logger.Fields{"user_id": idParam, "roles": roles, "by": middleware.UserIDFrom(r.Context())})
+        utils.WriteSuccess(w, "roles updated successfully", map[string]interface{}{"roles": roles})
+    }
+}
+
+// DeleteUser mută un user în coș (doar proprietarul contului sau un admin); sesiunile lui se închid
+func (h *UsersHandler) DeleteUser() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        objID, err := pathUserID(r)
+        if err != nil {
+            utils.WriteBadRequest(w, "invalid user ID format")
+            return
+        }
+        if !canModifyUser(r, objID) {
+            utils.WriteForbidden(w, "you can only delete your own account")
+            return
+        }
         ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
         defer cancel()
-        ok, err := h.Repo.DeleteByID(ctx, objID)
+        now := time.Now()
+        by := middleware.UserIDFrom(r.Context())
+        ok, err := h.Repo.SoftDelete(ctx, objID, by, now)
         if err != nil {
             utils.WriteInternalServerError(w, "failed to delete user", err.Error())
             return
@@ -184,6 +319,114 @@ func (h *UsersHandler) DeleteUser() http.HandlerFunc {
             utils.WriteNotFound(w, "user not found")
             return
         }
+        if h.Revocations != nil {
+            if err := h.Revocations.RevokeUserTokensBefore(ctx, objID.Hex(), now); err != nil {
+                logger.Errorf("user_delete_revoke_failed", logger.Fields{"user_id": objID.Hex(), "error": err.Error()})
+            }
+        }
+        logger.Infof("user_deleted", logger.Fields{"user_id": objID.Hex(), "by": by})
         utils.WriteSuccess(w, "user deleted successfully", nil)
     }
-}
\ No newline at end of file
+}
+
+// ListDeletedUsers listează userii din coș (doar admin), cu aceiași parametri ca lista de useri
+func (h *UsersHandler) ListDeletedUsers() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        allowed := map[string]string{
+            "name":      "string",
+            "email":     "string",
+            "phone":     "string",
+            "deletedBy": "string",
+        }
+        allowedSort := map[string]bool{"name": true, "email": true, "deletedAt": true}
+        q := utils.ParseListQueryNormalized(r, allowed, allowedSort, "-deletedAt", 20, 100, userFilterNormalizers)
+        users, total, err := h.Repo.ListDeleted(ctx, q)
+        if err != nil {
+            utils.WriteInternalServerError(w, "failed to fetch deleted users", err.Error())
+            return
+        }
+        resp := map[string]interface{}{
+            "items": users,
+            "page":  q.Page,
+            "limit": q.Limit,
+            "total": total,
+        }
+        utils.WriteSuccess(w, "deleted users retrieved successfully", resp)
+    }
+}
+
+// RestoreUser scoate un user din coș (doar admin); 409 dacă emailul sau telefonul a fost preluat între timp
+func (h *UsersHandler) RestoreUser() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        objID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+        if err != nil {
+            utils.WriteBadRequest(w, "invalid user ID format")
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        ok, err := h.Repo.Restore(ctx, objID)
+        if errors.Is(err, repository.ErrRestoreConflict) {
+            utils.WriteConflict(w, "email or phone number is already used by another account")
+            return
+        }
+        if err != nil {
+            utils.WriteInternalServerError(w, "failed to restore user", err.Error())
+            return
+        }
+        if !ok {
+            utils.WriteNotFound(w, "deleted user not found")
+            return
+        }
+        logger.Infof("user_restored", logger.Fields{"user_id": objID.Hex(), "by": middleware.UserIDFrom(r.Context())})
+        utils.WriteSuccess(w, "user restored successfully", nil)
+    }
+}
+
+// pathUserID citește {id} din path; "me" (sau ruta /users/me) înseamnă userul autentificat
+func pathUserID(r *http.Request) (primitive.ObjectID, error) {
+    idParam, ok := mux.Vars(r)["id"]
+    if !ok || idParam == "me" {
+        idParam = middleware.UserIDFrom(r.Context())
+    }
+    return primitive.ObjectIDFromHex(idParam)
+}
+
+// userFilterNormalizers aduc ?email= și ?phone= la forma în care sunt salvate (litere mici, E.164),
+// și atunci când ?q= adaugă o căutare globală
+var userFilterNormalizers = map[string]func(string) string{
+    "email": utils.NormalizeEmail,
+    "phone": func(phone string) string {
+        if normalized, err := utils.NormalizePhone(phone); err == nil {
+            return normalized
+        }
+        return phone
+    },
+}
+
+// canModifyUser permite accesul la cont (citire completă, modificare) doar proprietarului sau unui admin
+func canModifyUser(r *http.Request, id primitive.ObjectID) bool {
+    claims, ok := middleware.ClaimsFrom(r.Context())
+    if !ok {
+        return false
+    }
+    return claims.UserID == id.Hex() || claims.HasAnyRole(models.RoleAdmin)
+}
+
+func writeUserAdminError(w http.ResponseWriter, err error, msg string) {
+    var ve *services.ValidationError
+    switch {
+    case errors.As(err, &ve):
+        utils.WriteBadRequest(w, err.Error())
+    case errors.Is(err, services.ErrUserNotFound):
+        utils.WriteNotFound(w, err.Error())
+    case errors.Is(err, services.ErrStatusChangeNotAllowed):
+        utils.WriteForbidden(w, err.Error())
+    case errors.Is(err, services.ErrEmailExists), errors.Is(err, services.ErrPhoneExists):
+        utils.WriteConflict(w, err.Error())
+    default:
+        utils.WriteInternalServerError(w, msg, err.Error())
+    }
+}
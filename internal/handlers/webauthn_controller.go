@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/middleware"
+	"API-GO/internal/models"
+	"API-GO/internal/services"
+	"API-GO/internal/utils"
+	"API-GO/internal/webauthn"
+
+	"github.com/gorilla/mux"
+)
+
+// BeginPasskeyRegistration întoarce opțiunile pentru navigator.credentials.create
+func (h *AuthHandler) BeginPasskeyRegistration() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        opts, err := h.WebAuthn.BeginRegistration(ctx, middleware.UserIDFrom(r.Context()))
+        if err != nil {
+            writeWebAuthnError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "passkey registration started", opts)
+    }
+}
+
+// FinishPasskeyRegistration verifică răspunsul browserului și salvează passkey-ul
+func (h *AuthHandler) FinishPasskeyRegistration() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.WebAuthnRegistrationRequest
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        cred, err := h.WebAuthn.FinishRegistration(ctx, middleware.UserIDFrom(r.Context()), in)
+        if err != nil {
+            writeWebAuthnError(w, err)
+            return
+        }
+        utils.WriteCreated(w, "passkey registered successfully", cred)
+    }
+}
+
+// BeginPasskeyLogin întoarce opțiunile pentru navigator.credentials.get (body opțional { email })
+func (h *AuthHandler) BeginPasskeyLogin() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in models.WebAuthnLoginBeginRequest
+        if r.ContentLength != 0 {
+            if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+                utils.WriteBadRequest(w, "invalid request body", err.Error())
+                return
+            }
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        opts, err := h.WebAuthn.BeginLogin(ctx, in.Email)
+        if err != nil {
+            writeWebAuthnError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "passkey login started", opts)
+    }
+}
+
+// FinishPasskeyLogin verifică semnătura passkey-ului și setează cookie-urile ca /auth/login
+func (h *AuthHandler) FinishPasskeyLogin() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        var in webauthn.AssertionResponse
+        if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+            utils.WriteBadRequest(w, "invalid request body", err.Error())
+            return
+        }
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        user, tokens, err := h.WebAuthn.FinishLogin(ctx, in, h.client(r))
+        var challenge *services.TwoFactorRequiredError
+        if errors.As(err, &challenge) {
+            utils.WriteSuccess(w, "two-factor authentication required", challenge.Challenge)
+            return
+        }
+        if err != nil {
+            writeWebAuthnError(w, err)
+            return
+        }
+        h.setAuthCookies(w, tokens)
+        logger.Infof("login_success", logger.Fields{"user_id": user.ID, "email": user.Email, "method": "passkey"})
+        utils.WriteSuccess(w, "logged in successfully", user)
+    }
+}
+
+// ListPasskeys listează passkey-urile utilizatorului autentificat
+func (h *AuthHandler) ListPasskeys() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        creds, err := h.WebAuthn.ListCredentials(ctx, middleware.UserIDFrom(r.Context()))
+        if err != nil {
+            writeWebAuthnError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "passkeys retrieved successfully", creds)
+    }
+}
+
+// DeletePasskey șterge un passkey al utilizatorului autentificat
+func (h *AuthHandler) DeletePasskey() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+        defer cancel()
+        if err := h.WebAuthn.DeleteCredential(ctx, middleware.UserIDFrom(r.Context()), mux.Vars(r)["id"]); err != nil {
+            writeWebAuthnError(w, err)
+            return
+        }
+        utils.WriteSuccess(w, "passkey deleted successfully", nil)
+    }
+}
+
+func writeWebAuthnError(w http.ResponseWriter, err error) {
+    var ve *services.ValidationError
+    switch {
+    case errors.As(err, &ve), errors.Is(err, services.ErrInvalidWebAuthnChallenge):
+        utils.WriteBadRequest(w, err.Error())
+    case errors.Is(err, services.ErrWebAuthnFailed):
+        utils.WriteUnauthorized(w, err.Error())
+    case errors.Is(err, services.ErrEmailNotVerified), isAccountBlocked(err):
+        utils.WriteForbidden(w, err.Error())
+    case errors.Is(err, services.ErrCredentialExists):
+        utils.WriteConflict(w, err.Error())
+    case errors.Is(err, services.ErrCredentialNotFound):
+        utils.WriteNotFound(w, err.Error())
+    default:
+        utils.WriteInternalServerError(w, "passkey operation failed", err.Error())
+    }
+}
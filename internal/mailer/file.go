@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"API-GO/internal/logger"
+)
+
+const maxKept = 100
+
+// FileMailer writes each message to Dir as a .eml file; with an empty Dir it only logs them.
+// Meant for local development and tests; Sent keeps the most recent messages for inspection.
+type FileMailer struct {
+    Dir string
+
+    mu   sync.Mutex
+    Sent []Message
+}
+
+func NewFileMailer(dir string) *FileMailer {
+    return &FileMailer{Dir: dir}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+    m.mu.Lock()
+    m.Sent = append(m.Sent, msg)
+    if len(m.Sent) > maxKept {
+        m.Sent = m.Sent[len(m.Sent)-maxKept:]
+    }
+    m.mu.Unlock()
+
+    if m.Dir == "" {
+        logger.Infof("mail_logged", logger.Fields{"to": msg.To, "subject": msg.Subject, "body": msg.Body})
+        return nil
+    }
+    if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+        return err
+    }
+    name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), filepath.Base(sanitizeHeader(msg.To)))
+    content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", msg.To, msg.Subject, msg.Body)
+    if err := os.WriteFile(filepath.Join(m.Dir, name), []byte(content), 0o644); err != nil {
+        return err
+    }
+    logger.Infof("mail_written", logger.Fields{"to": msg.To, "subject": msg.Subject, "file": name})
+    return nil
+}
+
+// Last returns the most recent message sent to the given address.
+func (m *FileMailer) Last(to string) (Message, bool) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    for i := len(m.Sent) - 1; i >= 0; i-- {
+        if m.Sent[i].To == to {
+            return m.Sent[i], true
+        }
+    }
+    return Message{}, false
+}
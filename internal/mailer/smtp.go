@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends mail through an SMTP server using PLAIN auth when credentials are set.
+type SMTPMailer struct {
+    Host     string
+    Port     int
+    Username string
+    Password string
+    From     string
+}
+
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+    return &SMTPMailer{Host: host, Port: port, Username: username, Password: password, From: from}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+    var auth smtp.Auth
+    if m.Username != "" {
+        auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+    }
+    addr := net.JoinHostPort(m.Host, fmt.Sprint(m.Port))
+    // net/smtp has no context support; run it in a goroutine so ctx cancellation is honoured
+    done := make(chan error, 1)
+    go func() {
+        done <- smtp.SendMail(addr, auth, m.From, []string{msg.To}, m.format(msg))
+    }()
+    select {
+    case err := <-done:
+        return err
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+func (m *SMTPMailer) format(msg Message) []byte {
+    var b strings.Builder
+    fmt.Fprintf(&b, "From: %s\r\n", m.From)
+    fmt.Fprintf(&b, "To: %s\r\n", sanitizeHeader(msg.To))
+    fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(msg.Subject))
+    fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+    b.WriteString("MIME-Version: 1.0\r\n")
+    b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+    b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+    return []byte(b.String())
+}
+
+// sanitizeHeader strips CR/LF to prevent header injection.
+func sanitizeHeader(s string) string {
+    return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,367 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+)
+
+type ctxKey string
+
+const claimsKey ctxKey = "user_claims"
+
+// WithClaims attaches the authenticated user's claims to the context.
+func WithClaims(ctx context.Context, c *utils.UserClaims) context.Context {
+    return context.WithValue(ctx, claimsKey, c)
+}
+
+// ClaimsFrom returns the claims stored in the context by Authenticator.
+func ClaimsFrom(ctx context.Context) (*utils.UserClaims, bool) {
+    c, ok := ctx.Value(claimsKey).(*utils.UserClaims)
+    return c, ok && c != nil
+}
+
+// UserIDFrom returns the authenticated user ID, or "" for anonymous requests.
+func UserIDFrom(ctx context.Context) string {
+    if c, ok := ClaimsFrom(ctx); ok {
+        return c.UserID
+    }
+    return ""
+}
+
+// APIKeyHeader carries API keys for machine-to-machine clients.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyResolver turns a raw API key into its owner's claims, including the key's scopes.
+// It returns nil claims for unknown or expired keys; errors mean the lookup itself failed.
+type APIKeyResolver interface {
+    Authenticate(ctx context.Context, rawKey string) (*utils.UserClaims, error)
+}
+
+// AccountChecker reports whether a user's account may still be used (it exists and is not
+// suspended or disabled). Errors mean the lookup itself failed.
+type AccountChecker interface {
+    AccountActive(ctx context.Context, userID string) (bool, error)
+}
+
+// Authenticator validates the JWT sent in the auth cookie or the Authorization header.
+// When Revocations is set, tokens revoked server-side are rejected as well.
+// Accounts that are not complete yet (unverified email under EmailPolicy "restrict", a
+// pending mandatory 2FA enrollment, or a temporary password that must be changed) only pass
+// routes wrapped with AllowIncomplete.
+// API keys (X-API-Key) are accepted only by an Authenticator obtained through WithScope.
+// When JWT.CSRFCookieName is set, state-changing requests authenticated by the session
+// cookie must also pass the double-submit CSRF check; Bearer and API-key callers are exempt.
+// Impersonation tokens are accepted only when Audit is set: every such request is recorded
+// there and its response carries ImpersonationHeader. Authenticators obtained through Direct
+// refuse them. Public routes wrapped with Observe audit them the same way.
+// When Accounts is set, requests from suspended, disabled or deleted accounts are refused with 403.
+type Authenticator struct {
+    JWT         *utils.JWTManager
+    Revocations repository.TokenRevocationRepository
+    EmailPolicy string
+    APIKeys     APIKeyResolver
+    Audit       repository.ImpersonationAuditRepository
+    Accounts    AccountChecker
+    // Used for the client IP recorded in the impersonation audit
+    TrustProxyHeaders bool
+    // Scope an API key needs on this route; empty means API keys are refused
+    scope string
+    // Refuse impersonation tokens on this route
+    direct bool
+}
+
+// ImpersonationHeader is set on every response to a request made with an impersonation token;
+// it holds the email of the admin acting as the user.
+const ImpersonationHeader = "X-Impersonated-By"
+
+func NewAuthenticator(jwt *utils.JWTManager, revocations repository.TokenRevocationRepository) *Authenticator {
+    return &Authenticator{JWT: jwt, Revocations: revocations}
+}
+
+// WithScope returns a copy of the authenticator that also accepts API keys granted scope.
+// Session tokens are unaffected.
+func (a *Authenticator) WithScope(scope string) *Authenticator {
+    cp := *a
+    cp.scope = scope
+    return &cp
+}
+
+// Direct returns a copy of the authenticator that refuses impersonation tokens, for routes
+// that change the account's credentials or sessions.
+func (a *Authenticator) Direct() *Authenticator {
+    cp := *a
+    cp.direct = true
+    return &cp
+}
+
+// Require lets the request through only with a valid token; otherwise it answers 401.
+func (a *Authenticator) Require(next http.Handler) http.Handler {
+    return a.authenticate(next, false)
+}
+
+// AllowIncomplete is Require without the account completeness checks, for the routes an
+// incomplete account still needs (profile, resend verification, 2FA enrollment, logout).
+func (a *Authenticator) AllowIncomplete(next http.Handler) http.Handler {
+    return a.authenticate(next, true)
+}
+
+// Observe is for public routes: every request reaches next as before, but one made with an
+// impersonation token is audited and marked like on guarded routes (or refused on a Direct
+// authenticator). Other tokens, valid or not, are ignored and no claims are attached.
+func (a *Authenticator) Observe(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        token, _ := accessToken(r, a.JWT.CookieName)
+        if token == "" {
+            next.ServeHTTP(w, r)
+            return
+        }
+        // Revoked impersonation tokens are audited too: the attempt is what matters here
+        claims, err := a.JWT.ParseToken(token)
+        if err != nil || !claims.Impersonated() {
+            next.ServeHTTP(w, r)
+            return
+        }
+        a.serveImpersonated(w, r, next, claims)
+    })
+}
+
+func (a *Authenticator) authenticate(next http.Handler, allowIncomplete bool) http.Handler {
+    if !allowIncomplete {
+        next = a.requireComplete(next)
+    }
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        var claims *utils.UserClaims
+        if key := r.Header.Get(APIKeyHeader); key != "" {
+            claims = a.apiKeyClaims(w, r, key)
+        } else {
+            claims = a.tokenClaims(w, r)
+        }
+        if claims == nil || !a.accountActive(w, r, claims) {
+            return
+        }
+        r = r.WithContext(WithClaims(r.Context(), claims))
+        if claims.Impersonated() {
+            a.serveImpersonated(w, r, next, claims)
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// accountActive checks the account behind claims; on failure it writes the response and returns false.
+func (a *Authenticator) accountActive(w http.ResponseWriter, r *http.Request, claims *utils.UserClaims) bool {
+    if a.Accounts == nil {
+        return true
+    }
+    active, err := a.Accounts.AccountActive(r.Context(), claims.UserID)
+    if err != nil {
+        logger.Errorf("auth_account_check_failed", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "error": err.Error()})
+        utils.WriteServiceUnavailable(w, "unable to verify account")
+        return false
+    }
+    if !active {
+        logger.Warnf("auth_account_inactive", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "user_id": claims.UserID})
+        utils.WriteForbidden(w, "account is not active")
+        return false
+    }
+    return true
+}
+
+// requireComplete refuses accounts that are not complete yet (see Authenticator).
+func (a *Authenticator) requireComplete(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        claims, _ := ClaimsFrom(r.Context())
+        if !claims.EmailVerified && a.EmailPolicy == models.EmailPolicyRestrict {
+            utils.WriteForbidden(w, "email not verified")
+            return
+        }
+        if claims.TwoFactorSetupRequired {
+            utils.WriteForbidden(w, "two-factor authentication must be enabled for your role")
+            return
+        }
+        if claims.PasswordChangeRequired {
+            utils.WriteForbidden(w, "temporary password must be changed")
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// serveImpersonated marks the response and records the request in the impersonation audit,
+// including requests refused because the route does not allow impersonation.
+func (a *Authenticator) serveImpersonated(w http.ResponseWriter, r *http.Request, next http.Handler, claims *utils.UserClaims) {
+    if a.Audit == nil {
+        utils.WriteForbidden(w, "impersonation is not enabled")
+        return
+    }
+    w.Header().Set(ImpersonationHeader, claims.Actor.Email)
+    sw := &statusWriter{ResponseWriter: w}
+    if a.direct {
+        utils.WriteForbidden(sw, "not allowed while impersonating a user")
+    } else {
+        next.ServeHTTP(sw, r)
+    }
+    if sw.status == 0 {
+        sw.status = http.StatusOK
+    }
+    rid := logger.RequestIDFrom(r.Context())
+    entry := models.ImpersonationAuditEntry{
+        Event:        models.ImpersonationEventRequest,
+        ActorID:      claims.Actor.UserID,
+        ActorEmail:   claims.Actor.Email,
+        SubjectID:    claims.UserID,
+        SubjectEmail: claims.Email,
+        TokenID:      claims.ID,
+        Method:       r.Method,
+        Path:         r.URL.Path,
+        Query:        r.URL.RawQuery,
+        Status:       sw.status,
+        IP:           ClientIP(r, a.TrustProxyHeaders),
+        UserAgent:    r.UserAgent(),
+        RequestID:    rid,
+        At:           time.Now(),
+    }
+    // The request context may already be cancelled once the response is written
+    ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 5*time.Second)
+    defer cancel()
+    if err := a.Audit.Record(ctx, &entry); err != nil {
+        logger.Errorf("impersonation_audit_failed", logger.Fields{"request_id": rid, "token_id": claims.ID, "error": err.Error()})
+    }
+}
+
+// tokenClaims validates the session token; on failure it writes the response and returns nil.
+func (a *Authenticator) tokenClaims(w http.ResponseWriter, r *http.Request) *utils.UserClaims {
+    token, fromCookie := accessToken(r, a.JWT.CookieName)
+    if token == "" {
+        utils.WriteUnauthorized(w, "authentication required")
+        return nil
+    }
+    claims, err := a.JWT.ParseToken(token)
+    if err != nil {
+        logger.Debugf("auth_token_rejected", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "error": err.Error()})
+        utils.WriteUnauthorized(w, "invalid or expired token")
+        return nil
+    }
+    revoked, err := a.isRevoked(r.Context(), claims)
+    if err != nil {
+        logger.Errorf("auth_revocation_check_failed", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "error": err.Error()})
+        utils.WriteServiceUnavailable(w, "unable to verify token")
+        return nil
+    }
+    if revoked {
+        utils.WriteUnauthorized(w, "token has been revoked")
+        return nil
+    }
+    if fromCookie && a.JWT.CSRFCookieName != "" && !ValidCSRF(r, a.JWT.CSRFCookieName) {
+        logger.Warnf("auth_csrf_rejected", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "user_id": claims.UserID, "method": r.Method, "path": r.URL.Path})
+        utils.WriteForbidden(w, "missing or invalid CSRF token")
+        return nil
+    }
+    return claims
+}
+
+// apiKeyClaims resolves an X-API-Key and checks the route's scope; on failure it writes the response and returns nil.
+func (a *Authenticator) apiKeyClaims(w http.ResponseWriter, r *http.Request, key string) *utils.UserClaims {
+    if a.APIKeys == nil || a.scope == "" {
+        utils.WriteUnauthorized(w, "API keys are not accepted on this route")
+        return nil
+    }
+    claims, err := a.APIKeys.Authenticate(r.Context(), key)
+    if err != nil {
+        logger.Errorf("auth_api_key_lookup_failed", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "error": err.Error()})
+        utils.WriteServiceUnavailable(w, "unable to verify API key")
+        return nil
+    }
+    if claims == nil {
+        utils.WriteUnauthorized(w, "invalid or expired API key")
+        return nil
+    }
+    if !claims.HasScope(a.scope) {
+        logger.Warnf("auth_api_key_scope_denied", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "key_id": claims.APIKeyID, "scope": a.scope})
+        utils.WriteForbidden(w, "API key lacks the required scope: "+a.scope)
+        return nil
+    }
+    return claims
+}
+
+// RequireRoles authenticates the request and then allows it only when the caller
+// holds at least one of the given roles (403 otherwise).
+func (a *Authenticator) RequireRoles(roles ...string) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        check := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            claims, _ := ClaimsFrom(r.Context())
+            if !claims.HasAnyRole(roles...) {
+                logger.Warnf("auth_forbidden", logger.Fields{"request_id": logger.RequestIDFrom(r.Context()), "user_id": claims.UserID, "path": r.URL.Path})
+                utils.WriteForbidden(w, "insufficient permissions")
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+        return a.Require(check)
+    }
+}
+
+// isRevoked checks the token's jti, its session and the user's "logout everywhere" cutoff.
+func (a *Authenticator) isRevoked(ctx context.Context, c *utils.UserClaims) (bool, error) {
+    if a.Revocations == nil {
+        return false, nil
+    }
+    if c.ID != "" {
+        revoked, err := a.Revocations.IsTokenRevoked(ctx, c.ID)
+        if err != nil || revoked {
+            return revoked, err
+        }
+    }
+    if c.SessionID != "" {
+        revoked, err := a.Revocations.IsTokenRevoked(ctx, utils.SessionRevocationKey(c.SessionID))
+        if err != nil || revoked {
+            return revoked, err
+        }
+    }
+    revoked, err := a.issuedBeforeCutoff(ctx, c, c.UserID)
+    if err != nil || revoked || c.Actor == nil {
+        return revoked, err
+    }
+    // The admin logging out everywhere also ends their impersonation tokens
+    return a.issuedBeforeCutoff(ctx, c, c.Actor.UserID)
+}
+
+// issuedBeforeCutoff checks the token against userID's "logout everywhere" cutoff.
+func (a *Authenticator) issuedBeforeCutoff(ctx context.Context, c *utils.UserClaims, userID string) (bool, error) {
+    cutoff, err := a.Revocations.UserTokensRevokedBefore(ctx, userID)
+    if err != nil || cutoff.IsZero() {
+        return false, err
+    }
+    // iat has second precision; tokens from the cutoff second itself stay valid so that a
+    // session re-issued right after the cutoff (e.g. on password change) is not rejected
+    return c.IssuedAt == nil || c.IssuedAt.Time.Before(cutoff.Truncate(time.Second)), nil
+}
+
+// AccessTokenFrom prefers "Authorization: Bearer <token>" and falls back to the given cookie.
+func AccessTokenFrom(r *http.Request, cookieName string) string {
+    token, _ := accessToken(r, cookieName)
+    return token
+}
+
+// accessToken is AccessTokenFrom that also reports whether the token came from the cookie.
+func accessToken(r *http.Request, cookieName string) (string, bool) {
+    if h := r.Header.Get("Authorization"); h != "" {
+        if len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+            return strings.TrimSpace(h[7:]), false
+        }
+        return "", false
+    }
+    if cookieName != "" {
+        if c, err := r.Cookie(cookieName); err == nil {
+            return c.Value, true
+        }
+    }
+    return "", false
+}
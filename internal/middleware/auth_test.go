@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+)
+
+const testUserID = "64b7f0c2a1b2c3d4e5f60718"
+
+func newTestAuthenticator() (*Authenticator, *repository.MemoryTokenRevocationRepository) {
+    revocations := repository.NewMemoryTokenRevocationRepository()
+    jwt := &utils.JWTManager{Secret: []byte("test-secret-with-enough-entropy!"), AccessTTL: 15 * time.Minute}
+    return NewAuthenticator(jwt, revocations), revocations
+}
+
+func issueToken(t *testing.T, a *Authenticator, sub utils.TokenSubject) (string, *utils.UserClaims) {
+    t.Helper()
+    token, _, err := a.JWT.GenerateToken(sub)
+    if err != nil {
+        t.Fatal(err)
+    }
+    claims, err := a.JWT.ParseToken(token)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return token, claims
+}
+
+// serve sends an authenticated request through Require and returns the status code.
+func serve(a *Authenticator, token string) int {
+    h := a.Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
+    req.Header.Set("Authorization", "Bearer "+token)
+    rec := httptest.NewRecorder()
+    h.ServeHTTP(rec, req)
+    return rec.Code
+}
+
+func TestRequireAcceptsValidToken(t *testing.T) {
+    a, _ := newTestAuthenticator()
+    token, _ := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true})
+    if code := serve(a, token); code != http.StatusNoContent {
+        t.Fatalf("status = %d, want %d", code, http.StatusNoContent)
+    }
+}
+
+func TestRequireRejectsRevokedJTI(t *testing.T) {
+    a, revocations := newTestAuthenticator()
+    token, claims := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true})
+    if err := revocations.RevokeToken(context.Background(), claims.ID, testUserID, claims.ExpiresAt.Time); err != nil {
+        t.Fatal(err)
+    }
+    if code := serve(a, token); code != http.StatusUnauthorized {
+        t.Fatalf("status = %d, want %d", code, http.StatusUnauthorized)
+    }
+
+    // Revoking one token leaves the user's other tokens valid
+    other, _ := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true})
+    if code := serve(a, other); code != http.StatusNoContent {
+        t.Fatalf("other token: status = %d, want %d", code, http.StatusNoContent)
+    }
+}
+
+func TestRequireRejectsRevokedSession(t *testing.T) {
+    a, revocations := newTestAuthenticator()
+    token, claims := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true, SessionID: "family-1"})
+    if err := revocations.RevokeToken(context.Background(), utils.SessionRevocationKey("family-1"), testUserID, claims.ExpiresAt.Time); err != nil {
+        t.Fatal(err)
+    }
+    if code := serve(a, token); code != http.StatusUnauthorized {
+        t.Fatalf("status = %d, want %d", code, http.StatusUnauthorized)
+    }
+}
+
+func TestRequireLogoutEverywhereCutoff(t *testing.T) {
+    a, revocations := newTestAuthenticator()
+    ctx := context.Background()
+
+    before, _ := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true})
+    // iat has second precision: a cutoff in the next second is strictly after the token
+    if err := revocations.RevokeUserTokensBefore(ctx, testUserID, time.Now().Add(time.Second)); err != nil {
+        t.Fatal(err)
+    }
+    if code := serve(a, before); code != http.StatusUnauthorized {
+        t.Fatalf("token issued before the cutoff: status = %d, want %d", code, http.StatusUnauthorized)
+    }
+
+    other, _ := issueToken(t, a, utils.TokenSubject{UserID: "64b7f0c2a1b2c3d4e5f60719", EmailVerified: true})
+    if code := serve(a, other); code != http.StatusNoContent {
+        t.Fatalf("other user's token: status = %d, want %d", code, http.StatusNoContent)
+    }
+}
+
+func TestRequireAcceptsTokenIssuedAfterCutoff(t *testing.T) {
+    a, revocations := newTestAuthenticator()
+    if err := revocations.RevokeUserTokensBefore(context.Background(), testUserID, time.Now().Add(-time.Second)); err != nil {
+        t.Fatal(err)
+    }
+    after, _ := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true})
+    if code := serve(a, after); code != http.StatusNoContent {
+        t.Fatalf("token issued after the cutoff: status = %d, want %d", code, http.StatusNoContent)
+    }
+}
+
+func TestRequireCutoffOfImpersonatingAdmin(t *testing.T) {
+    a, revocations := newTestAuthenticator()
+    token, _, err := a.JWT.GenerateImpersonationToken(
+        utils.TokenSubject{UserID: testUserID, EmailVerified: true},
+        utils.TokenActor{UserID: "64b7f0c2a1b2c3d4e5f60720", Email: "admin@example.com"},
+        time.Minute,
+    )
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := revocations.RevokeUserTokensBefore(context.Background(), "64b7f0c2a1b2c3d4e5f60720", time.Now().Add(time.Second)); err != nil {
+        t.Fatal(err)
+    }
+    // Rejected as revoked (401) before impersonation is even considered (403 without Audit)
+    if code := serve(a, token); code != http.StatusUnauthorized {
+        t.Fatalf("status = %d, want %d", code, http.StatusUnauthorized)
+    }
+}
+
+// recordingAudit keeps the entries recorded by the Authenticator.
+type recordingAudit struct {
+    repository.ImpersonationAuditRepository
+    entries []models.ImpersonationAuditEntry
+}
+
+func (r *recordingAudit) Record(ctx context.Context, e *models.ImpersonationAuditEntry) error {
+    r.entries = append(r.entries, *e)
+    return nil
+}
+
+func TestObserveAuditsImpersonationOnPublicRoutes(t *testing.T) {
+    a, _ := newTestAuthenticator()
+    audit := &recordingAudit{}
+    a.Audit = audit
+    public := a.Observe(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if _, ok := ClaimsFrom(r.Context()); ok {
+            t.Error("claims attached on a public route")
+        }
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    call := func(h http.Handler, token string) *httptest.ResponseRecorder {
+        req := httptest.NewRequest(http.MethodGet, "/books?author=x", nil)
+        if token != "" {
+            req.Header.Set("Authorization", "Bearer "+token)
+        }
+        rec := httptest.NewRecorder()
+        h.ServeHTTP(rec, req)
+        return rec
+    }
+
+    // Anonymous, plain and invalid tokens pass untouched and are not audited
+    plain, _ := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true})
+    for _, token := range []string{"", plain, "not-a-jwt"} {
+        if rec := call(public, token); rec.Code != http.StatusNoContent || rec.Header().Get(ImpersonationHeader) != "" {
+            t.Fatalf("token %q: status = %d, header = %q", token, rec.Code, rec.Header().Get(ImpersonationHeader))
+        }
+    }
+    if len(audit.entries) != 0 {
+        t.Fatalf("audited %d requests without impersonation", len(audit.entries))
+    }
+
+    token, _, err := a.JWT.GenerateImpersonationToken(
+        utils.TokenSubject{UserID: testUserID, EmailVerified: true},
+        utils.TokenActor{UserID: "64b7f0c2a1b2c3d4e5f60720", Email: "admin@example.com"},
+        time.Minute,
+    )
+    if err != nil {
+        t.Fatal(err)
+    }
+    rec := call(public, token)
+    if rec.Code != http.StatusNoContent || rec.Header().Get(ImpersonationHeader) != "admin@example.com" {
+        t.Fatalf("status = %d, header = %q", rec.Code, rec.Header().Get(ImpersonationHeader))
+    }
+    if len(audit.entries) != 1 {
+        t.Fatalf("audit entries = %d, want 1", len(audit.entries))
+    }
+    e := audit.entries[0]
+    if e.ActorID != "64b7f0c2a1b2c3d4e5f60720" || e.SubjectID != testUserID || e.Path != "/books" || e.Query != "author=x" || e.Status != http.StatusNoContent {
+        t.Fatalf("unexpected audit entry: %+v", e)
+    }
+
+    // A Direct authenticator refuses the token on its public routes, and still audits the attempt
+    if rec := call(a.Direct().Observe(public), token); rec.Code != http.StatusForbidden {
+        t.Fatalf("direct: status = %d, want %d", rec.Code, http.StatusForbidden)
+    }
+    if len(audit.entries) != 2 || audit.entries[1].Status != http.StatusForbidden {
+        t.Fatalf("refused request not audited: %+v", audit.entries)
+    }
+}
+
+func TestPasswordChangeRequiredOnlyPassesAllowIncomplete(t *testing.T) {
+    a, _ := newTestAuthenticator()
+    token, _ := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true, PasswordChangeRequired: true})
+    if code := serve(a, token); code != http.StatusForbidden {
+        t.Fatalf("Require: status = %d, want %d", code, http.StatusForbidden)
+    }
+    h := a.AllowIncomplete(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    req := httptest.NewRequest(http.MethodPost, "/auth/change-password", nil)
+    req.Header.Set("Authorization", "Bearer "+token)
+    rec := httptest.NewRecorder()
+    h.ServeHTTP(rec, req)
+    if rec.Code != http.StatusNoContent {
+        t.Fatalf("AllowIncomplete: status = %d, want %d", rec.Code, http.StatusNoContent)
+    }
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the caller's IP address. X-Forwarded-For / X-Real-IP are honoured only
+// when trustProxy is set (the server runs behind a proxy that overwrites them); otherwise
+// clients could pick their own address and dodge per-IP limits.
+func ClientIP(r *http.Request, trustProxy bool) string {
+    if trustProxy {
+        if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+            // The left-most entry is the original client
+            if ip := strings.TrimSpace(strings.Split(xff, ",")[0]); ip != "" {
+                return ip
+            }
+        }
+        if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+            return ip
+        }
+    }
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return r.RemoteAddr
+    }
+    return host
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"API-GO/internal/models"
+	"API-GO/internal/utils"
+)
+
+// staticKey resolves any API key to the same owner, granted every scope.
+type staticKey struct{}
+
+func (staticKey) Authenticate(ctx context.Context, rawKey string) (*utils.UserClaims, error) {
+    return &utils.UserClaims{UserID: testUserID, EmailVerified: true, APIKeyID: "key-1", Scopes: []string{models.ScopeBooksWrite}}, nil
+}
+
+func TestValidCSRF(t *testing.T) {
+    tests := []struct {
+        name   string
+        method string
+        cookie string
+        header string
+        want   bool
+    }{
+        {"safe method needs nothing", http.MethodGet, "", "", true},
+        {"matching header", http.MethodPost, "tok", "tok", true},
+        {"missing header", http.MethodPost, "tok", "", false},
+        {"missing cookie", http.MethodDelete, "", "tok", false},
+        {"different values", http.MethodPut, "tok", "other", false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest(tt.method, "/books", nil)
+            if tt.cookie != "" {
+                req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.cookie})
+            }
+            if tt.header != "" {
+                req.Header.Set(CSRFHeader, tt.header)
+            }
+            if got := ValidCSRF(req, "csrf_token"); got != tt.want {
+                t.Fatalf("ValidCSRF = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestCSRFOnlyChecksCookieSessions(t *testing.T) {
+    a, _ := newTestAuthenticator()
+    a.JWT.CookieName, a.JWT.CSRFCookieName = "access_token", "csrf_token"
+    a.APIKeys = staticKey{}
+    token, _ := issueToken(t, a, utils.TokenSubject{UserID: testUserID, EmailVerified: true})
+    h := a.WithScope(models.ScopeBooksWrite).Require(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusNoContent)
+    }))
+
+    tests := []struct {
+        name    string
+        prepare func(r *http.Request)
+        want    int
+    }{
+        {"cookie without CSRF header", func(r *http.Request) {
+            r.AddCookie(&http.Cookie{Name: "access_token", Value: token})
+            r.AddCookie(&http.Cookie{Name: "csrf_token", Value: "tok"})
+        }, http.StatusForbidden},
+        {"cookie with CSRF header", func(r *http.Request) {
+            r.AddCookie(&http.Cookie{Name: "access_token", Value: token})
+            r.AddCookie(&http.Cookie{Name: "csrf_token", Value: "tok"})
+            r.Header.Set(CSRFHeader, "tok")
+        }, http.StatusNoContent},
+        {"bearer is exempt", func(r *http.Request) {
+            r.Header.Set("Authorization", "Bearer "+token)
+        }, http.StatusNoContent},
+        {"API key is exempt", func(r *http.Request) {
+            r.Header.Set(APIKeyHeader, "ak_test")
+        }, http.StatusNoContent},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            req := httptest.NewRequest(http.MethodPost, "/books", nil)
+            tt.prepare(req)
+            rec := httptest.NewRecorder()
+            h.ServeHTTP(rec, req)
+            if rec.Code != tt.want {
+                t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+            }
+        })
+    }
+}
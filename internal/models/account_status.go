@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Stările unui cont; un user fără stare salvată e activ.
+const (
+    UserStatusActive    = "active"
+    UserStatusSuspended = "suspended"
+    UserStatusDisabled  = "disabled"
+)
+
+// IsValidUserStatus verifică dacă starea e una cunoscută
+func IsValidUserStatus(status string) bool {
+    switch status {
+    case UserStatusActive, UserStatusSuspended, UserStatusDisabled:
+        return true
+    }
+    return false
+}
+
+// EffectiveStatus returnează starea contului, cu active ca implicit
+func (u *User) EffectiveStatus() string {
+    if u.Status == "" {
+        return UserStatusActive
+    }
+    return u.Status
+}
+
+// StatusChange este o schimbare de stare, păstrată în istoricul userului
+type StatusChange struct {
+    Status string    `bson:"status" json:"status"`
+    Reason string    `bson:"reason" json:"reason"`
+    By     string    `bson:"by" json:"by"`
+    At     time.Time `bson:"at" json:"at"`
+}
+
+// StatusChangeRequest este payload-ul pentru suspend / disable / reactivate; motivul e obligatoriu
+type StatusChangeRequest struct {
+    Reason string `json:"reason"`
+}
+
+// AdminCreateUserRequest este payload-ul pentru POST /users (admin). Fără TemporaryPassword,
+// userul primește pe email o invitație să-și aleagă parola.
+type AdminCreateUserRequest struct {
+    Name              string   `json:"name"`
+    Email             string   `json:"email"`
+    Phone             string   `json:"phone"`
+    Roles             []string `json:"roles"`
+    TemporaryPassword bool     `json:"temporaryPassword"`
+}
+
+// AdminCreatedUser e răspunsul la crearea unui user de către admin; parola temporară apare o singură dată
+type AdminCreatedUser struct {
+    User              *AuthResponse `json:"user"`
+    TemporaryPassword string        `json:"temporaryPassword,omitempty"`
+    InviteSent        bool          `json:"inviteSent"`
+}
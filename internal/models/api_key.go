@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scopes pentru cheile API; rolurile proprietarului se aplică în continuare
+const (
+    ScopeBooksRead  = "books:read"
+    ScopeBooksWrite = "books:write"
+    ScopeUsersRead  = "users:read"
+    ScopeUsersWrite = "users:write"
+)
+
+// IsValidScope verifică dacă scope-ul e unul cunoscut
+func IsValidScope(scope string) bool {
+    switch scope {
+    case ScopeBooksRead, ScopeBooksWrite, ScopeUsersRead, ScopeUsersWrite:
+        return true
+    }
+    return false
+}
+
+// APIKey este o cheie pentru clienți automați (scripturi, integrări); se salvează doar hash-ul.
+type APIKey struct {
+    ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    UserID     primitive.ObjectID `bson:"userId" json:"userId"`
+    Name       string             `bson:"name" json:"name"`
+    // Primele caractere ale cheii, ca utilizatorul să o poată recunoaște
+    Prefix     string             `bson:"prefix" json:"prefix"`
+    KeyHash    string             `bson:"keyHash" json:"-"`
+    Scopes     []string           `bson:"scopes" json:"scopes"`
+    CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+    ExpiresAt  *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+    LastUsedAt *time.Time         `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+}
+
+// APIKeyInput este payload-ul pentru crearea (name și scopes obligatorii) și actualizarea unei chei
+type APIKeyInput struct {
+    Name      string     `json:"name"`
+    Scopes    []string   `json:"scopes"`
+    ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// APIKeyCreated conține cheia în clar; e afișată o singură dată, la creare
+type APIKeyCreated struct {
+    APIKey
+    Key string `json:"key"`
+}
@@ -20,5 +20,16 @@ type AuthResponse struct {
     ID    string `json:"id"`
     Name  string `json:"name"`
     Email string `json:"email"`
-    Phone string `json:"phone,omitempty"`
+    Phone string   `json:"phone,omitempty"`
+    Roles []string `json:"roles,omitempty"`
+    EmailVerified bool `json:"emailVerified"`
+    // Parola e temporară: singura acțiune permisă e /auth/change-password
+    MustChangePassword bool `json:"mustChangePassword,omitempty"`
+}
+
+// ChangePasswordRequest reprezintă payload-ul pentru /auth/change-password
+type ChangePasswordRequest struct {
+    CurrentPassword    string `json:"currentPassword"`
+    NewPassword        string `json:"newPassword"`
+    NewPasswordConfirm string `json:"newPasswordConfirm"`
 }
@@ -1,6 +1,10 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // Book represents a book document
 type Book struct {
@@ -9,4 +13,7 @@ type Book struct {
     Author        string             `bson:"author" json:"author"`
     YearPublished int                `bson:"yearPublished" json:"yearPublished"`
     Genre         string             `bson:"genre" json:"genre"`
+    // Ștergere soft: cartea stă în coș până la restaurare sau purjare
+    DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+    DeletedBy string     `bson:"deletedBy,omitempty" json:"deletedBy,omitempty"`
 }
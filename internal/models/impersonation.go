@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Evenimentele din jurnalul de impersonare
+const (
+    ImpersonationEventStart   = "start"
+    ImpersonationEventRequest = "request"
+    ImpersonationEventStop    = "stop"
+)
+
+// ImpersonationRequest este payload-ul pentru POST /auth/impersonate/{id}; motivul e obligatoriu (audit)
+type ImpersonationRequest struct {
+    Reason string `json:"reason"`
+    // Durata dorită în minute; 0 = maximul configurat
+    Minutes int `json:"minutes,omitempty"`
+}
+
+// ImpersonationToken e răspunsul la pornirea impersonării; token-ul se trimite ca Bearer
+type ImpersonationToken struct {
+    Token     string        `json:"token"`
+    ExpiresAt time.Time     `json:"expiresAt"`
+    User      *AuthResponse `json:"user"`
+}
+
+// ImpersonationAuditEntry este o intrare din jurnalul de impersonare: pornirea, oprirea
+// sau un request făcut cu token-ul de impersonare.
+type ImpersonationAuditEntry struct {
+    ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    Event        string             `bson:"event" json:"event"`
+    ActorID      string             `bson:"actorId" json:"actorId"`
+    ActorEmail   string             `bson:"actorEmail" json:"actorEmail"`
+    SubjectID    string             `bson:"subjectId" json:"subjectId"`
+    SubjectEmail string             `bson:"subjectEmail" json:"subjectEmail"`
+    // jti-ul token-ului de impersonare; leagă request-urile de pornire
+    TokenID   string    `bson:"tokenId" json:"tokenId"`
+    Reason    string    `bson:"reason,omitempty" json:"reason,omitempty"`
+    Method    string    `bson:"method,omitempty" json:"method,omitempty"`
+    Path      string    `bson:"path,omitempty" json:"path,omitempty"`
+    Query     string    `bson:"query,omitempty" json:"query,omitempty"`
+    Status    int       `bson:"status,omitempty" json:"status,omitempty"`
+    IP        string    `bson:"ip,omitempty" json:"ip,omitempty"`
+    UserAgent string    `bson:"userAgent,omitempty" json:"userAgent,omitempty"`
+    RequestID string    `bson:"requestId,omitempty" json:"requestId,omitempty"`
+    At        time.Time `bson:"at" json:"at"`
+}
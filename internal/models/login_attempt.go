@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LoginAttempts ține evidența încercărilor de login eșuate pentru o cheie (cont sau IP).
+type LoginAttempts struct {
+    Key            string    `bson:"_id"`
+    Failures       int       `bson:"failures"`
+    FirstFailureAt time.Time `bson:"firstFailureAt"`
+    LastFailureAt  time.Time `bson:"lastFailureAt"`
+    LockedUntil    time.Time `bson:"lockedUntil,omitempty"`
+    ExpiresAt      time.Time `bson:"expiresAt"`
+}
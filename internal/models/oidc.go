@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ExternalIdentity leagă un user local de contul lui la un furnizor OpenID Connect.
+type ExternalIdentity struct {
+    Provider string    `bson:"provider" json:"provider"`
+    Subject  string    `bson:"subject" json:"subject"`
+    Email    string    `bson:"email,omitempty" json:"email,omitempty"`
+    LinkedAt time.Time `bson:"linkedAt" json:"linkedAt"`
+}
+
+// OIDCState păstrează între /login și /callback datele unei autentificări OIDC în curs.
+// Cheia e hash-ul parametrului state; documentul se consumă o singură dată.
+type OIDCState struct {
+    StateHash    string    `bson:"_id"`
+    Provider     string    `bson:"provider"`
+    Nonce        string    `bson:"nonce"`
+    CodeVerifier string    `bson:"codeVerifier"`
+    CreatedAt    time.Time `bson:"createdAt"`
+    ExpiresAt    time.Time `bson:"expiresAt"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scopuri pentru token-urile de unică folosință
+const (
+    TokenPurposePasswordReset     = "password_reset"
+    TokenPurposeEmailVerification = "email_verification"
+    TokenPurposeMagicLink         = "magic_link"
+)
+
+// OneTimeToken este un token de unică folosință trimis pe email; se salvează doar hash-ul.
+type OneTimeToken struct {
+    ID        primitive.ObjectID `bson:"_id,omitempty"`
+    UserID    primitive.ObjectID `bson:"userId"`
+    Purpose   string             `bson:"purpose"`
+    TokenHash string             `bson:"tokenHash"`
+    Email     string             `bson:"email,omitempty"`
+    CreatedAt time.Time          `bson:"createdAt"`
+    ExpiresAt time.Time          `bson:"expiresAt"`
+    UsedAt    *time.Time         `bson:"usedAt,omitempty"`
+}
+
+// ForgotPasswordRequest este payload-ul pentru /auth/forgot-password
+type ForgotPasswordRequest struct {
+    Email string `json:"email"`
+}
+
+// MagicLinkRequest este payload-ul pentru /auth/magic-link
+type MagicLinkRequest struct {
+    Email string `json:"email"`
+}
+
+// MagicLinkConsumeRequest este payload-ul pentru /auth/magic-link/consume
+type MagicLinkConsumeRequest struct {
+    Token string `json:"token"`
+}
+
+// ResetPasswordRequest este payload-ul pentru /auth/reset-password
+type ResetPasswordRequest struct {
+    Token           string `json:"token"`
+    Password        string `json:"password"`
+    PasswordConfirm string `json:"passwordConfirm"`
+}
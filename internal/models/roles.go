@@ -0,0 +1,42 @@
+package models
+
+// Roluri suportate; un user fără roluri salvate e tratat ca member.
+const (
+    RoleAdmin     = "admin"
+    RoleLibrarian = "librarian"
+    RoleMember    = "member"
+)
+
+// IsValidRole verifică dacă rolul e unul cunoscut
+func IsValidRole(role string) bool {
+    switch role {
+    case RoleAdmin, RoleLibrarian, RoleMember:
+        return true
+    }
+    return false
+}
+
+// EffectiveRoles returnează rolurile userului, cu member ca implicit
+func (u *User) EffectiveRoles() []string {
+    if len(u.Roles) == 0 {
+        return []string{RoleMember}
+    }
+    return u.Roles
+}
+
+// HasAnyRole verifică dacă userul are cel puțin unul dintre rolurile date
+func (u *User) HasAnyRole(roles ...string) bool {
+    for _, have := range u.EffectiveRoles() {
+        for _, want := range roles {
+            if have == want {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// RolesInput este payload-ul pentru PUT /users/{id}/roles
+type RolesInput struct {
+    Roles []string `json:"roles"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TokenPair grupează access token-ul (JWT) și refresh token-ul emise la autentificare
+type TokenPair struct {
+    AccessToken      string
+    AccessExpiresAt  time.Time
+    RefreshToken     string
+    RefreshExpiresAt time.Time
+}
+
+// RefreshToken este documentul salvat pentru un refresh token; valoarea brută nu se salvează, doar hash-ul.
+// Toate token-urile obținute prin rotație dintr-un login au același FamilyID.
+type RefreshToken struct {
+    ID         primitive.ObjectID  `bson:"_id,omitempty"`
+    UserID     primitive.ObjectID  `bson:"userId"`
+    FamilyID   string              `bson:"familyId"`
+    TokenHash  string              `bson:"tokenHash"`
+    CreatedAt  time.Time           `bson:"createdAt"`
+    ExpiresAt  time.Time           `bson:"expiresAt"`
+    UsedAt     *time.Time          `bson:"usedAt,omitempty"`
+    ReplacedBy *primitive.ObjectID `bson:"replacedBy,omitempty"`
+    RevokedAt  *time.Time          `bson:"revokedAt,omitempty"`
+    // Date despre sesiune (familie): începutul ei și clientul care a emis token-ul
+    SessionStartedAt time.Time `bson:"sessionStartedAt,omitempty"`
+    IP               string    `bson:"ip,omitempty"`
+    UserAgent        string    `bson:"userAgent,omitempty"`
+}
+
+// ClientInfo descrie clientul care face cererea; se salvează pe sesiune
+type ClientInfo struct {
+    IP        string
+    UserAgent string
+}
+
+// Session este o sesiune activă (o familie de refresh token-uri), cum o vede userul
+type Session struct {
+    ID         string    `json:"id"`
+    Device     string    `json:"device"`
+    IP         string    `json:"ip,omitempty"`
+    UserAgent  string    `json:"userAgent,omitempty"`
+    CreatedAt  time.Time `json:"createdAt"`
+    LastSeenAt time.Time `json:"lastSeenAt"`
+    ExpiresAt  time.Time `json:"expiresAt"`
+    Current    bool      `json:"current"`
+}
+
+// TokenInfo descrie access token-ul curent (din UserClaims)
+type TokenInfo struct {
+    Roles     []string  `json:"roles"`
+    IssuedAt  time.Time `json:"issuedAt"`
+    ExpiresAt time.Time `json:"expiresAt"`
+    SessionID string    `json:"sessionId,omitempty"`
+    // Emailul adminului, când token-ul e de impersonare
+    ImpersonatedBy string `json:"impersonatedBy,omitempty"`
+}
+
+// MeResponse este răspunsul pentru GET /auth/me
+type MeResponse struct {
+    AuthResponse
+    Token TokenInfo `json:"token"`
+}
+
+// RefreshRequest permite clienților fără cookie să trimită refresh token-ul în body
+type RefreshRequest struct {
+    RefreshToken string `json:"refreshToken"`
+}
+
+// LogoutAllRequest este body-ul opțional pentru /auth/logout-all; Before gol înseamnă "acum"
+type LogoutAllRequest struct {
+    Before time.Time `json:"before"`
+}
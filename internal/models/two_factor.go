@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// TwoFactorChallenge e răspunsul primului pas de login când userul are 2FA activ
+type TwoFactorChallenge struct {
+    TwoFactorRequired bool      `json:"twoFactorRequired"`
+    ChallengeToken    string    `json:"challengeToken"`
+    ExpiresAt         time.Time `json:"expiresAt"`
+}
+
+// TwoFactorSetupResponse conține secretul TOTP și URI-ul otpauth:// pentru codul QR
+type TwoFactorSetupResponse struct {
+    Secret     string `json:"secret"`
+    OTPAuthURL string `json:"otpauthUrl"`
+}
+
+// TwoFactorCodeRequest e payload-ul pentru confirmarea înrolării și regenerarea codurilor de recuperare
+type TwoFactorCodeRequest struct {
+    Code string `json:"code"`
+}
+
+// TwoFactorLoginRequest e al doilea pas de login: codul TOTP sau un cod de recuperare
+type TwoFactorLoginRequest struct {
+    ChallengeToken string `json:"challengeToken"`
+    Code           string `json:"code"`
+    RecoveryCode   string `json:"recoveryCode"`
+}
+
+// TwoFactorDisableRequest cere parola și un cod valid pentru dezactivarea 2FA
+type TwoFactorDisableRequest struct {
+    Password string `json:"password"`
+    Code     string `json:"code"`
+}
+
+// RecoveryCodesResponse întoarce codurile de recuperare în clar, o singură dată
+type RecoveryCodesResponse struct {
+    RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// SecuritySettings sunt setările de securitate modificabile de admini la runtime
+type SecuritySettings struct {
+    TwoFactorRequiredRoles []string  `bson:"twoFactorRequiredRoles" json:"twoFactorRequiredRoles"`
+    UpdatedAt              time.Time `bson:"updatedAt" json:"updatedAt"`
+    UpdatedBy              string    `bson:"updatedBy,omitempty" json:"updatedBy,omitempty"`
+}
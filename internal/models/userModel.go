@@ -1,6 +1,10 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type User struct {
     ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -8,6 +12,27 @@ type User struct {
     Email    string             `bson:"email" json:"email"`
     Password string             `bson:"password,omitempty" json:"-"`
     Phone    string             `bson:"phone,omitempty" json:"phone"`
+    Roles    []string           `bson:"roles,omitempty" json:"roles,omitempty"`
+    EmailVerified   bool       `bson:"emailVerified" json:"emailVerified"`
+    EmailVerifiedAt *time.Time `bson:"emailVerifiedAt,omitempty" json:"emailVerifiedAt,omitempty"`
+    // 2FA (TOTP): secretul activ, cel în curs de înrolare, ultimul pas folosit (anti-replay) și hash-urile codurilor de recuperare
+    TOTPEnabled       bool     `bson:"totpEnabled" json:"totpEnabled"`
+    TOTPSecret        string   `bson:"totpSecret,omitempty" json:"-"`
+    TOTPPendingSecret string   `bson:"totpPendingSecret,omitempty" json:"-"`
+    TOTPLastStep      int64    `bson:"totpLastStep,omitempty" json:"-"`
+    RecoveryCodes     []string `bson:"recoveryCodes,omitempty" json:"-"`
+    // Parolă temporară dată de admin: până la schimbare contul primește doar acces limitat,
+    // iar după TemporaryPasswordExpiresAt parola nu mai e acceptată la login
+    MustChangePassword         bool       `bson:"mustChangePassword,omitempty" json:"mustChangePassword,omitempty"`
+    TemporaryPasswordExpiresAt *time.Time `bson:"temporaryPasswordExpiresAt,omitempty" json:"-"`
+    // Conturi externe (OIDC) legate de acest user
+    Identities []ExternalIdentity `bson:"identities,omitempty" json:"identities,omitempty"`
+    // Starea contului (active / suspended / disabled) și istoricul schimbărilor, cu motiv
+    Status          string         `bson:"status,omitempty" json:"status,omitempty"`
+    StatusHistory   []StatusChange `bson:"statusHistory,omitempty" json:"statusHistory,omitempty"`
+    // Ștergere soft: userul stă în coș până la restaurare sau purjare
+    DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+    DeletedBy string     `bson:"deletedBy,omitempty" json:"deletedBy,omitempty"`
 }
 
 // DTO pentru Create/Update
@@ -0,0 +1,25 @@
+package models
+
+// Politici pentru conturile cu email neverificat
+const (
+    // EmailPolicyAllow: userii neverificați au acces complet (compatibil cu conturile existente)
+    EmailPolicyAllow = "allow"
+    // EmailPolicyRestrict: userii neverificați se pot loga, dar accesează doar rutele marcate explicit
+    EmailPolicyRestrict = "restrict"
+    // EmailPolicyBlock: login-ul e refuzat până la verificare
+    EmailPolicyBlock = "block"
+)
+
+// IsValidEmailPolicy verifică dacă politica e una cunoscută
+func IsValidEmailPolicy(p string) bool {
+    switch p {
+    case EmailPolicyAllow, EmailPolicyRestrict, EmailPolicyBlock:
+        return true
+    }
+    return false
+}
+
+// VerifyEmailRequest este payload-ul pentru /auth/verify-email
+type VerifyEmailRequest struct {
+    Token string `json:"token"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"API-GO/internal/webauthn"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scopurile ceremoniilor WebAuthn în curs
+const (
+    WebAuthnPurposeRegistration = "registration"
+    WebAuthnPurposeLogin        = "login"
+)
+
+// WebAuthnCredential este o cheie de acces (passkey) înregistrată de un user.
+// CredentialID e id-ul dat de autentificator, în base64url; cheia publică e păstrată în format COSE.
+type WebAuthnCredential struct {
+    ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+    UserID         primitive.ObjectID `bson:"userId" json:"-"`
+    CredentialID   string             `bson:"credentialId" json:"credentialId"`
+    PublicKey      []byte             `bson:"publicKey" json:"-"`
+    Algorithm      int64              `bson:"algorithm" json:"algorithm"`
+    SignCount      uint32             `bson:"signCount" json:"signCount"`
+    AAGUID         string             `bson:"aaguid,omitempty" json:"aaguid,omitempty"`
+    Transports     []string           `bson:"transports,omitempty" json:"transports,omitempty"`
+    BackupEligible bool               `bson:"backupEligible" json:"backupEligible"`
+    BackedUp       bool               `bson:"backedUp" json:"backedUp"`
+    Name           string             `bson:"name" json:"name"`
+    CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+    LastUsedAt     *time.Time         `bson:"lastUsedAt,omitempty" json:"lastUsedAt,omitempty"`
+}
+
+// WebAuthnChallenge păstrează challenge-ul unei ceremonii între begin și finish.
+// Cheia e hash-ul challenge-ului (regăsit în clientDataJSON); documentul se consumă o singură dată.
+type WebAuthnChallenge struct {
+    ChallengeHash string             `bson:"_id"`
+    Purpose       string             `bson:"purpose"`
+    // Userul care înregistrează cheia sau, la login, cel indicat prin email (zero = orice passkey)
+    UserID    primitive.ObjectID `bson:"userId,omitempty"`
+    Challenge []byte             `bson:"challenge"`
+    CreatedAt time.Time          `bson:"createdAt"`
+    ExpiresAt time.Time          `bson:"expiresAt"`
+}
+
+// WebAuthnRegistrationRequest este payload-ul pentru /auth/webauthn/register/finish:
+// răspunsul browserului (RegistrationResponseJSON) plus un nume opțional pentru cheie.
+type WebAuthnRegistrationRequest struct {
+    Name string `json:"name"`
+    webauthn.RegistrationResponse
+}
+
+// WebAuthnLoginBeginRequest este payload-ul pentru /auth/webauthn/login/begin; fără email se acceptă orice passkey.
+type WebAuthnLoginBeginRequest struct {
+    Email string `json:"email"`
+}
@@ -0,0 +1,19 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"API-GO/internal/utils"
+)
+
+// NewCodeVerifier returns a random PKCE code verifier (43 URL-safe characters, RFC 7636 §4.1).
+func NewCodeVerifier() (string, error) {
+    return utils.GenerateOpaqueToken()
+}
+
+// CodeChallenge derives the S256 challenge sent in the authorization request.
+func CodeChallenge(verifier string) string {
+    sum := sha256.Sum256([]byte(verifier))
+    return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,287 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"API-GO/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksRefreshInterval limits how often an unknown "kid" triggers a JWKS re-download.
+const jwksRefreshInterval = time.Minute
+
+// Config describes one external OpenID Connect provider.
+type Config struct {
+    Name         string
+    Issuer       string
+    ClientID     string
+    ClientSecret string
+    RedirectURL  string
+    Scopes       []string
+    // AllowSignup creates a local account when no user matches the verified email
+    AllowSignup bool
+}
+
+// Discovery is the subset of the provider metadata (/.well-known/openid-configuration) we use.
+type Discovery struct {
+    Issuer                string `json:"issuer"`
+    AuthorizationEndpoint string `json:"authorization_endpoint"`
+    TokenEndpoint         string `json:"token_endpoint"`
+    JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse is the token endpoint's answer to an authorization-code exchange.
+type TokenResponse struct {
+    AccessToken string `json:"access_token"`
+    TokenType   string `json:"token_type"`
+    IDToken     string `json:"id_token"`
+    ExpiresIn   int    `json:"expires_in"`
+}
+
+// Bool accepts both JSON booleans and the "true"/"false" strings some providers send.
+type Bool bool
+
+func (b *Bool) UnmarshalJSON(data []byte) error {
+    s := strings.Trim(string(data), `"`)
+    *b = Bool(s == "true")
+    return nil
+}
+
+// IDTokenClaims are the ID token claims relevant for linking the identity to a local user.
+type IDTokenClaims struct {
+    Email           string `json:"email"`
+    EmailVerified   Bool   `json:"email_verified"`
+    Name            string `json:"name"`
+    Nonce           string `json:"nonce"`
+    AuthorizedParty string `json:"azp"`
+    jwt.RegisteredClaims
+}
+
+// Provider runs the authorization-code + PKCE flow against one provider.
+// Discovery metadata and signing keys are fetched lazily and cached.
+type Provider struct {
+    Config Config
+    HTTP   *http.Client
+
+    mu          sync.Mutex
+    discovery   *Discovery
+    keys        map[string]*utils.JWTKey
+    keysFetched time.Time
+}
+
+// NewProvider builds a provider; a nil httpClient gets a client with a 10s timeout.
+func NewProvider(cfg Config, httpClient *http.Client) *Provider {
+    if httpClient == nil {
+        httpClient = &http.Client{Timeout: 10 * time.Second}
+    }
+    if len(cfg.Scopes) == 0 {
+        cfg.Scopes = []string{"openid", "email", "profile"}
+    }
+    cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+    return &Provider{Config: cfg, HTTP: httpClient}
+}
+
+// Discover returns the provider metadata, downloading it on first use.
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.discovery != nil {
+        return p.discovery, nil
+    }
+    var d Discovery
+    if err := p.getJSON(ctx, p.Config.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+        return nil, fmt.Errorf("oidc discovery: %w", err)
+    }
+    // The issuer must be exactly the configured one (OpenID Connect Discovery §4.3)
+    if strings.TrimRight(d.Issuer, "/") != p.Config.Issuer {
+        return nil, fmt.Errorf("oidc discovery: issuer mismatch %q", d.Issuer)
+    }
+    if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+        return nil, errors.New("oidc discovery: incomplete provider metadata")
+    }
+    p.discovery = &d
+    return p.discovery, nil
+}
+
+// AuthCodeURL builds the URL the browser is redirected to for login.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+    d, err := p.Discover(ctx)
+    if err != nil {
+        return "", err
+    }
+    q := url.Values{
+        "response_type":         {"code"},
+        "client_id":             {p.Config.ClientID},
+        "redirect_uri":          {p.Config.RedirectURL},
+        "scope":                 {strings.Join(p.Config.Scopes, " ")},
+        "state":                 {state},
+        "nonce":                 {nonce},
+        "code_challenge":        {codeChallenge},
+        "code_challenge_method": {"S256"},
+    }
+    sep := "?"
+    if strings.Contains(d.AuthorizationEndpoint, "?") {
+        sep = "&"
+    }
+    return d.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange trades the authorization code (and PKCE verifier) for tokens.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+    d, err := p.Discover(ctx)
+    if err != nil {
+        return nil, err
+    }
+    form := url.Values{
+        "grant_type":    {"authorization_code"},
+        "code":          {code},
+        "redirect_uri":  {p.Config.RedirectURL},
+        "code_verifier": {codeVerifier},
+        "client_id":     {p.Config.ClientID},
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Accept", "application/json")
+    if p.Config.ClientSecret != "" {
+        // client_secret_basic; credentials are form-encoded first (RFC 6749 §2.3.1)
+        req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+    }
+    resp, err := p.HTTP.Do(req)
+    if err != nil {
+        return nil, fmt.Errorf("oidc token exchange: %w", err)
+    }
+    defer resp.Body.Close()
+    body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+    if err != nil {
+        return nil, fmt.Errorf("oidc token exchange: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        var e struct {
+            Error       string `json:"error"`
+            Description string `json:"error_description"`
+        }
+        _ = json.Unmarshal(body, &e)
+        return nil, fmt.Errorf("oidc token exchange: status %d: %s %s", resp.StatusCode, e.Error, e.Description)
+    }
+    var t TokenResponse
+    if err := json.Unmarshal(body, &t); err != nil {
+        return nil, fmt.Errorf("oidc token exchange: %w", err)
+    }
+    if t.IDToken == "" {
+        return nil, errors.New("oidc token exchange: no id_token in response")
+    }
+    return &t, nil
+}
+
+// VerifyIDToken checks the ID token signature against the provider's JWKS together with
+// issuer, audience, expiry and the nonce sent in the authorization request.
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*IDTokenClaims, error) {
+    d, err := p.Discover(ctx)
+    if err != nil {
+        return nil, err
+    }
+    claims := &IDTokenClaims{}
+    _, err = jwt.ParseWithClaims(raw, claims,
+        func(t *jwt.Token) (interface{}, error) { return p.keyFor(ctx, t) },
+        jwt.WithValidMethods([]string{"RS256", "ES256", "ES384", "ES512", "EdDSA"}),
+        jwt.WithIssuer(d.Issuer),
+        jwt.WithAudience(p.Config.ClientID),
+        jwt.WithExpirationRequired(),
+        jwt.WithLeeway(30*time.Second),
+    )
+    if err != nil {
+        return nil, fmt.Errorf("oidc id token: %w", err)
+    }
+    if claims.Subject == "" {
+        return nil, errors.New("oidc id token: missing sub")
+    }
+    if nonce == "" || claims.Nonce != nonce {
+        return nil, errors.New("oidc id token: nonce mismatch")
+    }
+    if len(claims.Audience) > 1 && claims.AuthorizedParty != p.Config.ClientID {
+        return nil, errors.New("oidc id token: azp mismatch")
+    }
+    return claims, nil
+}
+
+// keyFor picks the verification key by "kid", re-downloading the JWKS (at most once per
+// jwksRefreshInterval) when the provider has rotated to a key we have not seen yet.
+func (p *Provider) keyFor(ctx context.Context, t *jwt.Token) (interface{}, error) {
+    kid, _ := t.Header["kid"].(string)
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    k := p.lookupKey(kid)
+    if k == nil && time.Since(p.keysFetched) >= jwksRefreshInterval {
+        if err := p.refreshKeys(ctx); err != nil {
+            return nil, err
+        }
+        k = p.lookupKey(kid)
+    }
+    if k == nil {
+        return nil, fmt.Errorf("%w: unknown kid %q", jwt.ErrTokenUnverifiable, kid)
+    }
+    if t.Method.Alg() != k.Method.Alg() {
+        return nil, jwt.ErrTokenSignatureInvalid
+    }
+    return k.Public, nil
+}
+
+// lookupKey accepts a missing kid only when the provider publishes a single key.
+func (p *Provider) lookupKey(kid string) *utils.JWTKey {
+    if kid == "" && len(p.keys) == 1 {
+        for _, k := range p.keys {
+            return k
+        }
+    }
+    return p.keys[kid]
+}
+
+func (p *Provider) refreshKeys(ctx context.Context) error {
+    var set utils.JWKS
+    if err := p.getJSON(ctx, p.discovery.JWKSURI, &set); err != nil {
+        return fmt.Errorf("oidc jwks: %w", err)
+    }
+    keys := map[string]*utils.JWTKey{}
+    for _, jwk := range set.Keys {
+        if jwk.Use != "" && jwk.Use != "sig" {
+            continue
+        }
+        // Keys we cannot use (unsupported types) are skipped rather than failing the whole set
+        if k, err := jwk.JWTKey(); err == nil {
+            keys[jwk.Kid] = k
+        }
+    }
+    p.keys = keys
+    p.keysFetched = time.Now()
+    return nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, out interface{}) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Accept", "application/json")
+    resp, err := p.HTTP.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+    }
+    return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
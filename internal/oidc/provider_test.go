@@ -0,0 +1,321 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"API-GO/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+    testClientID = "test-client"
+    testRedirect = "https://app.example/auth/oidc/test/callback"
+)
+
+// fakeProvider is a local OpenID provider serving discovery, JWKS and the token endpoint.
+// The authorization step is simulated by authorize, which records what the browser would send.
+type fakeProvider struct {
+    t   *testing.T
+    srv *httptest.Server
+
+    mu          sync.Mutex
+    signing     *utils.JWTKey
+    published   []*utils.JWTKey
+    jwksFetches int
+    code        string
+    challenge   string
+    nonce       string
+    // claims returns the ID token claims issued for the current code
+    claims func(f *fakeProvider) *IDTokenClaims
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+    t.Helper()
+    f := &fakeProvider{t: t}
+    f.signing = f.newKey("key-1")
+    f.published = []*utils.JWTKey{f.signing}
+    f.claims = func(f *fakeProvider) *IDTokenClaims { return f.defaultClaims() }
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+        writeJSON(w, http.StatusOK, Discovery{
+            Issuer:                f.srv.URL,
+            AuthorizationEndpoint: f.srv.URL + "/authorize",
+            TokenEndpoint:         f.srv.URL + "/token",
+            JWKSURI:               f.srv.URL + "/jwks",
+        })
+    })
+    mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+        f.mu.Lock()
+        defer f.mu.Unlock()
+        f.jwksFetches++
+        var set utils.JWKS
+        for _, k := range f.published {
+            jwk, err := k.JWK()
+            if err != nil {
+                t.Error(err)
+            }
+            set.Keys = append(set.Keys, jwk)
+        }
+        writeJSON(w, http.StatusOK, set)
+    })
+    mux.HandleFunc("/token", f.token)
+    f.srv = httptest.NewServer(mux)
+    t.Cleanup(f.srv.Close)
+    return f
+}
+
+func (f *fakeProvider) newKey(kid string) *utils.JWTKey {
+    priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        f.t.Fatal(err)
+    }
+    k, err := utils.NewJWTKey(kid, priv, &priv.PublicKey)
+    if err != nil {
+        f.t.Fatal(err)
+    }
+    return k
+}
+
+func (f *fakeProvider) provider() *Provider {
+    return NewProvider(Config{Name: "test", Issuer: f.srv.URL, ClientID: testClientID, RedirectURL: testRedirect}, f.srv.Client())
+}
+
+func (f *fakeProvider) defaultClaims() *IDTokenClaims {
+    now := time.Now()
+    return &IDTokenClaims{
+        Email:         "ana@example.com",
+        EmailVerified: true,
+        Nonce:         f.nonce,
+        RegisteredClaims: jwt.RegisteredClaims{
+            Issuer:    f.srv.URL,
+            Subject:   "subject-1",
+            Audience:  jwt.ClaimStrings{testClientID},
+            IssuedAt:  jwt.NewNumericDate(now),
+            ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+        },
+    }
+}
+
+// authorize plays the browser and the provider's login page: it reads the authorization
+// request and returns the code the provider redirects back with.
+func (f *fakeProvider) authorize(authURL string) string {
+    f.t.Helper()
+    u, err := url.Parse(authURL)
+    if err != nil {
+        f.t.Fatal(err)
+    }
+    q := u.Query()
+    if u.Path != "/authorize" || q.Get("client_id") != testClientID || q.Get("redirect_uri") != testRedirect {
+        f.t.Fatalf("unexpected authorization request %s", authURL)
+    }
+    if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+        f.t.Fatalf("authorization request without an S256 code challenge: %s", authURL)
+    }
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.code = "code-" + q.Get("state")
+    f.challenge = q.Get("code_challenge")
+    f.nonce = q.Get("nonce")
+    return f.code
+}
+
+func (f *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+    if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+        writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+        return
+    }
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    if r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("client_id") != testClientID ||
+        r.PostForm.Get("redirect_uri") != testRedirect || f.code == "" || r.PostForm.Get("code") != f.code {
+        writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+        return
+    }
+    // PKCE S256 (RFC 7636 §4.6)
+    if CodeChallenge(r.PostForm.Get("code_verifier")) != f.challenge {
+        writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "code verifier mismatch"})
+        return
+    }
+    f.code = ""
+    writeJSON(w, http.StatusOK, TokenResponse{AccessToken: "access", TokenType: "Bearer", IDToken: f.sign(f.claims(f)), ExpiresIn: 300})
+}
+
+func (f *fakeProvider) sign(claims *IDTokenClaims) string {
+    tok := jwt.NewWithClaims(f.signing.Method, claims)
+    tok.Header["kid"] = f.signing.ID
+    s, err := tok.SignedString(f.signing.Private)
+    if err != nil {
+        f.t.Fatal(err)
+    }
+    return s
+}
+
+// rotate switches signing to a new key and publishes it next to the old ones.
+func (f *fakeProvider) rotate(kid string) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.signing = f.newKey(kid)
+    f.published = append(f.published, f.signing)
+}
+
+func (f *fakeProvider) fetches() int {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.jwksFetches
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
+
+// login runs the whole flow and returns the verified claims.
+func login(t *testing.T, f *fakeProvider, p *Provider) (*IDTokenClaims, error) {
+    t.Helper()
+    ctx := context.Background()
+    verifier, err := NewCodeVerifier()
+    if err != nil {
+        t.Fatal(err)
+    }
+    nonce, _ := utils.GenerateOpaqueToken()
+    authURL, err := p.AuthCodeURL(ctx, "state-1", nonce, CodeChallenge(verifier))
+    if err != nil {
+        t.Fatal(err)
+    }
+    code := f.authorize(authURL)
+    tok, err := p.Exchange(ctx, code, verifier)
+    if err != nil {
+        t.Fatalf("Exchange: %v", err)
+    }
+    return p.VerifyIDToken(ctx, tok.IDToken, nonce)
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+    f := newFakeProvider(t)
+    claims, err := login(t, f, f.provider())
+    if err != nil {
+        t.Fatalf("VerifyIDToken: %v", err)
+    }
+    if claims.Subject != "subject-1" || claims.Email != "ana@example.com" || !bool(claims.EmailVerified) {
+        t.Fatalf("unexpected claims: %+v", claims)
+    }
+}
+
+func TestExchangeRejectsWrongCodeVerifier(t *testing.T) {
+    f := newFakeProvider(t)
+    p := f.provider()
+    ctx := context.Background()
+    verifier, _ := NewCodeVerifier()
+    authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallenge(verifier))
+    if err != nil {
+        t.Fatal(err)
+    }
+    code := f.authorize(authURL)
+    other, _ := NewCodeVerifier()
+    _, err = p.Exchange(ctx, code, other)
+    if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+        t.Fatalf("err = %v, want invalid_grant", err)
+    }
+}
+
+func TestVerifyIDTokenRejected(t *testing.T) {
+    tests := []struct {
+        name   string
+        modify func(f *fakeProvider, c *IDTokenClaims)
+    }{
+        {"wrong nonce", func(f *fakeProvider, c *IDTokenClaims) { c.Nonce = "other-nonce" }},
+        {"missing nonce", func(f *fakeProvider, c *IDTokenClaims) { c.Nonce = "" }},
+        {"wrong issuer", func(f *fakeProvider, c *IDTokenClaims) { c.Issuer = "https://evil.example" }},
+        {"wrong audience", func(f *fakeProvider, c *IDTokenClaims) { c.Audience = jwt.ClaimStrings{"other-client"} }},
+        {"several audiences without azp", func(f *fakeProvider, c *IDTokenClaims) {
+            c.Audience = jwt.ClaimStrings{testClientID, "other-client"}
+        }},
+        {"several audiences with wrong azp", func(f *fakeProvider, c *IDTokenClaims) {
+            c.Audience = jwt.ClaimStrings{testClientID, "other-client"}
+            c.AuthorizedParty = "other-client"
+        }},
+        {"expired", func(f *fakeProvider, c *IDTokenClaims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }},
+        {"missing subject", func(f *fakeProvider, c *IDTokenClaims) { c.Subject = "" }},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            f := newFakeProvider(t)
+            f.claims = func(f *fakeProvider) *IDTokenClaims {
+                c := f.defaultClaims()
+                tt.modify(f, c)
+                return c
+            }
+            if _, err := login(t, f, f.provider()); err == nil {
+                t.Fatal("ID token accepted")
+            }
+        })
+    }
+}
+
+func TestVerifyIDTokenAcceptsMatchingAuthorizedParty(t *testing.T) {
+    f := newFakeProvider(t)
+    f.claims = func(f *fakeProvider) *IDTokenClaims {
+        c := f.defaultClaims()
+        c.Audience = jwt.ClaimStrings{testClientID, "other-client"}
+        c.AuthorizedParty = testClientID
+        return c
+    }
+    if _, err := login(t, f, f.provider()); err != nil {
+        t.Fatalf("VerifyIDToken: %v", err)
+    }
+}
+
+func TestVerifyIDTokenRejectsUnpublishedKey(t *testing.T) {
+    f := newFakeProvider(t)
+    f.signing = f.newKey("key-1") // same kid, different key
+    if _, err := login(t, f, f.provider()); err == nil {
+        t.Fatal("ID token signed with an unpublished key accepted")
+    }
+}
+
+func TestJWKSRefreshedOnUnknownKid(t *testing.T) {
+    f := newFakeProvider(t)
+    p := f.provider()
+    if _, err := login(t, f, p); err != nil {
+        t.Fatal(err)
+    }
+    if _, err := login(t, f, p); err != nil {
+        t.Fatal(err)
+    }
+    if n := f.fetches(); n != 1 {
+        t.Fatalf("JWKS fetched %d times for a known kid, want 1", n)
+    }
+
+    // A rotation right after a download is not picked up until the refresh interval passes
+    f.rotate("key-2")
+    if _, err := login(t, f, p); err == nil {
+        t.Fatal("unknown kid accepted without a JWKS refresh")
+    }
+    if n := f.fetches(); n != 1 {
+        t.Fatalf("JWKS fetched %d times within the refresh interval, want 1", n)
+    }
+
+    p.mu.Lock()
+    p.keysFetched = p.keysFetched.Add(-jwksRefreshInterval)
+    p.mu.Unlock()
+    if _, err := login(t, f, p); err != nil {
+        t.Fatalf("VerifyIDToken after rotation: %v", err)
+    }
+    if n := f.fetches(); n != 2 {
+        t.Fatalf("JWKS fetched %d times after rotation, want 2", n)
+    }
+}
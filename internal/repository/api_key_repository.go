@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKeyRepository stores hashed API keys. Lookups by user scope every operation to the owner.
+type APIKeyRepository interface {
+    Create(ctx context.Context, k *models.APIKey) error
+    // GetByHash returns the key with the given hash, or nil when there is none.
+    GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+    // GetForUser returns the user's key, or nil when it does not exist or belongs to someone else.
+    GetForUser(ctx context.Context, userID, id primitive.ObjectID) (*models.APIKey, error)
+    ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error)
+    CountForUser(ctx context.Context, userID primitive.ObjectID) (int64, error)
+    UpdateFields(ctx context.Context, userID, id primitive.ObjectID, fields map[string]interface{}) (bool, error)
+    Delete(ctx context.Context, userID, id primitive.ObjectID) (bool, error)
+    TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
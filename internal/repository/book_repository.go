@@ -11,6 +11,7 @@ import (
 // BookRepository follows the CRUDRepository contract for Book.
 type BookRepository interface {
     CRUDRepository[models.Book, primitive.ObjectID]
+    SoftDeleteRepository[models.Book, primitive.ObjectID]
 	// Extended list supporting filtering/sorting/pagination
 	ListWithQuery(ctx context.Context, q utils.ListQuery) ([]models.Book, int64, error)
 }
@@ -1,6 +1,11 @@
 package repository
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/utils"
+)
 
 // CRUDRepository defines a generic interface for simple CRUD.
 // T is the domain model and ID is its identifier type.
@@ -14,3 +19,15 @@ type CRUDRepository[T any, ID any] interface {
     UpdateFields(ctx context.Context, id ID, fields map[string]interface{}) (bool, error)
     DeleteByID(ctx context.Context, id ID) (bool, error)
 }
+
+// SoftDeleteRepository moves documents to a trash instead of removing them. Trashed documents
+// carry deletedAt/deletedBy and are invisible to every other method except DeleteByID.
+type SoftDeleteRepository[T any, ID any] interface {
+    // SoftDelete trashes the document; by is the ID of the user who deleted it.
+    SoftDelete(ctx context.Context, id ID, by string, at time.Time) (bool, error)
+    // Restore takes the document out of the trash; ErrRestoreConflict means a unique field is now taken.
+    Restore(ctx context.Context, id ID) (bool, error)
+    ListDeleted(ctx context.Context, q utils.ListQuery) ([]T, int64, error)
+    // PurgeDeletedBefore permanently removes documents trashed before the given time.
+    PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
+}
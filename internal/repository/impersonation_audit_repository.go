@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"API-GO/internal/models"
+	"API-GO/internal/utils"
+)
+
+// ImpersonationAuditRepository is an append-only log of impersonation sessions and the requests made under them.
+type ImpersonationAuditRepository interface {
+    Record(ctx context.Context, e *models.ImpersonationAuditEntry) error
+    // ListWithQuery returns the matching entries and the total number of matches.
+    ListWithQuery(ctx context.Context, q utils.ListQuery) ([]models.ImpersonationAuditEntry, int64, error)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/models"
+)
+
+// LoginAttemptRepository counts failed logins per key (account or client IP) for brute-force protection.
+type LoginAttemptRepository interface {
+    // Get returns the counters for key, or nil when there are none.
+    Get(ctx context.Context, key string) (*models.LoginAttempts, error)
+    // RecordFailure atomically increments the counter; it restarts at 1 when the first
+    // failure is older than window. The updated counters are returned.
+    RecordFailure(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error)
+    // Lock blocks the key until the given time.
+    Lock(ctx context.Context, key string, until time.Time) error
+    Reset(ctx context.Context, key string) error
+}
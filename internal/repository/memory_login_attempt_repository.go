@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"API-GO/internal/models"
+)
+
+// MemoryLoginAttemptRepository is an in-process LoginAttemptRepository for tests and single-instance runs.
+type MemoryLoginAttemptRepository struct {
+    mu       sync.Mutex
+    attempts map[string]*models.LoginAttempts
+}
+
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+    return &MemoryLoginAttemptRepository{attempts: map[string]*models.LoginAttempts{}}
+}
+
+func (r *MemoryLoginAttemptRepository) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    a, ok := r.attempts[key]
+    if !ok || !time.Now().Before(a.ExpiresAt) {
+        return nil, nil
+    }
+    cp := *a
+    return &cp, nil
+}
+
+func (r *MemoryLoginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    now := time.Now()
+    // Curăță intrările expirate, echivalentul indexului TTL din Mongo
+    for k, a := range r.attempts {
+        if !now.Before(a.ExpiresAt) {
+            delete(r.attempts, k)
+        }
+    }
+    a, ok := r.attempts[key]
+    if !ok {
+        a = &models.LoginAttempts{Key: key}
+        r.attempts[key] = a
+    }
+    if a.FirstFailureAt.Before(now.Add(-window)) {
+        a.Failures = 0
+        a.FirstFailureAt = now
+    }
+    a.Failures++
+    a.LastFailureAt = now
+    if exp := now.Add(window); exp.After(a.ExpiresAt) {
+        a.ExpiresAt = exp
+    }
+    cp := *a
+    return &cp, nil
+}
+
+func (r *MemoryLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    a, ok := r.attempts[key]
+    if !ok {
+        a = &models.LoginAttempts{Key: key}
+        r.attempts[key] = a
+    }
+    a.LockedUntil = until
+    if until.After(a.ExpiresAt) {
+        a.ExpiresAt = until
+    }
+    return nil
+}
+
+func (r *MemoryLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    delete(r.attempts, key)
+    return nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"API-GO/internal/models"
+)
+
+// MemoryOIDCStateRepository is an in-process OIDCStateRepository for tests and single-instance runs.
+type MemoryOIDCStateRepository struct {
+    mu     sync.Mutex
+    states map[string]models.OIDCState
+}
+
+func NewMemoryOIDCStateRepository() *MemoryOIDCStateRepository {
+    return &MemoryOIDCStateRepository{states: map[string]models.OIDCState{}}
+}
+
+func (r *MemoryOIDCStateRepository) Create(ctx context.Context, s *models.OIDCState) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    now := time.Now()
+    // Curăță intrările expirate, echivalentul indexului TTL din Mongo
+    for k, st := range r.states {
+        if !now.Before(st.ExpiresAt) {
+            delete(r.states, k)
+        }
+    }
+    r.states[s.StateHash] = *s
+    return nil
+}
+
+func (r *MemoryOIDCStateRepository) Consume(ctx context.Context, stateHash string, now time.Time) (*models.OIDCState, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    s, ok := r.states[stateHash]
+    if !ok {
+        return nil, nil
+    }
+    delete(r.states, stateHash)
+    if !now.Before(s.ExpiresAt) {
+        return nil, nil
+    }
+    return &s, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryTokenRevocationRepository is an in-process TokenRevocationRepository for tests and single-instance dev runs.
+type MemoryTokenRevocationRepository struct {
+    mu      sync.RWMutex
+    tokens  map[string]time.Time // jti -> expiresAt
+    cutoffs map[string]time.Time // userID -> before
+}
+
+func NewMemoryTokenRevocationRepository() *MemoryTokenRevocationRepository {
+    return &MemoryTokenRevocationRepository{tokens: map[string]time.Time{}, cutoffs: map[string]time.Time{}}
+}
+
+func (r *MemoryTokenRevocationRepository) RevokeToken(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    now := time.Now()
+    // Curăță intrările expirate, echivalentul indexului TTL din Mongo
+    for k, exp := range r.tokens {
+        if !now.Before(exp) {
+            delete(r.tokens, k)
+        }
+    }
+    r.tokens[jti] = expiresAt
+    return nil
+}
+
+func (r *MemoryTokenRevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    exp, ok := r.tokens[jti]
+    return ok && time.Now().Before(exp), nil
+}
+
+func (r *MemoryTokenRevocationRepository) RevokeUserTokensBefore(ctx context.Context, userID string, before time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if before.After(r.cutoffs[userID]) {
+        r.cutoffs[userID] = before
+    }
+    return nil
+}
+
+func (r *MemoryTokenRevocationRepository) UserTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    return r.cutoffs[userID], nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryWebAuthnCredentialRepository is an in-process WebAuthnCredentialRepository for tests and single-instance runs.
+type MemoryWebAuthnCredentialRepository struct {
+    mu    sync.Mutex
+    creds map[primitive.ObjectID]models.WebAuthnCredential
+}
+
+func NewMemoryWebAuthnCredentialRepository() *MemoryWebAuthnCredentialRepository {
+    return &MemoryWebAuthnCredentialRepository{creds: map[primitive.ObjectID]models.WebAuthnCredential{}}
+}
+
+func (r *MemoryWebAuthnCredentialRepository) Create(ctx context.Context, c *models.WebAuthnCredential) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for _, existing := range r.creds {
+        if existing.CredentialID == c.CredentialID {
+            return ErrDuplicateCredential
+        }
+    }
+    if c.ID.IsZero() {
+        c.ID = primitive.NewObjectID()
+    }
+    r.creds[c.ID] = *c
+    return nil
+}
+
+func (r *MemoryWebAuthnCredentialRepository) GetByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for _, c := range r.creds {
+        if c.CredentialID == credentialID {
+            return &c, nil
+        }
+    }
+    return nil, nil
+}
+
+func (r *MemoryWebAuthnCredentialRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.WebAuthnCredential, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    out := []models.WebAuthnCredential{}
+    for _, c := range r.creds {
+        if c.UserID == userID {
+            out = append(out, c)
+        }
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+    return out, nil
+}
+
+func (r *MemoryWebAuthnCredentialRepository) UpdateUsage(ctx context.Context, id primitive.ObjectID, signCount uint32, backedUp bool, at time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if c, ok := r.creds[id]; ok {
+        c.SignCount = signCount
+        c.BackedUp = backedUp
+        c.LastUsedAt = &at
+        r.creds[id] = c
+    }
+    return nil
+}
+
+func (r *MemoryWebAuthnCredentialRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    c, ok := r.creds[id]
+    if !ok || c.UserID != userID {
+        return false, nil
+    }
+    delete(r.creds, id)
+    return true, nil
+}
+
+// MemoryWebAuthnChallengeRepository is an in-process WebAuthnChallengeRepository for tests and single-instance runs.
+type MemoryWebAuthnChallengeRepository struct {
+    mu         sync.Mutex
+    challenges map[string]models.WebAuthnChallenge
+}
+
+func NewMemoryWebAuthnChallengeRepository() *MemoryWebAuthnChallengeRepository {
+    return &MemoryWebAuthnChallengeRepository{challenges: map[string]models.WebAuthnChallenge{}}
+}
+
+func (r *MemoryWebAuthnChallengeRepository) Create(ctx context.Context, c *models.WebAuthnChallenge) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    now := time.Now()
+    // Drops expired entries, like the TTL index in Mongo
+    for k, ch := range r.challenges {
+        if !now.Before(ch.ExpiresAt) {
+            delete(r.challenges, k)
+        }
+    }
+    r.challenges[c.ChallengeHash] = *c
+    return nil
+}
+
+func (r *MemoryWebAuthnChallengeRepository) Consume(ctx context.Context, challengeHash string, now time.Time) (*models.WebAuthnChallenge, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    c, ok := r.challenges[challengeHash]
+    if !ok {
+        return nil, nil
+    }
+    delete(r.challenges, challengeHash)
+    if !now.Before(c.ExpiresAt) {
+        return nil, nil
+    }
+    return &c, nil
+}
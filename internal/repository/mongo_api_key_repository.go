@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"API-GO/internal/database"
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoAPIKeyRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoAPIKeyRepository(client *mongo.Client) *MongoAPIKeyRepository {
+    return &MongoAPIKeyRepository{client: client}
+}
+
+func (r *MongoAPIKeyRepository) collection() *mongo.Collection {
+    return database.APIKeyCollection(r.client)
+}
+
+func (r *MongoAPIKeyRepository) Create(ctx context.Context, k *models.APIKey) error {
+    res, err := r.collection().InsertOne(ctx, k)
+    if err != nil {
+        return err
+    }
+    if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+        k.ID = oid
+    }
+    return nil
+}
+
+func (r *MongoAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+    return r.findOne(ctx, bson.M{"keyHash": hash})
+}
+
+func (r *MongoAPIKeyRepository) GetForUser(ctx context.Context, userID, id primitive.ObjectID) (*models.APIKey, error) {
+    return r.findOne(ctx, bson.M{"_id": id, "userId": userID})
+}
+
+func (r *MongoAPIKeyRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.APIKey, error) {
+    cursor, err := r.collection().Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+    keys := []models.APIKey{}
+    if err := cursor.All(ctx, &keys); err != nil {
+        return nil, err
+    }
+    return keys, nil
+}
+
+func (r *MongoAPIKeyRepository) CountForUser(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+    return r.collection().CountDocuments(ctx, bson.M{"userId": userID})
+}
+
+func (r *MongoAPIKeyRepository) UpdateFields(ctx context.Context, userID, id primitive.ObjectID, fields map[string]interface{}) (bool, error) {
+    res, err := r.collection().UpdateOne(ctx, bson.M{"_id": id, "userId": userID}, bson.M{"$set": fields})
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
+
+func (r *MongoAPIKeyRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) (bool, error) {
+    res, err := r.collection().DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+    if err != nil {
+        return false, err
+    }
+    return res.DeletedCount > 0, nil
+}
+
+func (r *MongoAPIKeyRepository) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+    _, err := r.collection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$max": bson.M{"lastUsedAt": at}})
+    return err
+}
+
+func (r *MongoAPIKeyRepository) findOne(ctx context.Context, filter bson.M) (*models.APIKey, error) {
+    var k models.APIKey
+    err := r.collection().FindOne(ctx, filter).Decode(&k)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &k, nil
+}
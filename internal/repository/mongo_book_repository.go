@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"sync"
+	"time"
 
 	"API-GO/internal/database"
 	"API-GO/internal/models"
@@ -33,7 +34,7 @@ func (r *MongoBookRepository) Create(ctx context.Context, b *models.Book) error
 
 func (r *MongoBookRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Book, error) {
     var b models.Book
-    err := r.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&b)
+    err := r.collection().FindOne(ctx, notDeleted(bson.M{"_id": id})).Decode(&b)
     if err != nil {
         return nil, err
     }
@@ -41,7 +42,7 @@ func (r *MongoBookRepository) GetByID(ctx context.Context, id primitive.ObjectID
 }
 
 func (r *MongoBookRepository) List(ctx context.Context) ([]models.Book, error) {
-    cur, err := r.collection().Find(ctx, bson.M{})
+    cur, err := r.collection().Find(ctx, notDeleted(bson.M{}))
     if err != nil {
         return nil, err
     }
@@ -62,10 +63,11 @@ func (r *MongoBookRepository) ListWithQuery(ctx context.Context, q utils.ListQue
         opts.SetLimit(q.Limit)
         opts.SetSkip(q.Skip)
     }
-    filter := q.Filter
-    if filter == nil {
-        filter = bson.M{}
+    filter := bson.M{}
+    for k, v := range q.Filter {
+        filter[k] = v
     }
+    filter = notDeleted(filter)
 
     var wg sync.WaitGroup
     wg.Add(2)
@@ -99,7 +101,7 @@ func (r *MongoBookRepository) ListWithQuery(ctx context.Context, q utils.ListQue
 }
 
 func (r *MongoBookRepository) UpdateFields(ctx context.Context, id primitive.ObjectID, fields map[string]interface{}) (bool, error) {
-    res, err := r.collection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+    res, err := r.collection().UpdateOne(ctx, notDeleted(bson.M{"_id": id}), bson.M{"$set": fields})
     if err != nil {
         return false, err
     }
@@ -113,3 +115,19 @@ func (r *MongoBookRepository) DeleteByID(ctx context.Context, id primitive.Objec
     }
     return res.DeletedCount > 0, nil
 }
+
+func (r *MongoBookRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, by string, at time.Time) (bool, error) {
+    return softDelete(ctx, r.collection(), id, by, at)
+}
+
+func (r *MongoBookRepository) Restore(ctx context.Context, id primitive.ObjectID) (bool, error) {
+    return restore(ctx, r.collection(), id)
+}
+
+func (r *MongoBookRepository) ListDeleted(ctx context.Context, q utils.ListQuery) ([]models.Book, int64, error) {
+    return listDeleted[models.Book](ctx, r.collection(), q, nil)
+}
+
+func (r *MongoBookRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+    return purgeDeletedBefore(ctx, r.collection(), before)
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"API-GO/internal/database"
+	"API-GO/internal/models"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoImpersonationAuditRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoImpersonationAuditRepository(client *mongo.Client) *MongoImpersonationAuditRepository {
+    return &MongoImpersonationAuditRepository{client: client}
+}
+
+func (r *MongoImpersonationAuditRepository) collection() *mongo.Collection {
+    return database.ImpersonationAuditCollection(r.client)
+}
+
+func (r *MongoImpersonationAuditRepository) Record(ctx context.Context, e *models.ImpersonationAuditEntry) error {
+    res, err := r.collection().InsertOne(ctx, e)
+    if err != nil {
+        return err
+    }
+    if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+        e.ID = oid
+    }
+    return nil
+}
+
+func (r *MongoImpersonationAuditRepository) ListWithQuery(ctx context.Context, q utils.ListQuery) ([]models.ImpersonationAuditEntry, int64, error) {
+    filter := q.Filter
+    if filter == nil {
+        filter = bson.M{}
+    }
+    total, err := r.collection().CountDocuments(ctx, filter)
+    if err != nil {
+        return nil, 0, err
+    }
+    opts := options.Find()
+    if len(q.Sort) > 0 {
+        opts.SetSort(q.Sort)
+    }
+    if q.Limit > 0 {
+        opts.SetLimit(q.Limit)
+        opts.SetSkip(q.Skip)
+    }
+    cursor, err := r.collection().Find(ctx, filter, opts)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer cursor.Close(ctx)
+    entries := []models.ImpersonationAuditEntry{}
+    if err := cursor.All(ctx, &entries); err != nil {
+        return nil, 0, err
+    }
+    return entries, total, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"API-GO/internal/database"
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoLoginAttemptRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoLoginAttemptRepository(client *mongo.Client) *MongoLoginAttemptRepository {
+    return &MongoLoginAttemptRepository{client: client}
+}
+
+func (r *MongoLoginAttemptRepository) collection() *mongo.Collection {
+    return database.LoginAttemptCollection(r.client)
+}
+
+func (r *MongoLoginAttemptRepository) Get(ctx context.Context, key string) (*models.LoginAttempts, error) {
+    var a models.LoginAttempts
+    // Indexul TTL nu șterge instantaneu, așa că filtrăm și aici documentele expirate
+    err := r.collection().FindOne(ctx, bson.M{"_id": key, "expiresAt": bson.M{"$gt": time.Now()}}).Decode(&a)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &a, nil
+}
+
+func (r *MongoLoginAttemptRepository) RecordFailure(ctx context.Context, key string, window time.Duration) (*models.LoginAttempts, error) {
+    now := time.Now()
+    // Update cu pipeline: fereastra se resetează atomic, fără read-modify-write între instanțe.
+    // Expresiile din același $set văd valorile vechi ale documentului.
+    inWindow := bson.D{{Key: "$gte", Value: bson.A{"$firstFailureAt", now.Add(-window)}}}
+    update := mongo.Pipeline{
+        {{Key: "$set", Value: bson.D{
+            {Key: "failures", Value: bson.D{{Key: "$cond", Value: bson.A{
+                inWindow,
+                bson.D{{Key: "$add", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$failures", 0}}}, 1}}},
+                1,
+            }}}},
+            {Key: "firstFailureAt", Value: bson.D{{Key: "$cond", Value: bson.A{inWindow, "$firstFailureAt", now}}}},
+            {Key: "lastFailureAt", Value: now},
+            {Key: "expiresAt", Value: bson.D{{Key: "$max", Value: bson.A{"$expiresAt", now.Add(window)}}}},
+        }}},
+    }
+    var a models.LoginAttempts
+    err := r.collection().FindOneAndUpdate(ctx, bson.M{"_id": key}, update,
+        options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+    ).Decode(&a)
+    if err != nil {
+        return nil, err
+    }
+    return &a, nil
+}
+
+func (r *MongoLoginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+    _, err := r.collection().UpdateOne(ctx,
+        bson.M{"_id": key},
+        bson.M{"$set": bson.M{"lockedUntil": until}, "$max": bson.M{"expiresAt": until}},
+        options.Update().SetUpsert(true),
+    )
+    return err
+}
+
+func (r *MongoLoginAttemptRepository) Reset(ctx context.Context, key string) error {
+    _, err := r.collection().DeleteOne(ctx, bson.M{"_id": key})
+    return err
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"API-GO/internal/database"
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoOIDCStateRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoOIDCStateRepository(client *mongo.Client) *MongoOIDCStateRepository {
+    return &MongoOIDCStateRepository{client: client}
+}
+
+func (r *MongoOIDCStateRepository) collection() *mongo.Collection {
+    return database.OIDCStateCollection(r.client)
+}
+
+func (r *MongoOIDCStateRepository) Create(ctx context.Context, s *models.OIDCState) error {
+    _, err := r.collection().InsertOne(ctx, s)
+    return err
+}
+
+func (r *MongoOIDCStateRepository) Consume(ctx context.Context, stateHash string, now time.Time) (*models.OIDCState, error) {
+    var s models.OIDCState
+    err := r.collection().FindOneAndDelete(ctx, bson.M{"_id": stateHash, "expiresAt": bson.M{"$gt": now}}).Decode(&s)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &s, nil
+}
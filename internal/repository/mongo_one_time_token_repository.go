@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/database"
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoOneTimeTokenRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoOneTimeTokenRepository(client *mongo.Client) *MongoOneTimeTokenRepository {
+    return &MongoOneTimeTokenRepository{client: client}
+}
+
+func (r *MongoOneTimeTokenRepository) collection() *mongo.Collection {
+    return database.OneTimeTokenCollection(r.client)
+}
+
+func (r *MongoOneTimeTokenRepository) Create(ctx context.Context, t *models.OneTimeToken) error {
+    _, err := r.collection().InsertOne(ctx, t)
+    return err
+}
+
+func (r *MongoOneTimeTokenRepository) Find(ctx context.Context, purpose, hash string, now time.Time) (*models.OneTimeToken, error) {
+    filter := bson.M{
+        "tokenHash": hash,
+        "purpose":   purpose,
+        "usedAt":    nil,
+        "expiresAt": bson.M{"$gt": now},
+    }
+    var t models.OneTimeToken
+    if err := r.collection().FindOne(ctx, filter).Decode(&t); err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+func (r *MongoOneTimeTokenRepository) Consume(ctx context.Context, purpose, hash string, now time.Time) (*models.OneTimeToken, error) {
+    filter := bson.M{
+        "tokenHash": hash,
+        "purpose":   purpose,
+        "usedAt":    nil,
+        "expiresAt": bson.M{"$gt": now},
+    }
+    var t models.OneTimeToken
+    err := r.collection().FindOneAndUpdate(ctx, filter,
+        bson.M{"$set": bson.M{"usedAt": now}},
+        options.FindOneAndUpdate().SetReturnDocument(options.After),
+    ).Decode(&t)
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+func (r *MongoOneTimeTokenRepository) InvalidateForUser(ctx context.Context, userID primitive.ObjectID, purpose string, at time.Time) (int64, error) {
+    res, err := r.collection().UpdateMany(ctx,
+        bson.M{"userId": userID, "purpose": purpose, "usedAt": nil},
+        bson.M{"$set": bson.M{"usedAt": at}},
+    )
+    if err != nil {
+        return 0, err
+    }
+    return res.ModifiedCount, nil
+}
+
+func (r *MongoOneTimeTokenRepository) CountCreatedSince(ctx context.Context, userID primitive.ObjectID, purpose string, since time.Time) (int64, error) {
+    return r.collection().CountDocuments(ctx, bson.M{"userId": userID, "purpose": purpose, "createdAt": bson.M{"$gte": since}})
+}
+
+func (r *MongoOneTimeTokenRepository) LatestForUser(ctx context.Context, userID primitive.ObjectID, purpose string) (*models.OneTimeToken, error) {
+    var t models.OneTimeToken
+    opts := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+    err := r.collection().FindOne(ctx, bson.M{"userId": userID, "purpose": purpose}, opts).Decode(&t)
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/database"
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoRefreshTokenRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoRefreshTokenRepository(client *mongo.Client) *MongoRefreshTokenRepository {
+    return &MongoRefreshTokenRepository{client: client}
+}
+
+func (r *MongoRefreshTokenRepository) collection() *mongo.Collection {
+    return database.RefreshTokenCollection(r.client)
+}
+
+func (r *MongoRefreshTokenRepository) Create(ctx context.Context, t *models.RefreshToken) error {
+    _, err := r.collection().InsertOne(ctx, t)
+    return err
+}
+
+func (r *MongoRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+    var t models.RefreshToken
+    err := r.collection().FindOne(ctx, bson.M{"tokenHash": hash}).Decode(&t)
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+func (r *MongoRefreshTokenRepository) MarkUsed(ctx context.Context, id primitive.ObjectID, replacedBy primitive.ObjectID, at time.Time) (bool, error) {
+    filter := bson.M{"_id": id, "usedAt": nil, "revokedAt": nil}
+    res, err := r.collection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"usedAt": at, "replacedBy": replacedBy}})
+    if err != nil {
+        return false, err
+    }
+    return res.ModifiedCount > 0, nil
+}
+
+func (r *MongoRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) (int64, error) {
+    res, err := r.collection().UpdateMany(ctx, bson.M{"familyId": familyID, "revokedAt": nil}, bson.M{"$set": bson.M{"revokedAt": at}})
+    if err != nil {
+        return 0, err
+    }
+    return res.ModifiedCount, nil
+}
+
+func (r *MongoRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error) {
+    res, err := r.collection().UpdateMany(ctx, bson.M{"userId": userID, "revokedAt": nil}, bson.M{"$set": bson.M{"revokedAt": at}})
+    if err != nil {
+        return 0, err
+    }
+    return res.ModifiedCount, nil
+}
+
+func (r *MongoRefreshTokenRepository) ListActive(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]models.RefreshToken, error) {
+    filter := bson.M{"userId": userID, "usedAt": nil, "revokedAt": nil, "expiresAt": bson.M{"$gt": now}}
+    cur, err := r.collection().Find(ctx, filter, options.Find().SetSort(bson.M{"createdAt": -1}))
+    if err != nil {
+        return nil, err
+    }
+    defer cur.Close(ctx)
+    tokens := []models.RefreshToken{}
+    if err := cur.All(ctx, &tokens); err != nil {
+        return nil, err
+    }
+    return tokens, nil
+}
+
+func (r *MongoRefreshTokenRepository) RevokeFamilyForUser(ctx context.Context, userID primitive.ObjectID, familyID string, at time.Time) (int64, error) {
+    res, err := r.collection().UpdateMany(ctx, bson.M{"userId": userID, "familyId": familyID, "revokedAt": nil}, bson.M{"$set": bson.M{"revokedAt": at}})
+    if err != nil {
+        return 0, err
+    }
+    return res.ModifiedCount, nil
+}
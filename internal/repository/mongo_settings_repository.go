@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"API-GO/internal/database"
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const securitySettingsID = "security"
+
+type MongoSettingsRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoSettingsRepository(client *mongo.Client) *MongoSettingsRepository {
+    return &MongoSettingsRepository{client: client}
+}
+
+func (r *MongoSettingsRepository) collection() *mongo.Collection {
+    return database.SettingsCollection(r.client)
+}
+
+func (r *MongoSettingsRepository) GetSecurity(ctx context.Context) (*models.SecuritySettings, error) {
+    var s models.SecuritySettings
+    err := r.collection().FindOne(ctx, bson.M{"_id": securitySettingsID}).Decode(&s)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return &models.SecuritySettings{}, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &s, nil
+}
+
+func (r *MongoSettingsRepository) SaveSecurity(ctx context.Context, s *models.SecuritySettings) error {
+    _, err := r.collection().ReplaceOne(ctx, bson.M{"_id": securitySettingsID}, s, options.Replace().SetUpsert(true))
+    return err
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"API-GO/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoTokenRevocationRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoTokenRevocationRepository(client *mongo.Client) *MongoTokenRevocationRepository {
+    return &MongoTokenRevocationRepository{client: client}
+}
+
+func (r *MongoTokenRevocationRepository) RevokeToken(ctx context.Context, jti, userID string, expiresAt time.Time) error {
+    // Upsert: revocarea repetată a aceluiași token nu e o eroare
+    _, err := database.RevokedTokenCollection(r.client).UpdateOne(ctx,
+        bson.M{"_id": jti},
+        bson.M{"$set": bson.M{"userId": userID, "expiresAt": expiresAt, "revokedAt": time.Now()}},
+        options.Update().SetUpsert(true),
+    )
+    return err
+}
+
+func (r *MongoTokenRevocationRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+    count, err := database.RevokedTokenCollection(r.client).CountDocuments(ctx, bson.M{"_id": jti}, options.Count().SetLimit(1))
+    if err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+func (r *MongoTokenRevocationRepository) RevokeUserTokensBefore(ctx context.Context, userID string, before time.Time) error {
+    // $max: un cutoff mai vechi nu poate reactiva token-uri deja invalidate
+    _, err := database.TokenCutoffCollection(r.client).UpdateOne(ctx,
+        bson.M{"_id": userID},
+        bson.M{"$max": bson.M{"before": before}},
+        options.Update().SetUpsert(true),
+    )
+    return err
+}
+
+func (r *MongoTokenRevocationRepository) UserTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error) {
+    var doc struct {
+        Before time.Time `bson:"before"`
+    }
+    err := database.TokenCutoffCollection(r.client).FindOne(ctx, bson.M{"_id": userID}).Decode(&doc)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return time.Time{}, nil
+    }
+    if err != nil {
+        return time.Time{}, err
+    }
+    return doc.Before, nil
+}
@@ -2,15 +2,28 @@ package repository
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"API-GO/internal/database"
 	"API-GO/internal/models"
+	"API-GO/internal/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// userListProjection excludes the credentials from list results.
+var userListProjection = bson.M{
+    "password":          0,
+    "totpSecret":        0,
+    "totpPendingSecret": 0,
+    "totpLastStep":      0,
+    "recoveryCodes":     0,
+}
+
 type MongoUserRepository struct {
     client *mongo.Client
 }
@@ -30,16 +43,18 @@ func (r *MongoUserRepository) Create(ctx context.Context, user *models.User) err
 
 func (r *MongoUserRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
     var user models.User
-    err := r.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+    err := r.collection().FindOne(ctx, notDeleted(bson.M{"_id": id})).Decode(&user)
     if err != nil {
         return nil, err
     }
     return &user, nil
 }
 
+// GetByEmail matches case-insensitively, using the collation of the unique email index.
 func (r *MongoUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
     var user models.User
-    err := r.collection().FindOne(ctx, bson.M{"email": email}).Decode(&user)
+    filter := notDeleted(bson.M{"email": utils.NormalizeEmail(email)})
+    err := r.collection().FindOne(ctx, filter, options.FindOne().SetCollation(database.EmailCollation)).Decode(&user)
     if err != nil {
         return nil, err
     }
@@ -47,7 +62,7 @@ func (r *MongoUserRepository) GetByEmail(ctx context.Context, email string) (*mo
 }
 
 func (r *MongoUserRepository) List(ctx context.Context) ([]models.User, error) {
-    cur, err := r.collection().Find(ctx, bson.M{})
+    cur, err := r.collection().Find(ctx, notDeleted(bson.M{}))
     if err != nil {
         return nil, err
     }
@@ -59,12 +74,70 @@ func (r *MongoUserRepository) List(ctx context.Context) ([]models.User, error) {
     return users, nil
 }
 
+func (r *MongoUserRepository) ListWithQuery(ctx context.Context, q utils.ListQuery) ([]models.User, int64, error) {
+    opts := options.Find().SetProjection(userListProjection)
+    if len(q.Sort) > 0 {
+        opts.SetSort(q.Sort)
+    }
+    if q.Limit > 0 {
+        opts.SetLimit(q.Limit)
+        opts.SetSkip(q.Skip)
+    }
+    filter := bson.M{}
+    for k, v := range q.Filter {
+        filter[k] = v
+    }
+    filter = notDeleted(filter)
+
+    var wg sync.WaitGroup
+    wg.Add(2)
+    var (
+        items    []models.User
+        total    int64
+        findErr  error
+        countErr error
+    )
+    go func() {
+        defer wg.Done()
+        cur, err := r.collection().Find(ctx, filter, opts)
+        if err != nil {
+            findErr = err
+            return
+        }
+        defer cur.Close(ctx)
+        out := []models.User{}
+        if err := cur.All(ctx, &out); err != nil {
+            findErr = err
+            return
+        }
+        items = out
+    }()
+    go func() {
+        defer wg.Done()
+        cnt, err := r.collection().CountDocuments(ctx, filter)
+        if err != nil {
+            countErr = err
+            return
+        }
+        total = cnt
+    }()
+    wg.Wait()
+    if findErr != nil {
+        return nil, 0, findErr
+    }
+    if countErr != nil {
+        return nil, 0, countErr
+    }
+    return items, total, nil
+}
+
+// EmailExists matches case-insensitively, like GetByEmail.
 func (r *MongoUserRepository) EmailExists(ctx context.Context, email string, excludeID ...primitive.ObjectID) (bool, error) {
-    filter := bson.M{"email": email}
+    filter := notDeleted(bson.M{"email": utils.NormalizeEmail(email)})
     if len(excludeID) > 0 && excludeID[0] != primitive.NilObjectID {
         filter["_id"] = bson.M{"$ne": excludeID[0]}
     }
-    count, err := r.collection().CountDocuments(ctx, filter)
+    count, err := r.collection().CountDocuments(ctx, filter, options.Count().SetCollation(database.EmailCollation))
     if err != nil {
         return false, err
     }
@@ -75,7 +148,11 @@ func (r *MongoUserRepository) PhoneExists(ctx context.Context, phone string, exc
     if phone == "" {
         return false, nil
     }
-    filter := bson.M{"phone": phone}
+    // Stored numbers are E.164; anything that does not normalise is looked up as given
+    if normalized, err := utils.NormalizePhone(phone); err == nil {
+        phone = normalized
+    }
+    filter := notDeleted(bson.M{"phone": phone})
     if len(excludeID) > 0 && excludeID[0] != primitive.NilObjectID {
         filter["_id"] = bson.M{"$ne": excludeID[0]}
     }
@@ -86,8 +163,28 @@ func (r *MongoUserRepository) PhoneExists(ctx context.Context, phone string, exc
     return count > 0, nil
 }
 
+func (r *MongoUserRepository) ExistsWithRole(ctx context.Context, role string) (bool, error) {
+    count, err := r.collection().CountDocuments(ctx, notDeleted(bson.M{"roles": role}), options.Count().SetLimit(1))
+    if err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
 func (r *MongoUserRepository) UpdateFields(ctx context.Context, id primitive.ObjectID, fields map[string]interface{}) (bool, error) {
-    res, err := r.collection().UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+    res, err := r.collection().UpdateOne(ctx, notDeleted(bson.M{"_id": id}), bson.M{"$set": fields})
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
+
+func (r *MongoUserRepository) SetStatus(ctx context.Context, id primitive.ObjectID, change models.StatusChange) (bool, error) {
+    update := bson.M{
+        "$set":  bson.M{"status": change.Status},
+        "$push": bson.M{"statusHistory": change},
+    }
+    res, err := r.collection().UpdateOne(ctx, notDeleted(bson.M{"_id": id}), update)
     if err != nil {
         return false, err
     }
@@ -101,3 +198,54 @@ func (r *MongoUserRepository) DeleteByID(ctx context.Context, id primitive.Objec
     }
     return res.DeletedCount > 0, nil
 }
+
+func (r *MongoUserRepository) MarkTOTPStepUsed(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+    filter := bson.M{"_id": id, "totpLastStep": bson.M{"$not": bson.M{"$gte": step}}}
+    res, err := r.collection().UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totpLastStep": step}})
+    if err != nil {
+        return false, err
+    }
+    return res.ModifiedCount > 0, nil
+}
+
+func (r *MongoUserRepository) ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) (bool, error) {
+    res, err := r.collection().UpdateOne(ctx, bson.M{"_id": id, "recoveryCodes": hash}, bson.M{"$pull": bson.M{"recoveryCodes": hash}})
+    if err != nil {
+        return false, err
+    }
+    return res.ModifiedCount > 0, nil
+}
+
+func (r *MongoUserRepository) GetByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+    var user models.User
+    filter := notDeleted(bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}})
+    if err := r.collection().FindOne(ctx, filter).Decode(&user); err != nil {
+        return nil, err
+    }
+    return &user, nil
+}
+
+func (r *MongoUserRepository) AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.ExternalIdentity) (bool, error) {
+    filter := bson.M{"_id": id, "identities.provider": bson.M{"$ne": identity.Provider}}
+    res, err := r.collection().UpdateOne(ctx, filter, bson.M{"$push": bson.M{"identities": identity}})
+    if err != nil {
+        return false, err
+    }
+    return res.ModifiedCount > 0, nil
+}
+
+func (r *MongoUserRepository) SoftDelete(ctx context.Context, id primitive.ObjectID, by string, at time.Time) (bool, error) {
+    return softDelete(ctx, r.collection(), id, by, at)
+}
+
+func (r *MongoUserRepository) Restore(ctx context.Context, id primitive.ObjectID) (bool, error) {
+    return restore(ctx, r.collection(), id)
+}
+
+func (r *MongoUserRepository) ListDeleted(ctx context.Context, q utils.ListQuery) ([]models.User, int64, error) {
+    return listDeleted[models.User](ctx, r.collection(), q, userListProjection)
+}
+
+func (r *MongoUserRepository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+    return purgeDeletedBefore(ctx, r.collection(), before)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"API-GO/internal/database"
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoWebAuthnCredentialRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoWebAuthnCredentialRepository(client *mongo.Client) *MongoWebAuthnCredentialRepository {
+    return &MongoWebAuthnCredentialRepository{client: client}
+}
+
+func (r *MongoWebAuthnCredentialRepository) collection() *mongo.Collection {
+    return database.WebAuthnCredentialCollection(r.client)
+}
+
+func (r *MongoWebAuthnCredentialRepository) Create(ctx context.Context, c *models.WebAuthnCredential) error {
+    res, err := r.collection().InsertOne(ctx, c)
+    if mongo.IsDuplicateKeyError(err) {
+        return ErrDuplicateCredential
+    }
+    if err != nil {
+        return err
+    }
+    if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+        c.ID = oid
+    }
+    return nil
+}
+
+func (r *MongoWebAuthnCredentialRepository) GetByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+    var c models.WebAuthnCredential
+    err := r.collection().FindOne(ctx, bson.M{"credentialId": credentialID}).Decode(&c)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &c, nil
+}
+
+func (r *MongoWebAuthnCredentialRepository) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.WebAuthnCredential, error) {
+    cursor, err := r.collection().Find(ctx, bson.M{"userId": userID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+    if err != nil {
+        return nil, err
+    }
+    defer cursor.Close(ctx)
+    creds := []models.WebAuthnCredential{}
+    if err := cursor.All(ctx, &creds); err != nil {
+        return nil, err
+    }
+    return creds, nil
+}
+
+func (r *MongoWebAuthnCredentialRepository) UpdateUsage(ctx context.Context, id primitive.ObjectID, signCount uint32, backedUp bool, at time.Time) error {
+    _, err := r.collection().UpdateOne(ctx, bson.M{"_id": id},
+        bson.M{"$set": bson.M{"signCount": signCount, "backedUp": backedUp, "lastUsedAt": at}})
+    return err
+}
+
+func (r *MongoWebAuthnCredentialRepository) Delete(ctx context.Context, userID, id primitive.ObjectID) (bool, error) {
+    res, err := r.collection().DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+    if err != nil {
+        return false, err
+    }
+    return res.DeletedCount > 0, nil
+}
+
+type MongoWebAuthnChallengeRepository struct {
+    client *mongo.Client
+}
+
+func NewMongoWebAuthnChallengeRepository(client *mongo.Client) *MongoWebAuthnChallengeRepository {
+    return &MongoWebAuthnChallengeRepository{client: client}
+}
+
+func (r *MongoWebAuthnChallengeRepository) collection() *mongo.Collection {
+    return database.WebAuthnChallengeCollection(r.client)
+}
+
+func (r *MongoWebAuthnChallengeRepository) Create(ctx context.Context, c *models.WebAuthnChallenge) error {
+    _, err := r.collection().InsertOne(ctx, c)
+    return err
+}
+
+func (r *MongoWebAuthnChallengeRepository) Consume(ctx context.Context, challengeHash string, now time.Time) (*models.WebAuthnChallenge, error) {
+    var c models.WebAuthnChallenge
+    err := r.collection().FindOneAndDelete(ctx, bson.M{"_id": challengeHash, "expiresAt": bson.M{"$gt": now}}).Decode(&c)
+    if errors.Is(err, mongo.ErrNoDocuments) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &c, nil
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/models"
+)
+
+// OIDCStateRepository keeps pending OpenID Connect logins between the redirect and the callback.
+type OIDCStateRepository interface {
+    Create(ctx context.Context, s *models.OIDCState) error
+    // Consume atomically removes and returns an unexpired state, or nil when there is none.
+    Consume(ctx context.Context, stateHash string, now time.Time) (*models.OIDCState, error)
+}
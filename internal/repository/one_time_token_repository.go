@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OneTimeTokenRepository stores hashed single-use tokens (password reset, etc.).
+type OneTimeTokenRepository interface {
+    Create(ctx context.Context, t *models.OneTimeToken) error
+    // Find returns an unused, unexpired token without consuming it.
+    Find(ctx context.Context, purpose, hash string, now time.Time) (*models.OneTimeToken, error)
+    // Consume atomically marks an unused, unexpired token as used and returns it.
+    Consume(ctx context.Context, purpose, hash string, now time.Time) (*models.OneTimeToken, error)
+    // InvalidateForUser marks all of the user's outstanding tokens for purpose as used.
+    InvalidateForUser(ctx context.Context, userID primitive.ObjectID, purpose string, at time.Time) (int64, error)
+    // CountCreatedSince counts tokens issued to the user for purpose since the given time (used for throttling).
+    CountCreatedSince(ctx context.Context, userID primitive.ObjectID, purpose string, since time.Time) (int64, error)
+    // LatestForUser returns the most recently issued token for purpose, used or not.
+    LatestForUser(ctx context.Context, userID primitive.ObjectID, purpose string) (*models.OneTimeToken, error)
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RefreshTokenRepository stores hashed refresh tokens grouped in rotation families.
+type RefreshTokenRepository interface {
+    Create(ctx context.Context, t *models.RefreshToken) error
+    GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+    // MarkUsed atomically flags an unused, unrevoked token as consumed; false means it was already used.
+    MarkUsed(ctx context.Context, id primitive.ObjectID, replacedBy primitive.ObjectID, at time.Time) (bool, error)
+    RevokeFamily(ctx context.Context, familyID string, at time.Time) (int64, error)
+    RevokeAllForUser(ctx context.Context, userID primitive.ObjectID, at time.Time) (int64, error)
+    // ListActive returns the current (unused, unrevoked, unexpired) token of each of the user's families.
+    ListActive(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]models.RefreshToken, error)
+    // RevokeFamilyForUser is RevokeFamily limited to a family owned by userID.
+    RevokeFamilyForUser(ctx context.Context, userID primitive.ObjectID, familyID string, at time.Time) (int64, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"API-GO/internal/models"
+)
+
+// SettingsRepository persists runtime settings editable by admins.
+type SettingsRepository interface {
+    // GetSecurity returns the stored security settings, or zero-value settings when none were saved.
+    GetSecurity(ctx context.Context) (*models.SecuritySettings, error)
+    SaveSecurity(ctx context.Context, s *models.SecuritySettings) error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrRestoreConflict is returned when restoring a document would break a unique index,
+// e.g. a new account took the email of a deleted user.
+var ErrRestoreConflict = errors.New("an active document conflicts with the one being restored")
+
+// IsNotFound reports whether err means the document does not exist (or is in the trash).
+func IsNotFound(err error) bool {
+    return errors.Is(err, mongo.ErrNoDocuments)
+}
+
+// notDeleted restricts filter to documents that are not in the trash.
+func notDeleted(filter bson.M) bson.M {
+    filter["deletedAt"] = bson.M{"$exists": false}
+    return filter
+}
+
+// deletedOnly restricts filter to documents in the trash.
+func deletedOnly(filter bson.M) bson.M {
+    filter["deletedAt"] = bson.M{"$exists": true}
+    return filter
+}
+
+func softDelete(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID, by string, at time.Time) (bool, error) {
+    set := bson.M{"deletedAt": at}
+    if by != "" {
+        set["deletedBy"] = by
+    }
+    res, err := coll.UpdateOne(ctx, notDeleted(bson.M{"_id": id}), bson.M{"$set": set})
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
+
+func restore(ctx context.Context, coll *mongo.Collection, id primitive.ObjectID) (bool, error) {
+    res, err := coll.UpdateOne(ctx, deletedOnly(bson.M{"_id": id}), bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": ""}})
+    if mongo.IsDuplicateKeyError(err) {
+        return false, ErrRestoreConflict
+    }
+    if err != nil {
+        return false, err
+    }
+    return res.MatchedCount > 0, nil
+}
+
+func purgeDeletedBefore(ctx context.Context, coll *mongo.Collection, before time.Time) (int64, error) {
+    res, err := coll.DeleteMany(ctx, bson.M{"deletedAt": bson.M{"$lt": before}})
+    if err != nil {
+        return 0, err
+    }
+    return res.DeletedCount, nil
+}
+
+// listDeleted pages through the trash of coll; projection may be nil.
+func listDeleted[T any](ctx context.Context, coll *mongo.Collection, q utils.ListQuery, projection interface{}) ([]T, int64, error) {
+    filter := bson.M{}
+    for k, v := range q.Filter {
+        filter[k] = v
+    }
+    filter = deletedOnly(filter)
+    opts := options.Find()
+    if projection != nil {
+        opts.SetProjection(projection)
+    }
+    if len(q.Sort) > 0 {
+        opts.SetSort(q.Sort)
+    }
+    if q.Limit > 0 {
+        opts.SetLimit(q.Limit)
+        opts.SetSkip(q.Skip)
+    }
+    total, err := coll.CountDocuments(ctx, filter)
+    if err != nil {
+        return nil, 0, err
+    }
+    cur, err := coll.Find(ctx, filter, opts)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer cur.Close(ctx)
+    items := []T{}
+    if err := cur.All(ctx, &items); err != nil {
+        return nil, 0, err
+    }
+    return items, total, nil
+}
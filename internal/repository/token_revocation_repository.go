@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// TokenRevocationRepository tracks access tokens revoked before their natural expiry:
+// single tokens by jti (logout) and every token of a user issued before a cutoff (logout everywhere).
+type TokenRevocationRepository interface {
+    RevokeToken(ctx context.Context, jti, userID string, expiresAt time.Time) error
+    IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+    RevokeUserTokensBefore(ctx context.Context, userID string, before time.Time) error
+    // UserTokensRevokedBefore returns the user's cutoff, or the zero time when none was set.
+    UserTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error)
+}
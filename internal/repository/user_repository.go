@@ -4,18 +4,33 @@ import (
 	"context"
 
 	"API-GO/internal/models"
+	"API-GO/internal/utils"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // UserRepository defines the contract for user data access (SOLID: DIP)
 type UserRepository interface {
+    SoftDeleteRepository[models.User, primitive.ObjectID]
     Create(ctx context.Context, user *models.User) error
     GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
     GetByEmail(ctx context.Context, email string) (*models.User, error)
     EmailExists(ctx context.Context, email string, excludeID ...primitive.ObjectID) (bool, error)
     PhoneExists(ctx context.Context, phone string, excludeID ...primitive.ObjectID) (bool, error)
+    ExistsWithRole(ctx context.Context, role string) (bool, error)
     List(ctx context.Context) ([]models.User, error)
+    // ListWithQuery supports filtering/sorting/pagination; secrets (password hash, TOTP, recovery codes) are never loaded.
+    ListWithQuery(ctx context.Context, q utils.ListQuery) ([]models.User, int64, error)
     UpdateFields(ctx context.Context, id primitive.ObjectID, fields map[string]interface{}) (bool, error)
     DeleteByID(ctx context.Context, id primitive.ObjectID) (bool, error)
+    // SetStatus sets the account status and appends the change to the user's status history.
+    SetStatus(ctx context.Context, id primitive.ObjectID, change models.StatusChange) (bool, error)
+    // MarkTOTPStepUsed records the TOTP step atomically; false means that code (or a later one) was already used.
+    MarkTOTPStepUsed(ctx context.Context, id primitive.ObjectID, step int64) (bool, error)
+    // ConsumeRecoveryCode removes a hashed recovery code; false means it was not found.
+    ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) (bool, error)
+    // GetByIdentity finds the user linked to an external (OIDC) identity.
+    GetByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+    // AddIdentity links an external identity; false means the user already has one for that provider.
+    AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.ExternalIdentity) (bool, error)
 }
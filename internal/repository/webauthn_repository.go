@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"API-GO/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrDuplicateCredential is returned by Create when the credential id is already registered.
+var ErrDuplicateCredential = errors.New("credential already registered")
+
+// WebAuthnCredentialRepository stores registered passkeys; each belongs to one user.
+type WebAuthnCredentialRepository interface {
+    Create(ctx context.Context, c *models.WebAuthnCredential) error
+    // GetByCredentialID returns the credential with the authenticator-assigned id, or nil when there is none.
+    GetByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+    ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.WebAuthnCredential, error)
+    // UpdateUsage records a successful assertion: the new signature counter, backup state and time.
+    UpdateUsage(ctx context.Context, id primitive.ObjectID, signCount uint32, backedUp bool, at time.Time) error
+    Delete(ctx context.Context, userID, id primitive.ObjectID) (bool, error)
+}
+
+// WebAuthnChallengeRepository keeps pending WebAuthn ceremonies between begin and finish.
+type WebAuthnChallengeRepository interface {
+    Create(ctx context.Context, c *models.WebAuthnChallenge) error
+    // Consume atomically removes and returns an unexpired challenge, or nil when there is none.
+    Consume(ctx context.Context, challengeHash string, now time.Time) (*models.WebAuthnChallenge, error)
+}
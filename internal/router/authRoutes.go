@@ -2,18 +2,91 @@ package router
 
 import (
 	"API-GO/internal/handlers"
+	"API-GO/internal/middleware"
+	"API-GO/internal/models"
 	"API-GO/internal/services"
 
 	"github.com/gorilla/mux"
 )
 
-func NewAuthRouter(svc *services.AuthService, cookieName string, secure bool) *mux.Router {
+// AuthRouterDeps groups the services and settings behind the /auth routes.
+type AuthRouterDeps struct {
+    Auth          *services.AuthService
+    PasswordReset *services.PasswordResetService
+    EmailVerification *services.EmailVerificationService
+    TwoFactor     *services.TwoFactorService
+    APIKeys       *services.APIKeyService
+    OIDC          *services.OIDCService
+    MagicLink     *services.MagicLinkService
+    WebAuthn      *services.WebAuthnService
+    Impersonation *services.ImpersonationService
+    Authenticator *middleware.Authenticator
+    CookieName    string
+    SecureCookies bool
+    TrustProxyHeaders bool
+}
+
+func NewAuthRouter(d AuthRouterDeps) *mux.Router {
     r := mux.NewRouter()
-    h := handlers.NewAuthHandler(svc, cookieName, secure)
+    h := handlers.NewAuthHandler(d.Auth, d.CookieName, d.SecureCookies)
+    h.PasswordReset = d.PasswordReset
+    h.EmailVerification = d.EmailVerification
+    h.TwoFactor = d.TwoFactor
+    h.OIDC = d.OIDC
+    h.MagicLink = d.MagicLink
+    h.WebAuthn = d.WebAuthn
+    h.Impersonation = d.Impersonation
+    h.TrustProxyHeaders = d.TrustProxyHeaders
+    auth := d.Authenticator
+    // Rutele care schimbă credențialele sau sesiunile contului nu acceptă token-uri de impersonare
+    direct := auth.Direct()
 
     r.HandleFunc("/auth/signup", h.Signup()).Methods("POST")
     r.HandleFunc("/auth/login", h.Login()).Methods("POST")
-    r.HandleFunc("/auth/logout", h.Logout()).Methods("POST")
+    r.HandleFunc("/auth/login/2fa", h.LoginTwoFactor()).Methods("POST")
+    r.HandleFunc("/auth/oidc/{provider}/login", h.OIDCLogin()).Methods("GET")
+    r.HandleFunc("/auth/oidc/{provider}/callback", h.OIDCCallback()).Methods("GET")
+    r.HandleFunc("/auth/magic-link", h.RequestMagicLink()).Methods("POST")
+    r.HandleFunc("/auth/magic-link/consume", h.ConsumeMagicLink()).Methods("POST")
+    // Publice, dar citesc cookie-urile sesiunii: un token de impersonare trimis aici se auditează
+    r.Handle("/auth/refresh", guard(h.Refresh(), auth.Observe)).Methods("POST")
+    r.Handle("/auth/logout", guard(h.Logout(), auth.Observe)).Methods("POST")
+    r.Handle("/auth/logout-all", guard(h.LogoutAll(), direct.AllowIncomplete)).Methods("POST")
+    // AllowIncomplete: e singura cale de ieșire pentru un cont cu parolă temporară
+    r.Handle("/auth/change-password", guard(h.ChangePassword(), direct.AllowIncomplete)).Methods("POST")
+    r.HandleFunc("/auth/forgot-password", h.ForgotPassword()).Methods("POST")
+    r.HandleFunc("/auth/reset-password", h.ResetPassword()).Methods("POST")
+    r.HandleFunc("/auth/verify-email", h.VerifyEmail()).Methods("GET", "POST")
+    r.Handle("/auth/verify-email/resend", guard(h.ResendVerification(), auth.AllowIncomplete)).Methods("POST")
+
+    // 2FA: înrolarea e permisă și conturilor care încă nu au 2FA obligatoriu activat
+    adminOnly := direct.RequireRoles(models.RoleAdmin)
+    r.Handle("/auth/2fa/setup", guard(h.SetupTwoFactor(), direct.AllowIncomplete)).Methods("POST")
+    r.Handle("/auth/2fa/confirm", guard(h.ConfirmTwoFactor(), direct.AllowIncomplete)).Methods("POST")
+    r.Handle("/auth/2fa/disable", guard(h.DisableTwoFactor(), direct.Require)).Methods("POST")
+    r.Handle("/auth/2fa/recovery-codes", guard(h.RegenerateRecoveryCodes(), direct.Require)).Methods("POST")
+    r.Handle("/auth/2fa/policy", guard(h.GetTwoFactorPolicy(), adminOnly)).Methods("GET")
+    r.Handle("/auth/2fa/policy", guard(h.SetTwoFactorPolicy(), adminOnly)).Methods("PUT")
+
+    r.Handle("/auth/me", guard(h.Me(), auth.AllowIncomplete)).Methods("GET")
+    r.Handle("/auth/sessions", guard(h.ListSessions(), auth.Require)).Methods("GET")
+    r.Handle("/auth/sessions/{id}", guard(h.RevokeSession(), direct.Require)).Methods("DELETE")
+
+    // Passkey-uri (WebAuthn): înregistrarea cere o sesiune, login-ul e public
+    r.Handle("/auth/webauthn/register/begin", guard(h.BeginPasskeyRegistration(), direct.Require)).Methods("POST")
+    r.Handle("/auth/webauthn/register/finish", guard(h.FinishPasskeyRegistration(), direct.Require)).Methods("POST")
+    r.HandleFunc("/auth/webauthn/login/begin", h.BeginPasskeyLogin()).Methods("POST")
+    r.HandleFunc("/auth/webauthn/login/finish", h.FinishPasskeyLogin()).Methods("POST")
+    r.Handle("/auth/webauthn/credentials", guard(h.ListPasskeys(), auth.Require)).Methods("GET")
+    r.Handle("/auth/webauthn/credentials/{id}", guard(h.DeletePasskey(), direct.Require)).Methods("DELETE")
+
+    // Cheile API se gestionează doar dintr-o sesiune (Require nu acceptă X-API-Key)
+    MountCRUD(r, "/auth/api-keys", handlers.NewAPIKeysHandler(d.APIKeys), AllGuarded(direct.Require))
+
+    // Impersonare: adminul primește un token cu care vede API-ul ca userul {id}; totul e auditat
+    r.Handle("/auth/impersonate/stop", guard(h.StopImpersonation(), auth.AllowIncomplete)).Methods("POST")
+    r.Handle("/auth/impersonate/audit", guard(h.ListImpersonationAudit(), adminOnly)).Methods("GET")
+    r.Handle("/auth/impersonate/{id}", guard(h.StartImpersonation(), adminOnly)).Methods("POST")
 
     return r
 }
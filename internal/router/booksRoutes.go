@@ -2,14 +2,30 @@ package router
 
 import (
 	"API-GO/internal/handlers"
+	"API-GO/internal/middleware"
+	"API-GO/internal/models"
 	"API-GO/internal/repository"
 
 	"github.com/gorilla/mux"
 )
 
-func NewBooksRouter(repo repository.BookRepository) *mux.Router {
+// NewBooksRouter keeps the catalog readable by anyone; changes are reserved to librarians and admins
+// (sessions, or API keys with the books:write scope).
+func NewBooksRouter(repo repository.BookRepository, auth *middleware.Authenticator) *mux.Router {
     r := mux.NewRouter()
     h := handlers.NewBooksHandler(repo)
-    MountCRUD(r, "/books", h)
+    staff := auth.WithScope(models.ScopeBooksWrite).RequireRoles(models.RoleLibrarian, models.RoleAdmin)
+    // Coșul: înregistrat înaintea rutelor cu {id}
+    admin := auth.WithScope(models.ScopeBooksWrite).RequireRoles(models.RoleAdmin)
+    r.Handle("/books/trash", guard(h.GetTrash(), admin)).Methods("GET")
+    r.Handle("/books/{id}/restore", guard(h.Restore(), admin)).Methods("POST")
+    MountCRUD(r, "/books", h, CRUDGuards{
+        // Citirile rămân publice; cele făcute cu un token de impersonare se auditează
+        List:   auth.Observe,
+        Get:    auth.Observe,
+        Create: staff,
+        Update: staff,
+        Delete: staff,
+    })
     return r
 }
@@ -15,11 +15,36 @@ type CRUDHandlers interface {
     Delete() http.HandlerFunc
 }
 
+// CRUDGuards declares the middleware applied to each CRUD route.
+// A nil entry leaves that route public.
+type CRUDGuards struct {
+    List   mux.MiddlewareFunc
+    Get    mux.MiddlewareFunc
+    Create mux.MiddlewareFunc
+    Update mux.MiddlewareFunc
+    Delete mux.MiddlewareFunc
+}
+
+// AllGuarded applies the same middleware to every CRUD route.
+func AllGuarded(mw mux.MiddlewareFunc) CRUDGuards {
+    return CRUDGuards{List: mw, Get: mw, Create: mw, Update: mw, Delete: mw}
+}
+
 // MountCRUD wires standard CRUD routes under the given base path.
-func MountCRUD(r *mux.Router, base string, h CRUDHandlers) {
-    r.HandleFunc(base, h.GetAll()).Methods("GET")
-    r.HandleFunc(base+"/{id}", h.GetOne()).Methods("GET")
-    r.HandleFunc(base, h.Create()).Methods("POST")
-    r.HandleFunc(base+"/{id}", h.Update()).Methods("PUT")
-    r.HandleFunc(base+"/{id}", h.Delete()).Methods("DELETE")
+func MountCRUD(r *mux.Router, base string, h CRUDHandlers, g CRUDGuards) {
+    r.Handle(base, guard(h.GetAll(), g.List)).Methods("GET")
+    r.Handle(base+"/{id}", guard(h.GetOne(), g.Get)).Methods("GET")
+    r.Handle(base, guard(h.Create(), g.Create)).Methods("POST")
+    r.Handle(base+"/{id}", guard(h.Update(), g.Update)).Methods("PUT")
+    r.Handle(base+"/{id}", guard(h.Delete(), g.Delete)).Methods("DELETE")
+}
+
+// guard wraps h with the given middleware; the first one runs outermost.
+func guard(h http.Handler, mws ...mux.MiddlewareFunc) http.Handler {
+    for i := len(mws) - 1; i >= 0; i-- {
+        if mws[i] != nil {
+            h = mws[i](h)
+        }
+    }
+    return h
 }
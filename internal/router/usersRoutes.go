@@ -2,20 +2,43 @@ package router
 
 import (
 	"API-GO/internal/handlers"
+	"API-GO/internal/middleware"
+	"API-GO/internal/models"
 	"API-GO/internal/repository"
+	"API-GO/internal/services"
 
 	"github.com/gorilla/mux"
 )
 
-// NewUsersRouter construieşte routerul de users folosind repository
-func NewUsersRouter(repo repository.UserRepository) *mux.Router {
+// NewUsersRouter construieşte routerul de users folosind repository; toate rutele cer autentificare
+func NewUsersRouter(repo repository.UserRepository, admin *services.UserAdminService, auth *middleware.Authenticator) *mux.Router {
     r := mux.NewRouter()
     h := handlers.NewUsersHandler(repo)
+    h.Revocations = auth.Revocations
+    h.Admin = admin
+    // Cheile API trec doar cu scope-ul potrivit; rolurile proprietarului se aplică în continuare
+    read := auth.WithScope(models.ScopeUsersRead)
+    write := auth.WithScope(models.ScopeUsersWrite)
 
-    r.HandleFunc("/users", h.GetAllUsers()).Methods("GET")
-    r.HandleFunc("/users/{id}", h.GetUser()).Methods("GET")
-    r.HandleFunc("/users/{id}", h.UpdateUser()).Methods("PUT")
-    r.HandleFunc("/users/{id}", h.DeleteUser()).Methods("DELETE")
+    r.Handle("/users", guard(h.GetAllUsers(), read.RequireRoles(models.RoleAdmin))).Methods("GET")
+    r.Handle("/users", guard(h.CreateUser(), write.Direct().RequireRoles(models.RoleAdmin))).Methods("POST")
+    // Coșul (useri șterși soft); înregistrat înaintea rutelor cu {id}
+    r.Handle("/users/trash", guard(h.ListDeletedUsers(), read.RequireRoles(models.RoleAdmin))).Methods("GET")
+    r.Handle("/users/{id}/restore", guard(h.RestoreUser(), write.Direct().RequireRoles(models.RoleAdmin))).Methods("POST")
+    // Alias pentru contul curent; înregistrat înaintea rutelor cu {id}
+    r.Handle("/users/me", guard(h.GetUser(), read.AllowIncomplete)).Methods("GET")
+    // Schimbarea datelor contului (inclusiv emailul) și ștergerea nu sunt permise cu un token de impersonare
+    r.Handle("/users/me", guard(h.UpdateUser(), write.Direct().Require)).Methods("PUT")
+    r.Handle("/users/me", guard(h.DeleteUser(), write.Direct().Require)).Methods("DELETE")
+    r.Handle("/users/{id}", guard(h.GetUser(), read.Require)).Methods("GET")
+    // Update/Delete verifică în handler că apelantul e proprietarul contului sau admin
+    r.Handle("/users/{id}", guard(h.UpdateUser(), write.Direct().Require)).Methods("PUT")
+    r.Handle("/users/{id}", guard(h.DeleteUser(), write.Direct().Require)).Methods("DELETE")
+    r.Handle("/users/{id}/roles", guard(h.SetRoles(), write.Direct().RequireRoles(models.RoleAdmin))).Methods("PUT")
+    // Starea contului; motivul se păstrează în statusHistory
+    r.Handle("/users/{id}/suspend", guard(h.SetStatus(models.UserStatusSuspended), write.Direct().RequireRoles(models.RoleAdmin))).Methods("POST")
+    r.Handle("/users/{id}/disable", guard(h.SetStatus(models.UserStatusDisabled), write.Direct().RequireRoles(models.RoleAdmin))).Methods("POST")
+    r.Handle("/users/{id}/reactivate", guard(h.SetStatus(models.UserStatusActive), write.Direct().RequireRoles(models.RoleAdmin))).Methods("POST")
 
     return r
 }
\ No newline at end of file
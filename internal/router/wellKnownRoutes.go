@@ -0,0 +1,18 @@
+package router
+
+import (
+	"API-GO/internal/handlers"
+	"API-GO/internal/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// NewWellKnownRouter serves public discovery documents mounted at the server root.
+func NewWellKnownRouter(jwt *utils.JWTManager) *mux.Router {
+    r := mux.NewRouter()
+    h := handlers.NewKeysHandler(jwt)
+
+    r.HandleFunc("/.well-known/jwks.json", h.JWKS()).Methods("GET")
+
+    return r
+}
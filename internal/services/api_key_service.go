@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+const (
+    apiKeyPrefix      = "ak_"
+    apiKeyPrefixLen   = 11 // "ak_" + 8 caractere afișate în listă
+    maxAPIKeysPerUser = 20
+    // lastUsedAt se actualizează cel mult o dată pe minut, nu la fiecare request
+    apiKeyTouchInterval = time.Minute
+)
+
+// APIKeyService gestionează cheile API ale utilizatorilor și le rezolvă la autentificare.
+type APIKeyService struct {
+    Keys  repository.APIKeyRepository
+    Users repository.UserRepository
+    // TwoFactor aplică politica 2FA pe roluri și cheilor, ca la emiterea token-urilor; nil o dezactivează
+    TwoFactor *TwoFactorService
+}
+
+func NewAPIKeyService(keys repository.APIKeyRepository, users repository.UserRepository) *APIKeyService {
+    return &APIKeyService{Keys: keys, Users: users}
+}
+
+// Create generează o cheie nouă; valoarea în clar e întoarsă doar acum.
+func (s *APIKeyService) Create(ctx context.Context, userID string, in models.APIKeyInput) (*models.APIKeyCreated, error) {
+    uid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, err
+    }
+    name := strings.TrimSpace(in.Name)
+    if name == "" || len(name) > 100 {
+        return nil, invalid("name is required (max 100 characters)")
+    }
+    scopes, err := cleanScopes(in.Scopes)
+    if err != nil {
+        return nil, err
+    }
+    now := time.Now()
+    if in.ExpiresAt != nil && !in.ExpiresAt.After(now) {
+        return nil, invalid("expiresAt must be in the future")
+    }
+    count, err := s.Keys.CountForUser(ctx, uid)
+    if err != nil {
+        return nil, err
+    }
+    if count >= maxAPIKeysPerUser {
+        return nil, invalid("api key limit reached; delete an unused key first")
+    }
+    token, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return nil, err
+    }
+    raw := apiKeyPrefix + token
+    k := models.APIKey{
+        UserID:    uid,
+        Name:      name,
+        Prefix:    raw[:apiKeyPrefixLen],
+        KeyHash:   utils.HashToken(raw),
+        Scopes:    scopes,
+        CreatedAt: now,
+        ExpiresAt: in.ExpiresAt,
+    }
+    if err := s.Keys.Create(ctx, &k); err != nil {
+        return nil, err
+    }
+    logger.Infof("api_key_created", logger.Fields{"user_id": userID, "key_id": k.ID.Hex(), "scopes": scopes})
+    return &models.APIKeyCreated{APIKey: k, Key: raw}, nil
+}
+
+// List întoarce cheile userului (fără valori în clar).
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]models.APIKey, error) {
+    uid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, err
+    }
+    return s.Keys.ListForUser(ctx, uid)
+}
+
+func (s *APIKeyService) Get(ctx context.Context, userID, id string) (*models.APIKey, error) {
+    uid, kid, err := apiKeyIDs(userID, id)
+    if err != nil {
+        return nil, err
+    }
+    k, err := s.Keys.GetForUser(ctx, uid, kid)
+    if err != nil {
+        return nil, err
+    }
+    if k == nil {
+        return nil, ErrAPIKeyNotFound
+    }
+    return k, nil
+}
+
+// Update modifică numele, scope-urile și/sau expirarea; câmpurile goale rămân neschimbate.
+func (s *APIKeyService) Update(ctx context.Context, userID, id string, in models.APIKeyInput) (*models.APIKey, error) {
+    uid, kid, err := apiKeyIDs(userID, id)
+    if err != nil {
+        return nil, err
+    }
+    fields := map[string]interface{}{}
+    if name := strings.TrimSpace(in.Name); name != "" {
+        if len(name) > 100 {
+            return nil, invalid("name cannot exceed 100 characters")
+        }
+        fields["name"] = name
+    }
+    if in.Scopes != nil {
+        scopes, err := cleanScopes(in.Scopes)
+        if err != nil {
+            return nil, err
+        }
+        fields["scopes"] = scopes
+    }
+    if in.ExpiresAt != nil {
+        if !in.ExpiresAt.After(time.Now()) {
+            return nil, invalid("expiresAt must be in the future")
+        }
+        fields["expiresAt"] = *in.ExpiresAt
+    }
+    if len(fields) == 0 {
+        return nil, invalid("no valid fields to update")
+    }
+    ok, err := s.Keys.UpdateFields(ctx, uid, kid, fields)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        return nil, ErrAPIKeyNotFound
+    }
+    return s.Get(ctx, userID, id)
+}
+
+// Delete revocă definitiv cheia.
+func (s *APIKeyService) Delete(ctx context.Context, userID, id string) error {
+    uid, kid, err := apiKeyIDs(userID, id)
+    if err != nil {
+        return err
+    }
+    ok, err := s.Keys.Delete(ctx, uid, kid)
+    if err != nil {
+        return err
+    }
+    if !ok {
+        return ErrAPIKeyNotFound
+    }
+    logger.Infof("api_key_deleted", logger.Fields{"user_id": userID, "key_id": id})
+    return nil
+}
+
+// Authenticate rezolvă o cheie primită în X-API-Key la identitatea proprietarului și scope-urile cheii.
+// Întoarce (nil, nil) pentru chei necunoscute sau expirate; eroarea e rezervată problemelor de stocare.
+func (s *APIKeyService) Authenticate(ctx context.Context, raw string) (*utils.UserClaims, error) {
+    if !strings.HasPrefix(raw, apiKeyPrefix) {
+        return nil, nil
+    }
+    k, err := s.Keys.GetByHash(ctx, utils.HashToken(raw))
+    if err != nil || k == nil {
+        return nil, err
+    }
+    now := time.Now()
+    if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+        return nil, nil
+    }
+    u, err := s.Users.GetByID(ctx, k.UserID)
+    if err != nil {
+        // Proprietarul a fost șters între timp
+        return nil, nil
+    }
+    if accountStatusError(u) != nil {
+        // Cheile unui cont suspendat sau dezactivat nu mai sunt acceptate
+        return nil, nil
+    }
+    // Până la înrolare cheia are doar accesul limitat al unei sesiuni fără 2FA
+    setupRequired, err := s.TwoFactor.SetupRequired(ctx, u)
+    if err != nil {
+        return nil, err
+    }
+    if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchInterval {
+        if err := s.Keys.TouchLastUsed(ctx, k.ID, now); err != nil {
+            logger.Warnf("api_key_touch_failed", logger.Fields{"key_id": k.ID.Hex(), "error": err.Error()})
+        }
+    }
+    return &utils.UserClaims{
+        UserID:                 u.ID.Hex(),
+        Email:                  u.Email,
+        Roles:                  u.EffectiveRoles(),
+        EmailVerified:          u.EmailVerified,
+        TwoFactorSetupRequired: setupRequired,
+        PasswordChangeRequired: u.MustChangePassword,
+        APIKeyID:               k.ID.Hex(),
+        Scopes:                 k.Scopes,
+    }, nil
+}
+
+func cleanScopes(scopes []string) ([]string, error) {
+    if len(scopes) == 0 {
+        return nil, invalid("at least one scope is required")
+    }
+    clean := []string{}
+    seen := map[string]bool{}
+    for _, sc := range scopes {
+        if !models.IsValidScope(sc) {
+            return nil, invalid("invalid scope: " + sc)
+        }
+        if !seen[sc] {
+            seen[sc] = true
+            clean = append(clean, sc)
+        }
+    }
+    return clean, nil
+}
+
+// apiKeyIDs convertește ID-urile; un ID de cheie invalid e tratat ca inexistent
+func apiKeyIDs(userID, id string) (primitive.ObjectID, primitive.ObjectID, error) {
+    uid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return uid, primitive.NilObjectID, err
+    }
+    kid, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return uid, kid, ErrAPIKeyNotFound
+    }
+    return uid, kid, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// oneKey serves a single stored key and ignores lastUsedAt updates.
+type oneKey struct {
+    repository.APIKeyRepository
+    key models.APIKey
+}
+
+func (r *oneKey) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+    if hash != r.key.KeyHash {
+        return nil, nil
+    }
+    k := r.key
+    return &k, nil
+}
+
+func (r *oneKey) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+    return nil
+}
+
+// fixedSettings returns the same security settings on every read.
+type fixedSettings struct {
+    repository.SettingsRepository
+    settings models.SecuritySettings
+}
+
+func (r *fixedSettings) GetSecurity(ctx context.Context) (*models.SecuritySettings, error) {
+    s := r.settings
+    return &s, nil
+}
+
+func TestAuthenticateAppliesTwoFactorRolePolicy(t *testing.T) {
+    u := &models.User{ID: primitive.NewObjectID(), Email: "admin@example.com", Roles: []string{models.RoleAdmin}}
+    raw := apiKeyPrefix + "secret"
+    users := &totpUsers{user: u}
+    s := &APIKeyService{
+        Keys:  &oneKey{key: models.APIKey{ID: primitive.NewObjectID(), UserID: u.ID, KeyHash: utils.HashToken(raw), Scopes: []string{models.ScopeUsersRead}}},
+        Users: users,
+        TwoFactor: &TwoFactorService{
+            Users:    users,
+            Settings: &fixedSettings{settings: models.SecuritySettings{TwoFactorRequiredRoles: []string{models.RoleAdmin}}},
+        },
+    }
+
+    claims, err := s.Authenticate(context.Background(), raw)
+    if err != nil || claims == nil {
+        t.Fatalf("Authenticate = %v, %v", claims, err)
+    }
+    if !claims.TwoFactorSetupRequired {
+        t.Fatal("key of an admin without 2FA got full access")
+    }
+
+    u.TOTPEnabled = true
+    if claims, _ = s.Authenticate(context.Background(), raw); claims.TwoFactorSetupRequired {
+        t.Fatal("key still limited after 2FA was enabled")
+    }
+}
@@ -3,9 +3,11 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"sync"
 	"time"
 
+	"API-GO/internal/logger"
 	"API-GO/internal/models"
 	"API-GO/internal/repository"
 	"API-GO/internal/utils"
@@ -13,28 +15,82 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+    ErrInvalidRefreshToken = errors.New("invalid refresh token")
+    ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+    ErrSessionNotFound     = errors.New("session not found")
+    ErrAccountSuspended    = errors.New("account suspended")
+    ErrAccountDisabled     = errors.New("account disabled")
+    ErrTemporaryPasswordExpired = errors.New("temporary password has expired")
+)
+
+// accountStatusError întoarce eroarea pentru conturile care nu pot primi sesiuni (nil pentru cele active).
+func accountStatusError(u *models.User) error {
+    switch u.EffectiveStatus() {
+    case models.UserStatusSuspended:
+        return ErrAccountSuspended
+    case models.UserStatusDisabled:
+        return ErrAccountDisabled
+    }
+    return nil
+}
+
+// temporaryPasswordExpired spune dacă userul are încă parola temporară și aceasta a expirat.
+func temporaryPasswordExpired(u *models.User, now time.Time) bool {
+    return u.MustChangePassword && u.TemporaryPasswordExpiresAt != nil && !now.Before(*u.TemporaryPasswordExpiresAt)
+}
+
+// clearTemporaryPassword adaugă la fields câmpurile care scot contul din starea de parolă temporară.
+func clearTemporaryPassword(fields map[string]interface{}) {
+    fields["mustChangePassword"] = false
+    fields["temporaryPasswordExpiresAt"] = nil
+}
+
 type AuthService struct {
-    Users repository.UserRepository
-    JWT   *utils.JWTManager
+    Users         repository.UserRepository
+    RefreshTokens repository.RefreshTokenRepository
+    Revocations   repository.TokenRevocationRepository
+    JWT           *utils.JWTManager
+    // Opțional: trimiterea emailului de verificare la signup și politica pentru conturi neverificate
+    EmailVerification *EmailVerificationService
+    // Opțional: 2FA (TOTP) în doi pași la login
+    TwoFactor *TwoFactorService
+    // Opțional: protecție brute-force (contoare per cont și IP, blocare temporară)
+    Throttle *LoginThrottle
+    // Hash-uri noi cu algoritmul preferat; cele vechi sunt acceptate și refăcute la login
+    Passwords utils.PasswordHasher
+    // Reguli pentru parolele noi (signup, schimbare, resetare)
+    PasswordPolicy utils.PasswordPolicy
+    // Opțional: lista locală de parole compromise
+    BreachedPasswords utils.BreachedPasswordChecker
 }
 
-func NewAuthService(users repository.UserRepository, jwt *utils.JWTManager) *AuthService {
-    return &AuthService{Users: users, JWT: jwt}
+func NewAuthService(users repository.UserRepository, refresh repository.RefreshTokenRepository, revocations repository.TokenRevocationRepository, jwt *utils.JWTManager) *AuthService {
+    return &AuthService{Users: users, RefreshTokens: refresh, Revocations: revocations, JWT: jwt, Passwords: utils.DefaultPasswordHasher(), PasswordPolicy: utils.DefaultPasswordPolicy()}
 }
 
 // SignUp creează un utilizator nou
-func (s *AuthService) SignUp(ctx context.Context, in models.AuthSignUpRequest) (*models.AuthResponse, string, time.Time, error) {
+func (s *AuthService) SignUp(ctx context.Context, in models.AuthSignUpRequest, client models.ClientInfo) (*models.AuthResponse, *models.TokenPair, error) {
+    in.Email = utils.NormalizeEmail(in.Email)
     if in.Name == "" || in.Email == "" || in.Password == "" {
-        return nil, "", time.Time{}, errors.New("name, email and password are required")
+        return nil, nil, errors.New("name, email and password are required")
     }
     if in.Password != in.PasswordConfirm {
-        return nil, "", time.Time{}, errors.New("passwords do not match")
+        return nil, nil, errors.New("passwords do not match")
     }
     if !utils.IsValidEmail(in.Email) {
-        return nil, "", time.Time{}, errors.New("invalid email format")
+        return nil, nil, errors.New("invalid email format")
     }
-    if in.Phone != "" && !utils.IsValidPhone(in.Phone) {
-        return nil, "", time.Time{}, errors.New("invalid phone format")
+    if err := s.ValidateNewPassword(in.Password, in.Name, in.Email); err != nil {
+        return nil, nil, err
+    }
+    if in.Phone != "" {
+        // Telefonul se salvează în format E.164, ca indexul unic să prindă orice scriere a aceluiași număr
+        phone, err := utils.NormalizePhone(in.Phone)
+        if err != nil {
+            return nil, nil, errors.New("invalid phone format")
+        }
+        in.Phone = phone
     }
 
     var wg sync.WaitGroup
@@ -60,24 +116,24 @@ func (s *AuthService) SignUp(ctx context.Context, in models.AuthSignUpRequest) (
     wg.Add(1)
     go func() {
         defer wg.Done()
-        hashed, hashErr = utils.HashPassword(in.Password)
+        hashed, hashErr = s.Passwords.Hash(in.Password)
     }()
     wg.Wait()
     if emailErr != nil {
-        return nil, "", time.Time{}, emailErr
+        return nil, nil, emailErr
     }
     if phoneErr != nil {
-        return nil, "", time.Time{}, phoneErr
+        return nil, nil, phoneErr
     }
     if emailExists {
-        return nil, "", time.Time{}, errors.New("email already exists")
+        return nil, nil, errors.New("email already exists")
     }
     if phoneExists {
-        return nil, "", time.Time{}, errors.New("phone number already exists")
+        return nil, nil, errors.New("phone number already exists")
     }
 
     if hashErr != nil {
-        return nil, "", time.Time{}, hashErr
+        return nil, nil, hashErr
     }
 
     u := models.User{
@@ -86,36 +142,446 @@ func (s *AuthService) SignUp(ctx context.Context, in models.AuthSignUpRequest) (
         Email:    in.Email,
     Password: hashed,
         Phone:    in.Phone,
+        Roles:    []string{models.RoleMember},
     }
     if err := s.Users.Create(ctx, &u); err != nil {
-        return nil, "", time.Time{}, err
+        return nil, nil, err
+    }
+    if s.EmailVerification != nil {
+        s.EmailVerification.SendAsync(u, false)
+        // Cu politica "block" contul nu primește sesiune până la confirmarea emailului
+        if s.EmailVerification.Policy == models.EmailPolicyBlock {
+            return authResponse(&u), nil, nil
+        }
     }
 
-    token, exp, err := s.JWT.GenerateToken(u.ID.Hex(), u.Email)
+    tokens, err := s.issueTokens(ctx, &u, client)
     if err != nil {
-        return nil, "", time.Time{}, err
+        return nil, nil, err
     }
-
-    resp := &models.AuthResponse{ID: u.ID.Hex(), Name: u.Name, Email: u.Email, Phone: u.Phone}
-    return resp, token, exp, nil
+    return authResponse(&u), tokens, nil
 }
 
 // Login autentifică un utilizator existent
-func (s *AuthService) Login(ctx context.Context, in models.AuthLoginRequest) (*models.AuthResponse, string, time.Time, error) {
+// client.IP e folosit și pentru limitarea încercărilor eșuate (poate fi gol).
+func (s *AuthService) Login(ctx context.Context, in models.AuthLoginRequest, client models.ClientInfo) (*models.AuthResponse, *models.TokenPair, error) {
+    // Și limitarea încercărilor folosește emailul normalizat, altfel "Ana@x.ro" ar avea alt contor
+    in.Email = utils.NormalizeEmail(in.Email)
     if in.Email == "" || in.Password == "" {
-        return nil, "", time.Time{}, errors.New("email and password are required")
+        return nil, nil, errors.New("email and password are required")
+    }
+    if err := s.Throttle.Check(ctx, in.Email, client.IP); err != nil {
+        return nil, nil, err
     }
     u, err := s.Users.GetByEmail(ctx, in.Email)
     if err != nil {
-        return nil, "", time.Time{}, errors.New("invalid credentials")
+        s.Throttle.Failure(ctx, in.Email, client.IP)
+        return nil, nil, errors.New("invalid credentials")
+    }
+    if !s.Passwords.Verify(u.Password, in.Password) {
+        s.Throttle.Failure(ctx, in.Email, client.IP)
+        return nil, nil, errors.New("invalid credentials")
+    }
+    s.rehashIfNeeded(ctx, u, in.Password)
+    // Starea se dezvăluie doar după o parolă corectă
+    if err := accountStatusError(u); err != nil {
+        return nil, nil, err
+    }
+    if temporaryPasswordExpired(u, time.Now()) {
+        return nil, nil, ErrTemporaryPasswordExpired
+    }
+    if !u.EmailVerified && s.EmailVerification != nil && s.EmailVerification.Policy == models.EmailPolicyBlock {
+        // Retrimite linkul (respectând limitele) ca userul să se poată debloca
+        s.EmailVerification.SendAsync(*u, true)
+        return nil, nil, ErrEmailNotVerified
+    }
+    if s.TwoFactor != nil && u.TOTPEnabled {
+        // Contorul contului rămâne până la finalizarea pasului 2FA
+        return nil, nil, s.TwoFactor.Challenge(u)
+    }
+    tokens, err := s.issueTokens(ctx, u, client)
+    if err != nil {
+        return nil, nil, err
+    }
+    s.Throttle.Success(ctx, u.Email)
+    return authResponse(u), tokens, nil
+}
+
+// ValidateNewPassword aplică politica de parole și, dacă e configurată, verificarea în lista de parole compromise.
+// Dacă lista nu poate fi citită, eroarea se loghează și parola nu e respinsă.
+func (s *AuthService) ValidateNewPassword(password, name, email string) error {
+    if errs := s.PasswordPolicy.Validate(password, name, email); len(errs) > 0 {
+        return invalid(strings.Join(errs, "; "))
+    }
+    if s.BreachedPasswords == nil {
+        return nil
+    }
+    breached, err := s.BreachedPasswords.IsBreached(password)
+    if err != nil {
+        logger.Warnf("breached_password_check_failed", logger.Fields{"error": err.Error()})
+        return nil
+    }
+    if breached {
+        return invalid("this password has appeared in a data breach; please choose a different one")
+    }
+    return nil
+}
+
+// rehashIfNeeded înlocuiește hash-ul stocat când a fost creat cu alt algoritm sau cu parametri mai vechi.
+// Parola tocmai a fost verificată, deci e singurul moment în care hash-ul poate fi refăcut; erorile doar se loghează.
+func (s *AuthService) rehashIfNeeded(ctx context.Context, u *models.User, plain string) {
+    if !s.Passwords.NeedsRehash(u.Password) {
+        return
+    }
+    hashed, err := s.Passwords.Hash(plain)
+    if err == nil {
+        _, err = s.Users.UpdateFields(ctx, u.ID, map[string]interface{}{"password": hashed})
+    }
+    if err != nil {
+        logger.Warnf("password_rehash_failed", logger.Fields{"user_id": u.ID.Hex(), "error": err.Error()})
+        return
+    }
+    u.Password = hashed
+    logger.Infof("password_rehashed", logger.Fields{"user_id": u.ID.Hex()})
+}
+
+// ChangePassword verifică parola curentă, setează parola nouă și revocă toate celelalte sesiuni.
+// Sesiunea apelantului primește o pereche nouă de token-uri.
+func (s *AuthService) ChangePassword(ctx context.Context, userID string, in models.ChangePasswordRequest, client models.ClientInfo) (*models.AuthResponse, *models.TokenPair, error) {
+    if in.CurrentPassword == "" || in.NewPassword == "" {
+        return nil, nil, invalid("current and new password are required")
+    }
+    if in.NewPassword != in.NewPasswordConfirm {
+        return nil, nil, invalid("passwords do not match")
+    }
+    if in.NewPassword == in.CurrentPassword {
+        return nil, nil, invalid("new password must be different from the current one")
+    }
+    oid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, nil, err
+    }
+    u, err := s.Users.GetByID(ctx, oid)
+    if err != nil {
+        return nil, nil, err
+    }
+    // Parola curentă se verifică înaintea politicii pentru cea nouă, iar încercările greșite se numără
+    // ca la login, ca o sesiune furată să nu poată ghici parola
+    if err := s.Throttle.Check(ctx, u.Email, client.IP); err != nil {
+        return nil, nil, err
+    }
+    if !s.Passwords.Verify(u.Password, in.CurrentPassword) {
+        s.Throttle.Failure(ctx, u.Email, client.IP)
+        logger.Warnf("change_password_wrong_current", logger.Fields{"user_id": userID})
+        return nil, nil, invalid("current password is incorrect")
+    }
+    if temporaryPasswordExpired(u, time.Now()) {
+        return nil, nil, ErrTemporaryPasswordExpired
+    }
+    if err := s.ValidateNewPassword(in.NewPassword, u.Name, u.Email); err != nil {
+        return nil, nil, err
+    }
+    hashed, err := s.Passwords.Hash(in.NewPassword)
+    if err != nil {
+        return nil, nil, err
+    }
+    fields := map[string]interface{}{"password": hashed}
+    if u.MustChangePassword {
+        clearTemporaryPassword(fields)
+    }
+    if _, err := s.Users.UpdateFields(ctx, oid, fields); err != nil {
+        return nil, nil, err
+    }
+    u.MustChangePassword, u.TemporaryPasswordExpiresAt = false, nil
+    if err := s.LogoutEverywhere(ctx, userID, time.Now()); err != nil {
+        return nil, nil, err
+    }
+    tokens, err := s.issueTokens(ctx, u, client)
+    if err != nil {
+        return nil, nil, err
+    }
+    s.Throttle.Success(ctx, u.Email)
+    logger.Infof("password_changed", logger.Fields{"user_id": userID})
+    return authResponse(u), tokens, nil
+}
+
+// Refresh rotește refresh token-ul: cel prezentat devine consumat și se emite o pereche nouă în aceeași familie.
+// Un token deja folosit prezentat din nou indică furt, așa că întreaga familie e revocată.
+func (s *AuthService) Refresh(ctx context.Context, raw string, client models.ClientInfo) (*models.AuthResponse, *models.TokenPair, error) {
+    if raw == "" {
+        return nil, nil, ErrInvalidRefreshToken
+    }
+    now := time.Now()
+    rt, err := s.RefreshTokens.GetByHash(ctx, utils.HashToken(raw))
+    if err != nil {
+        return nil, nil, ErrInvalidRefreshToken
+    }
+    if rt.RevokedAt != nil || !now.Before(rt.ExpiresAt) {
+        return nil, nil, ErrInvalidRefreshToken
+    }
+    if rt.UsedAt != nil {
+        return nil, nil, s.revokeReusedFamily(ctx, rt, now)
+    }
+    u, err := s.Users.GetByID(ctx, rt.UserID)
+    if err != nil {
+        return nil, nil, ErrInvalidRefreshToken
+    }
+    nextID := primitive.NewObjectID()
+    ok, err := s.RefreshTokens.MarkUsed(ctx, rt.ID, nextID, now)
+    if err != nil {
+        return nil, nil, err
+    }
+    if !ok {
+        // Altă cerere a consumat token-ul între timp
+        return nil, nil, s.revokeReusedFamily(ctx, rt, now)
+    }
+    tokens, err := s.issueTokensWithID(ctx, u, rt, nextID, client)
+    if err != nil {
+        return nil, nil, err
+    }
+    return authResponse(u), tokens, nil
+}
+
+// Logout revocă access token-ul curent (după jti, până la expirarea lui) și familia refresh token-ului prezentat
+func (s *AuthService) Logout(ctx context.Context, accessToken, rawRefresh string) error {
+    if accessToken != "" {
+        if claims, err := s.JWT.ParseToken(accessToken); err == nil && claims.ID != "" && claims.ExpiresAt != nil {
+            if err := s.Revocations.RevokeToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time); err != nil {
+                return err
+            }
+        }
+    }
+    if rawRefresh == "" {
+        return nil
+    }
+    rt, err := s.RefreshTokens.GetByHash(ctx, utils.HashToken(rawRefresh))
+    if err != nil {
+        return nil
+    }
+    _, err = s.RefreshTokens.RevokeFamily(ctx, rt.FamilyID, time.Now())
+    return err
+}
+
+// LogoutEverywhere invalidează toate token-urile emise userului înainte de "before" (implicit acum),
+// inclusiv toate refresh token-urile active.
+func (s *AuthService) LogoutEverywhere(ctx context.Context, userID string, before time.Time) error {
+    oid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+    if before.IsZero() || before.After(now) {
+        before = now
+    }
+    if err := s.Revocations.RevokeUserTokensBefore(ctx, userID, before); err != nil {
+        return err
+    }
+    n, err := s.RefreshTokens.RevokeAllForUser(ctx, oid, now)
+    if err != nil {
+        return err
+    }
+    logger.Infof("logout_everywhere", logger.Fields{"user_id": userID, "before": before, "refresh_revoked": n})
+    return nil
+}
+
+// Me întoarce userul curent împreună cu datele access token-ului folosit.
+func (s *AuthService) Me(ctx context.Context, claims *utils.UserClaims) (*models.MeResponse, error) {
+    oid, err := primitive.ObjectIDFromHex(claims.UserID)
+    if err != nil {
+        return nil, err
+    }
+    u, err := s.Users.GetByID(ctx, oid)
+    if err != nil {
+        return nil, err
+    }
+    info := models.TokenInfo{Roles: claims.Roles, SessionID: claims.SessionID}
+    if claims.Impersonated() {
+        info.ImpersonatedBy = claims.Actor.Email
+    }
+    if claims.IssuedAt != nil {
+        info.IssuedAt = claims.IssuedAt.Time
+    }
+    if claims.ExpiresAt != nil {
+        info.ExpiresAt = claims.ExpiresAt.Time
+    }
+    return &models.MeResponse{AuthResponse: *authResponse(u), Token: info}, nil
+}
+
+// ListSessions întoarce sesiunile active ale userului; currentID marchează sesiunea apelantului.
+// "Last seen" e momentul ultimei rotații a refresh token-ului.
+func (s *AuthService) ListSessions(ctx context.Context, userID, currentID string) ([]models.Session, error) {
+    oid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, err
+    }
+    active, err := s.RefreshTokens.ListActive(ctx, oid, time.Now())
+    if err != nil {
+        return nil, err
+    }
+    sessions := make([]models.Session, 0, len(active))
+    for _, rt := range active {
+        started := rt.SessionStartedAt
+        if started.IsZero() {
+            started = rt.CreatedAt
+        }
+        sessions = append(sessions, models.Session{
+            ID:         rt.FamilyID,
+            Device:     utils.DescribeUserAgent(rt.UserAgent),
+            IP:         rt.IP,
+            UserAgent:  rt.UserAgent,
+            CreatedAt:  started,
+            LastSeenAt: rt.CreatedAt,
+            ExpiresAt:  rt.ExpiresAt,
+            Current:    rt.FamilyID == currentID,
+        })
+    }
+    return sessions, nil
+}
+
+// RevokeSession închide o sesiune: refresh token-urile familiei și access token-urile emise pentru ea.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+    oid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+    n, err := s.RefreshTokens.RevokeFamilyForUser(ctx, oid, sessionID, now)
+    if err != nil {
+        return err
+    }
+    if n == 0 {
+        return ErrSessionNotFound
+    }
+    // Access token-urile sesiunii expiră cel târziu după AccessTTL
+    if err := s.Revocations.RevokeToken(ctx, utils.SessionRevocationKey(sessionID), userID, now.Add(s.JWT.AccessTTL)); err != nil {
+        return err
+    }
+    logger.Infof("session_revoked", logger.Fields{"user_id": userID, "session_id": sessionID})
+    return nil
+}
+
+func (s *AuthService) revokeReusedFamily(ctx context.Context, rt *models.RefreshToken, now time.Time) error {
+    n, err := s.RefreshTokens.RevokeFamily(ctx, rt.FamilyID, now)
+    logger.Warnf("refresh_token_reuse", logger.Fields{"user_id": rt.UserID.Hex(), "family_id": rt.FamilyID, "revoked": n})
+    if err != nil {
+        return err
+    }
+    return ErrRefreshTokenReused
+}
+
+// issueTokens pornește o sesiune nouă (familie nouă de refresh token-uri)
+func (s *AuthService) issueTokens(ctx context.Context, u *models.User, client models.ClientInfo) (*models.TokenPair, error) {
+    return s.issueTokensWithID(ctx, u, nil, primitive.NewObjectID(), client)
+}
+
+// issueTokensWithID emite perechea de token-uri; cu prev != nil continuă sesiunea lui (rotație)
+func (s *AuthService) issueTokensWithID(ctx context.Context, u *models.User, prev *models.RefreshToken, refreshID primitive.ObjectID, client models.ClientInfo) (*models.TokenPair, error) {
+    // Toate fluxurile (login, 2FA, refresh, magic link, OIDC, passkey) trec pe aici
+    if err := accountStatusError(u); err != nil {
+        return nil, err
+    }
+    now := time.Now()
+    familyID := primitive.NewObjectID().Hex()
+    startedAt := now
+    if prev != nil {
+        familyID = prev.FamilyID
+        startedAt = prev.SessionStartedAt
+        if startedAt.IsZero() {
+            // Token-uri emise înainte de evidența sesiunilor
+            startedAt = prev.CreatedAt
+        }
+    }
+    // Rolurile care cer 2FA primesc acces limitat până la înrolare
+    setupRequired, err := s.TwoFactor.SetupRequired(ctx, u)
+    if err != nil {
+        return nil, err
     }
-    if !utils.CheckPassword(u.Password, in.Password) {
-        return nil, "", time.Time{}, errors.New("invalid credentials")
+    access, accessExp, err := s.JWT.GenerateToken(utils.TokenSubject{
+        UserID:                 u.ID.Hex(),
+        Email:                  u.Email,
+        Roles:                  u.EffectiveRoles(),
+        EmailVerified:          u.EmailVerified,
+        TwoFactorSetupRequired: setupRequired,
+        PasswordChangeRequired: u.MustChangePassword,
+        SessionID:              familyID,
+    })
+    if err != nil {
+        return nil, err
     }
-    token, exp, err := s.JWT.GenerateToken(u.ID.Hex(), u.Email)
+    raw, err := utils.GenerateOpaqueToken()
     if err != nil {
-        return nil, "", time.Time{}, err
+        return nil, err
+    }
+    rt := models.RefreshToken{
+        ID:               refreshID,
+        UserID:           u.ID,
+        FamilyID:         familyID,
+        TokenHash:        utils.HashToken(raw),
+        CreatedAt:        now,
+        ExpiresAt:        now.Add(s.JWT.RefreshTTL),
+        SessionStartedAt: startedAt,
+        IP:               client.IP,
+        UserAgent:        client.UserAgent,
+    }
+    if err := s.RefreshTokens.Create(ctx, &rt); err != nil {
+        return nil, err
+    }
+    return &models.TokenPair{
+        AccessToken:      access,
+        AccessExpiresAt:  accessExp,
+        RefreshToken:     raw,
+        RefreshExpiresAt: rt.ExpiresAt,
+    }, nil
+}
+
+func authResponse(u *models.User) *models.AuthResponse {
+    return &models.AuthResponse{ID: u.ID.Hex(), Name: u.Name, Email: u.Email, Phone: u.Phone, Roles: u.EffectiveRoles(), EmailVerified: u.EmailVerified, MustChangePassword: u.MustChangePassword}
+}
+
+// BootstrapAdmin garantează existența primului admin.
+// Dacă există deja un admin nu face nimic; dacă emailul aparține unui user existent îl promovează,
+// altfel creează contul cu parola dată.
+func (s *AuthService) BootstrapAdmin(ctx context.Context, name, email, password string) (bool, error) {
+    email = utils.NormalizeEmail(email)
+    if email == "" {
+        return false, nil
+    }
+    exists, err := s.Users.ExistsWithRole(ctx, models.RoleAdmin)
+    if err != nil {
+        return false, err
+    }
+    if exists {
+        return false, nil
+    }
+    if u, err := s.Users.GetByEmail(ctx, email); err == nil {
+        roles := append([]string{models.RoleAdmin}, u.EffectiveRoles()...)
+        _, err := s.Users.UpdateFields(ctx, u.ID, map[string]interface{}{"roles": roles})
+        return err == nil, err
+    }
+    if password == "" {
+        return false, errors.New("admin password is required to create the bootstrap admin")
+    }
+    if !utils.IsValidEmail(email) {
+        return false, errors.New("invalid email format")
+    }
+    hashed, err := s.Passwords.Hash(password)
+    if err != nil {
+        return false, err
+    }
+    if name == "" {
+        name = "Administrator"
+    }
+    u := models.User{
+        ID:       primitive.NewObjectID(),
+        Name:     name,
+        Email:    email,
+        Password: hashed,
+        Roles:    []string{models.RoleAdmin},
+        // Contul e creat de operator, nu prin signup
+        EmailVerified: true,
+    }
+    if err := s.Users.Create(ctx, &u); err != nil {
+        return false, err
     }
-    resp := &models.AuthResponse{ID: u.ID.Hex(), Name: u.Name, Email: u.Email, Phone: u.Phone}
-    return resp, token, exp, nil
+    return true, nil
 }
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordUsers serves one user for Login and ChangePassword; the password is never changed in these tests.
+type passwordUsers struct {
+    repository.UserRepository
+    user *models.User
+}
+
+func (r *passwordUsers) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+    if id != r.user.ID {
+        return nil, mongo.ErrNoDocuments
+    }
+    return r.user, nil
+}
+
+func (r *passwordUsers) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+    if utils.NormalizeEmail(email) != r.user.Email {
+        return nil, mongo.ErrNoDocuments
+    }
+    return r.user, nil
+}
+
+func newChangePasswordService(t *testing.T) (*AuthService, *models.User) {
+    t.Helper()
+    hasher := utils.NewMultiHasher(utils.BcryptHasher{Cost: bcrypt.MinCost})
+    hashed, err := hasher.Hash("Current123")
+    if err != nil {
+        t.Fatal(err)
+    }
+    u := &models.User{ID: primitive.NewObjectID(), Name: "Ana Pop", Email: "ana@example.com", Password: hashed}
+    s := &AuthService{
+        Users:          &passwordUsers{user: u},
+        Passwords:      hasher,
+        PasswordPolicy: utils.DefaultPasswordPolicy(),
+        Throttle:       NewLoginThrottle(repository.NewMemoryLoginAttemptRepository(), 5, 20, 15*time.Minute, 15*time.Minute),
+    }
+    return s, u
+}
+
+func TestChangePasswordChecksCurrentPasswordFirst(t *testing.T) {
+    s, u := newChangePasswordService(t)
+    // The new password breaks the policy, but the wrong current password is reported
+    in := models.ChangePasswordRequest{CurrentPassword: "Wrong123", NewPassword: "weak", NewPasswordConfirm: "weak"}
+    _, _, err := s.ChangePassword(context.Background(), u.ID.Hex(), in, models.ClientInfo{IP: "10.0.0.1"})
+    var ve *ValidationError
+    if !errors.As(err, &ve) || !strings.Contains(err.Error(), "current password is incorrect") {
+        t.Fatalf("err = %v, want the current password error", err)
+    }
+
+    in.CurrentPassword = "Current123"
+    _, _, err = s.ChangePassword(context.Background(), u.ID.Hex(), in, models.ClientInfo{IP: "10.0.0.1"})
+    if !errors.As(err, &ve) || strings.Contains(err.Error(), "current password") {
+        t.Fatalf("err = %v, want a password policy error", err)
+    }
+}
+
+func TestChangePasswordThrottlesWrongCurrentPassword(t *testing.T) {
+    s, u := newChangePasswordService(t)
+    ctx := context.Background()
+    client := models.ClientInfo{IP: "10.0.0.1"}
+    in := models.ChangePasswordRequest{CurrentPassword: "Wrong123", NewPassword: "NewSecret456", NewPasswordConfirm: "NewSecret456"}
+    for i := 0; i < loginFreeFailures; i++ {
+        if _, _, err := s.ChangePassword(ctx, u.ID.Hex(), in, client); err == nil || strings.Contains(err.Error(), "too many") {
+            t.Fatalf("attempt %d: err = %v, want the current password error", i+1, err)
+        }
+    }
+    // Even the right password has to wait once the account is throttled
+    in.CurrentPassword = "Current123"
+    var rl *RateLimitError
+    if _, _, err := s.ChangePassword(ctx, u.ID.Hex(), in, client); !errors.As(err, &rl) {
+        t.Fatalf("err = %v, want *RateLimitError", err)
+    }
+    // The failures count against the account, as for logins
+    if err := s.Throttle.Check(ctx, u.Email, "10.0.0.2"); !errors.As(err, &rl) {
+        t.Fatalf("login not throttled after wrong current passwords: %v", err)
+    }
+}
+
+func TestExpiredTemporaryPasswordIsRefused(t *testing.T) {
+    s, u := newChangePasswordService(t)
+    expired := time.Now().Add(-time.Minute)
+    u.MustChangePassword, u.TemporaryPasswordExpiresAt = true, &expired
+    ctx := context.Background()
+    client := models.ClientInfo{IP: "10.0.0.1"}
+
+    // The expiry is only revealed after the right password
+    if _, _, err := s.Login(ctx, models.AuthLoginRequest{Email: u.Email, Password: "Wrong123"}, client); err == nil || errors.Is(err, ErrTemporaryPasswordExpired) {
+        t.Fatalf("wrong password: err = %v, want invalid credentials", err)
+    }
+    if _, _, err := s.Login(ctx, models.AuthLoginRequest{Email: u.Email, Password: "Current123"}, client); !errors.Is(err, ErrTemporaryPasswordExpired) {
+        t.Fatalf("login: err = %v, want ErrTemporaryPasswordExpired", err)
+    }
+    // A session obtained before the expiry cannot use the old password either
+    in := models.ChangePasswordRequest{CurrentPassword: "Current123", NewPassword: "NewSecret456", NewPasswordConfirm: "NewSecret456"}
+    if _, _, err := s.ChangePassword(ctx, u.ID.Hex(), in, client); !errors.Is(err, ErrTemporaryPasswordExpired) {
+        t.Fatalf("change password: err = %v, want ErrTemporaryPasswordExpired", err)
+    }
+}
+
+// memRefreshTokens keeps refresh tokens in a map, enough for rotation and family revocation.
+type memRefreshTokens struct {
+    repository.RefreshTokenRepository
+    tokens map[primitive.ObjectID]*models.RefreshToken
+}
+
+func (r *memRefreshTokens) Create(ctx context.Context, t *models.RefreshToken) error {
+    if t.ID.IsZero() {
+        t.ID = primitive.NewObjectID()
+    }
+    cp := *t
+    r.tokens[t.ID] = &cp
+    return nil
+}
+
+func (r *memRefreshTokens) GetByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+    for _, t := range r.tokens {
+        if t.TokenHash == hash {
+            cp := *t
+            return &cp, nil
+        }
+    }
+    return nil, mongo.ErrNoDocuments
+}
+
+func (r *memRefreshTokens) MarkUsed(ctx context.Context, id, replacedBy primitive.ObjectID, at time.Time) (bool, error) {
+    t := r.tokens[id]
+    if t == nil || t.UsedAt != nil || t.RevokedAt != nil {
+        return false, nil
+    }
+    t.UsedAt, t.ReplacedBy = &at, &replacedBy
+    return true, nil
+}
+
+func (r *memRefreshTokens) RevokeFamily(ctx context.Context, familyID string, at time.Time) (int64, error) {
+    var n int64
+    for _, t := range r.tokens {
+        if t.FamilyID == familyID && t.RevokedAt == nil {
+            t.RevokedAt = &at
+            n++
+        }
+    }
+    return n, nil
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+    s, u := newChangePasswordService(t)
+    s.RefreshTokens = &memRefreshTokens{tokens: map[primitive.ObjectID]*models.RefreshToken{}}
+    s.JWT = &utils.JWTManager{Secret: []byte("test-secret-with-enough-entropy!"), AccessTTL: 15 * time.Minute, RefreshTTL: time.Hour}
+    ctx := context.Background()
+    client := models.ClientInfo{IP: "10.0.0.1"}
+
+    first, err := s.issueTokens(ctx, u, client)
+    if err != nil {
+        t.Fatal(err)
+    }
+    _, second, err := s.Refresh(ctx, first.RefreshToken, client)
+    if err != nil {
+        t.Fatalf("refresh: %v", err)
+    }
+    // Presenting the rotated token again means it leaked
+    if _, _, err := s.Refresh(ctx, first.RefreshToken, client); !errors.Is(err, ErrRefreshTokenReused) {
+        t.Fatalf("reuse: err = %v, want ErrRefreshTokenReused", err)
+    }
+    // The legitimate holder of the newest token is logged out too
+    if _, _, err := s.Refresh(ctx, second.RefreshToken, client); !errors.Is(err, ErrInvalidRefreshToken) {
+        t.Fatalf("after reuse: err = %v, want ErrInvalidRefreshToken", err)
+    }
+}
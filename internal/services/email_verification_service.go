@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/mailer"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+)
+
+var (
+    ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+    ErrEmailAlreadyVerified     = errors.New("email already verified")
+    ErrEmailNotVerified         = errors.New("email not verified")
+)
+
+// Limite pentru retrimiterea emailului de verificare
+const (
+    verificationResendCooldown = time.Minute
+    verificationMaxPerDay      = 5
+)
+
+// EmailVerificationService trimite și validează linkurile de confirmare a adresei de email.
+type EmailVerificationService struct {
+    Users   repository.UserRepository
+    Tokens  repository.OneTimeTokenRepository
+    Mailer  mailer.Mailer
+    BaseURL string
+    TTL     time.Duration
+    // Policy decide ce pot face userii neverificați (models.EmailPolicy*)
+    Policy string
+}
+
+func NewEmailVerificationService(users repository.UserRepository, tokens repository.OneTimeTokenRepository, m mailer.Mailer, baseURL string, ttl time.Duration, policy string) *EmailVerificationService {
+    return &EmailVerificationService{Users: users, Tokens: tokens, Mailer: m, BaseURL: strings.TrimRight(baseURL, "/"), TTL: ttl, Policy: policy}
+}
+
+// Send emite un token nou legat de adresa curentă a userului și trimite linkul; token-urile vechi sunt invalidate.
+func (s *EmailVerificationService) Send(ctx context.Context, u *models.User) error {
+    now := time.Now()
+    if _, err := s.Tokens.InvalidateForUser(ctx, u.ID, models.TokenPurposeEmailVerification, now); err != nil {
+        return err
+    }
+    raw, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return err
+    }
+    t := models.OneTimeToken{
+        UserID:    u.ID,
+        Purpose:   models.TokenPurposeEmailVerification,
+        TokenHash: utils.HashToken(raw),
+        Email:     u.Email,
+        CreatedAt: now,
+        ExpiresAt: now.Add(s.TTL),
+    }
+    if err := s.Tokens.Create(ctx, &t); err != nil {
+        return err
+    }
+    link := fmt.Sprintf("%s/verify-email?token=%s", s.BaseURL, url.QueryEscape(raw))
+    msg := mailer.Message{
+        To:      u.Email,
+        Subject: "Confirm your email address",
+        Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %d hours.\n\n%s\n",
+            u.Name, int(s.TTL.Hours()), link),
+    }
+    if err := s.Mailer.Send(ctx, msg); err != nil {
+        return err
+    }
+    logger.Infof("email_verification_sent", logger.Fields{"user_id": u.ID.Hex()})
+    return nil
+}
+
+// Resend retrimite linkul, cu cooldown între cereri și o limită zilnică.
+func (s *EmailVerificationService) Resend(ctx context.Context, u *models.User) error {
+    if u.EmailVerified {
+        return ErrEmailAlreadyVerified
+    }
+    if err := s.throttle(ctx, u); err != nil {
+        return err
+    }
+    return s.Send(ctx, u)
+}
+
+func (s *EmailVerificationService) throttle(ctx context.Context, u *models.User) error {
+    now := time.Now()
+    if last, err := s.Tokens.LatestForUser(ctx, u.ID, models.TokenPurposeEmailVerification); err == nil {
+        if wait := last.CreatedAt.Add(verificationResendCooldown).Sub(now); wait > 0 {
+            return &RateLimitError{Msg: "verification email sent recently; please wait", RetryAfter: wait}
+        }
+    }
+    n, err := s.Tokens.CountCreatedSince(ctx, u.ID, models.TokenPurposeEmailVerification, now.Add(-24*time.Hour))
+    if err != nil {
+        return err
+    }
+    if n >= verificationMaxPerDay {
+        return &RateLimitError{Msg: "too many verification emails requested; try again later", RetryAfter: time.Hour}
+    }
+    return nil
+}
+
+// Verify consumă token-ul și marchează emailul ca verificat, dacă adresa nu s-a schimbat între timp.
+func (s *EmailVerificationService) Verify(ctx context.Context, raw string) (*models.User, error) {
+    if raw == "" {
+        return nil, ErrInvalidVerificationToken
+    }
+    now := time.Now()
+    t, err := s.Tokens.Consume(ctx, models.TokenPurposeEmailVerification, utils.HashToken(raw), now)
+    if err != nil {
+        return nil, ErrInvalidVerificationToken
+    }
+    u, err := s.Users.GetByID(ctx, t.UserID)
+    if err != nil || !strings.EqualFold(u.Email, t.Email) {
+        return nil, ErrInvalidVerificationToken
+    }
+    if _, err := s.Users.UpdateFields(ctx, u.ID, map[string]interface{}{"emailVerified": true, "emailVerifiedAt": now}); err != nil {
+        return nil, err
+    }
+    u.EmailVerified = true
+    u.EmailVerifiedAt = &now
+    logger.Infof("email_verified", logger.Fields{"user_id": u.ID.Hex()})
+    return u, nil
+}
+
+// SendAsync trimite emailul de verificare în fundal (după signup / login blocat), logând eventualele erori.
+func (s *EmailVerificationService) SendAsync(u models.User, throttled bool) {
+    go func() {
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        defer cancel()
+        var err error
+        if throttled {
+            err = s.Resend(ctx, &u)
+        } else {
+            err = s.Send(ctx, &u)
+        }
+        if err != nil {
+            logger.Warnf("email_verification_send_failed", logger.Fields{"user_id": u.ID.Hex(), "error": err.Error()})
+        }
+    }()
+}
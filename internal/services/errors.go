@@ -0,0 +1,20 @@
+package services
+
+import "time"
+
+// ValidationError marks invalid client input; handlers report it as 400.
+type ValidationError struct {
+    Msg string
+}
+
+func (e *ValidationError) Error() string { return e.Msg }
+
+func invalid(msg string) error { return &ValidationError{Msg: msg} }
+
+// RateLimitError is returned when an action is throttled; handlers answer 429 with Retry-After.
+type RateLimitError struct {
+    Msg        string
+    RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string { return e.Msg }
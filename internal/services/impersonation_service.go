@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+    ErrImpersonationTargetNotFound = errors.New("user not found")
+    ErrImpersonationNotAllowed     = errors.New("this user cannot be impersonated")
+    ErrNotImpersonating            = errors.New("the current token is not an impersonation token")
+)
+
+const defaultImpersonationTTL = 30 * time.Minute
+
+// ImpersonationService emite token-uri cu care un admin vede API-ul ca un anumit user.
+// Token-ul poartă și adminul (claim-ul "act"), nu are sesiune (nu se poate reîmprospăta),
+// iar pornirea, oprirea și fiecare request făcut cu el ajung în jurnalul de audit.
+type ImpersonationService struct {
+    Users       repository.UserRepository
+    Revocations repository.TokenRevocationRepository
+    Audit       repository.ImpersonationAuditRepository
+    JWT         *utils.JWTManager
+    // Durata maximă a unui token de impersonare
+    MaxTTL time.Duration
+}
+
+func NewImpersonationService(users repository.UserRepository, revocations repository.TokenRevocationRepository, audit repository.ImpersonationAuditRepository, jwt *utils.JWTManager, maxTTL time.Duration) *ImpersonationService {
+    if maxTTL <= 0 {
+        maxTTL = defaultImpersonationTTL
+    }
+    return &ImpersonationService{Users: users, Revocations: revocations, Audit: audit, JWT: jwt, MaxTTL: maxTTL}
+}
+
+// Start emite un token de impersonare pentru userul targetID. Adminii nu pot fi impersonați
+// (token-ul ar purta drepturi de admin), iar un token de impersonare nu poate porni altul.
+func (s *ImpersonationService) Start(ctx context.Context, actor *utils.UserClaims, targetID string, in models.ImpersonationRequest, client models.ClientInfo) (*models.ImpersonationToken, error) {
+    if actor.Impersonated() {
+        return nil, ErrImpersonationNotAllowed
+    }
+    reason := strings.TrimSpace(in.Reason)
+    if reason == "" || len(reason) > 500 {
+        return nil, invalid("reason is required (max 500 characters)")
+    }
+    ttl := s.MaxTTL
+    if in.Minutes < 0 {
+        return nil, invalid("minutes must be positive")
+    }
+    if in.Minutes > 0 {
+        if d := time.Duration(in.Minutes) * time.Minute; d < ttl {
+            ttl = d
+        }
+    }
+    oid, err := primitive.ObjectIDFromHex(targetID)
+    if err != nil {
+        return nil, ErrImpersonationTargetNotFound
+    }
+    u, err := s.Users.GetByID(ctx, oid)
+    if err != nil {
+        return nil, ErrImpersonationTargetNotFound
+    }
+    if u.ID.Hex() == actor.UserID || u.HasAnyRole(models.RoleAdmin) {
+        return nil, ErrImpersonationNotAllowed
+    }
+    // Un cont suspendat sau dezactivat nu poate fi folosit nici prin impersonare
+    if err := accountStatusError(u); err != nil {
+        return nil, err
+    }
+    token, exp, err := s.JWT.GenerateImpersonationToken(utils.TokenSubject{
+        UserID:        u.ID.Hex(),
+        Email:         u.Email,
+        Roles:         u.EffectiveRoles(),
+        EmailVerified: u.EmailVerified,
+    }, utils.TokenActor{UserID: actor.UserID, Email: actor.Email}, ttl)
+    if err != nil {
+        return nil, err
+    }
+    claims, err := s.JWT.ParseToken(token)
+    if err != nil {
+        return nil, err
+    }
+    entry := models.ImpersonationAuditEntry{
+        Event:     models.ImpersonationEventStart,
+        TokenID:   claims.ID,
+        Reason:    reason,
+        IP:        client.IP,
+        UserAgent: client.UserAgent,
+        At:        time.Now(),
+    }
+    // Fără intrarea de audit token-ul nu e emis
+    if err := s.Audit.Record(ctx, auditEntry(entry, claims)); err != nil {
+        return nil, err
+    }
+    logger.Infof("impersonation_started", logger.Fields{"actor_id": actor.UserID, "user_id": u.ID.Hex(), "token_id": claims.ID, "expires_at": exp})
+    return &models.ImpersonationToken{Token: token, ExpiresAt: exp, User: authResponse(u)}, nil
+}
+
+// Stop revocă token-ul de impersonare cu care e făcut request-ul.
+func (s *ImpersonationService) Stop(ctx context.Context, claims *utils.UserClaims, client models.ClientInfo) error {
+    if !claims.Impersonated() {
+        return ErrNotImpersonating
+    }
+    exp := time.Now().Add(s.MaxTTL)
+    if claims.ExpiresAt != nil {
+        exp = claims.ExpiresAt.Time
+    }
+    if err := s.Revocations.RevokeToken(ctx, claims.ID, claims.UserID, exp); err != nil {
+        return err
+    }
+    entry := models.ImpersonationAuditEntry{
+        Event:     models.ImpersonationEventStop,
+        TokenID:   claims.ID,
+        IP:        client.IP,
+        UserAgent: client.UserAgent,
+        At:        time.Now(),
+    }
+    if err := s.Audit.Record(ctx, auditEntry(entry, claims)); err != nil {
+        logger.Errorf("impersonation_audit_failed", logger.Fields{"token_id": claims.ID, "error": err.Error()})
+    }
+    logger.Infof("impersonation_stopped", logger.Fields{"actor_id": claims.Actor.UserID, "user_id": claims.UserID, "token_id": claims.ID})
+    return nil
+}
+
+// ListAudit întoarce jurnalul de impersonare (filtre și paginare ca la listele de cărți).
+func (s *ImpersonationService) ListAudit(ctx context.Context, q utils.ListQuery) ([]models.ImpersonationAuditEntry, int64, error) {
+    return s.Audit.ListWithQuery(ctx, q)
+}
+
+// auditEntry completează adminul, userul și token-ul din claims.
+func auditEntry(e models.ImpersonationAuditEntry, claims *utils.UserClaims) *models.ImpersonationAuditEntry {
+    e.ActorID = claims.Actor.UserID
+    e.ActorEmail = claims.Actor.Email
+    e.SubjectID = claims.UserID
+    e.SubjectEmail = claims.Email
+    return &e
+}
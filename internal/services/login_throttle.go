@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+)
+
+// Întârzieri progresive între încercări, după primele eșecuri „gratuite”
+const (
+    loginFreeFailures = 3
+    loginBaseDelay    = time.Second
+    loginMaxDelay     = 30 * time.Second
+)
+
+// LoginThrottle limitează ghicirea parolelor: numără eșecurile per cont și per IP,
+// impune o pauză crescătoare între încercări și blochează temporar cheia la prag.
+// Un *LoginThrottle nil dezactivează protecția.
+type LoginThrottle struct {
+    Attempts repository.LoginAttemptRepository
+    // Eșecuri permise în Window înainte de blocare, per cont și per IP
+    MaxFailures   int
+    IPMaxFailures int
+    Window        time.Duration
+    Lockout       time.Duration
+}
+
+func NewLoginThrottle(attempts repository.LoginAttemptRepository, maxFailures, ipMaxFailures int, window, lockout time.Duration) *LoginThrottle {
+    return &LoginThrottle{Attempts: attempts, MaxFailures: maxFailures, IPMaxFailures: ipMaxFailures, Window: window, Lockout: lockout}
+}
+
+// Check întoarce *RateLimitError dacă contul sau IP-ul e blocat ori trebuie să mai aștepte.
+// Se apelează înainte de verificarea parolei, ca încercările blocate să nu coste un bcrypt.
+// Erorile de stocare sunt doar logate: o problemă cu baza nu trebuie să blocheze toate login-urile.
+func (t *LoginThrottle) Check(ctx context.Context, email, ip string) error {
+    if t == nil {
+        return nil
+    }
+    return t.check(ctx, t.keys("", email, ip))
+}
+
+func (t *LoginThrottle) check(ctx context.Context, keys []string) error {
+    now := time.Now()
+    for _, key := range keys {
+        a, err := t.Attempts.Get(ctx, key)
+        if err != nil {
+            logger.Errorf("login_throttle_check_failed", logger.Fields{"key": logKey(key), "error": err.Error()})
+            continue
+        }
+        if a == nil {
+            continue
+        }
+        if now.Before(a.LockedUntil) {
+            return &RateLimitError{Msg: "too many failed login attempts, try again later", RetryAfter: a.LockedUntil.Sub(now)}
+        }
+        if strings.HasPrefix(key, "account:") {
+            if next := a.LastFailureAt.Add(loginDelay(a.Failures)); now.Before(next) {
+                return &RateLimitError{Msg: "too many failed login attempts, slow down", RetryAfter: next.Sub(now)}
+            }
+        }
+    }
+    return nil
+}
+
+// Failure înregistrează o încercare eșuată și blochează cheile care au atins pragul.
+func (t *LoginThrottle) Failure(ctx context.Context, email, ip string) {
+    if t == nil {
+        return
+    }
+    t.record(ctx, t.keys("", email, ip))
+}
+
+// Limit numără o cerere de tipul scope (ex. resetare de parolă) pentru email și IP și o refuză cu
+// *RateLimitError peste aceleași praguri ca la login. Contoarele sunt separate de cele ale login-ului,
+// deci cererile de linkuri nu încetinesc autentificarea; se numără și emailurile inexistente.
+func (t *LoginThrottle) Limit(ctx context.Context, scope, email, ip string) error {
+    if t == nil {
+        return nil
+    }
+    keys := t.keys(scope, email, ip)
+    if err := t.check(ctx, keys); err != nil {
+        var rl *RateLimitError
+        if errors.As(err, &rl) {
+            rl.Msg = "too many requests, try again later"
+        }
+        return err
+    }
+    t.record(ctx, keys)
+    return nil
+}
+
+func (t *LoginThrottle) record(ctx context.Context, keys []string) {
+    for _, key := range keys {
+        a, err := t.Attempts.RecordFailure(ctx, key, t.Window)
+        if err != nil {
+            logger.Errorf("login_throttle_record_failed", logger.Fields{"key": logKey(key), "error": err.Error()})
+            continue
+        }
+        limit := t.MaxFailures
+        if strings.HasPrefix(key, "ip:") {
+            limit = t.IPMaxFailures
+        }
+        if limit <= 0 || a.Failures < limit {
+            continue
+        }
+        until := time.Now().Add(t.Lockout)
+        if err := t.Attempts.Lock(ctx, key, until); err != nil {
+            logger.Errorf("login_throttle_lock_failed", logger.Fields{"key": logKey(key), "error": err.Error()})
+            continue
+        }
+        logger.Warnf("login_lockout", logger.Fields{"key": logKey(key), "failures": a.Failures, "locked_until": until})
+    }
+}
+
+// Success șterge contorul contului după un login complet (inclusiv pasul 2FA).
+// Contorul IP-ului rămâne, altfel un cont valid ar putea „spăla” încercările pe alte conturi.
+func (t *LoginThrottle) Success(ctx context.Context, email string) {
+    if t == nil {
+        return
+    }
+    key := accountKey(email)
+    if err := t.Attempts.Reset(ctx, key); err != nil {
+        logger.Errorf("login_throttle_reset_failed", logger.Fields{"key": logKey(key), "error": err.Error()})
+    }
+}
+
+// keys întoarce cheile contului și IP-ului; scope gol înseamnă login
+func (t *LoginThrottle) keys(scope, email, ip string) []string {
+    keys := []string{accountKey(email)}
+    if ip != "" {
+        keys = append(keys, "ip:"+ip)
+    }
+    if scope != "" {
+        // "account:<scope>:<email>", "ip:<scope>:<ip>"; prefixul tipului alege în continuare pragurile
+        for i, k := range keys {
+            keys[i] = strings.Replace(k, ":", ":"+scope+":", 1)
+        }
+    }
+    return keys
+}
+
+// Cheia contului e emailul normalizat, deci și adresele inexistente sunt limitate
+func accountKey(email string) string {
+    return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// throttleScopes sunt scope-urile folosite cu Limit, ca logKey să le poată separa de email
+var throttleScopes = []string{passwordResetThrottleScope, magicLinkThrottleScope}
+
+// logKey înlocuiește emailul din cheia contului cu hash-ul lui: adresa poate să nu aparțină
+// niciunui cont, deci nu ajunge în loguri
+func logKey(key string) string {
+    email, ok := strings.CutPrefix(key, "account:")
+    if !ok {
+        return key
+    }
+    prefix := "account:"
+    for _, scope := range throttleScopes {
+        if rest, ok := strings.CutPrefix(email, scope+":"); ok {
+            prefix, email = prefix+scope+":", rest
+            break
+        }
+    }
+    return prefix + utils.EmailLogHash(email)
+}
+
+// loginDelay: 0 pentru primele eșecuri, apoi 1s, 2s, 4s... plafonat la loginMaxDelay
+func loginDelay(failures int) time.Duration {
+    if failures < loginFreeFailures {
+        return 0
+    }
+    d := loginBaseDelay
+    for i := loginFreeFailures; i < failures && d < loginMaxDelay; i++ {
+        d *= 2
+    }
+    if d > loginMaxDelay {
+        d = loginMaxDelay
+    }
+    return d
+}
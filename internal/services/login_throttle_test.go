@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+)
+
+func newTestThrottle() *LoginThrottle {
+    return NewLoginThrottle(repository.NewMemoryLoginAttemptRepository(), 5, 20, 15*time.Minute, 15*time.Minute)
+}
+
+func TestLoginThrottleLimit(t *testing.T) {
+    th := newTestThrottle()
+    ctx := context.Background()
+    // The first requests are free, the next one has to wait
+    for i := 0; i < loginFreeFailures; i++ {
+        if err := th.Limit(ctx, "password_reset", "Ana@Example.com", "10.0.0.1"); err != nil {
+            t.Fatalf("request %d: %v", i+1, err)
+        }
+    }
+    err := th.Limit(ctx, "password_reset", "ana@example.com", "10.0.0.2")
+    var rl *RateLimitError
+    if !errors.As(err, &rl) || rl.RetryAfter <= 0 {
+        t.Fatalf("err = %v, want *RateLimitError", err)
+    }
+
+    // Logins and other scopes keep their own counters
+    if err := th.Check(ctx, "ana@example.com", "10.0.0.1"); err != nil {
+        t.Fatalf("login throttled by reset requests: %v", err)
+    }
+    if err := th.Limit(ctx, "magic_link", "ana@example.com", "10.0.0.1"); err != nil {
+        t.Fatalf("magic link throttled by reset requests: %v", err)
+    }
+}
+
+func TestLoginThrottleLimitLocksIP(t *testing.T) {
+    th := NewLoginThrottle(repository.NewMemoryLoginAttemptRepository(), 0, 3, 15*time.Minute, 15*time.Minute)
+    ctx := context.Background()
+    // Different addresses from one IP: only the IP counter can stop them
+    for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+        if err := th.Limit(ctx, "password_reset", email, "10.0.0.1"); err != nil {
+            t.Fatalf("%s: %v", email, err)
+        }
+    }
+    var rl *RateLimitError
+    if err := th.Limit(ctx, "password_reset", "d@example.com", "10.0.0.1"); !errors.As(err, &rl) {
+        t.Fatalf("err = %v, want *RateLimitError", err)
+    }
+    if err := th.Limit(ctx, "password_reset", "d@example.com", "10.0.0.2"); err != nil {
+        t.Fatalf("other IP: %v", err)
+    }
+}
+
+func TestLogKeyHidesEmail(t *testing.T) {
+    hash := utils.EmailLogHash("ana@example.com")
+    tests := []struct {
+        key  string
+        want string
+    }{
+        {"account:ana@example.com", "account:" + hash},
+        {"account:password_reset:ana@example.com", "account:password_reset:" + hash},
+        {"account:magic_link:ana@example.com", "account:magic_link:" + hash},
+        {"ip:10.0.0.1", "ip:10.0.0.1"},
+        {"ip:magic_link:10.0.0.1", "ip:magic_link:10.0.0.1"},
+    }
+    for _, tt := range tests {
+        if got := logKey(tt.key); got != tt.want {
+            t.Errorf("logKey(%q) = %q, want %q", tt.key, got, tt.want)
+        }
+    }
+}
+
+func TestNilLoginThrottleAllowsEverything(t *testing.T) {
+    var th *LoginThrottle
+    if err := th.Limit(context.Background(), "password_reset", "ana@example.com", "10.0.0.1"); err != nil {
+        t.Fatal(err)
+    }
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/mailer"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+)
+
+var ErrInvalidMagicLink = errors.New("invalid or expired login link")
+
+// Limite pentru linkurile de login trimise aceleiași adrese
+const (
+    magicLinkCooldown   = time.Minute
+    magicLinkMaxPerHour = 5
+    // Cererile se limitează și cu LoginThrottle, pe contoare separate de cele ale login-ului
+    magicLinkThrottleScope = "magic_link"
+)
+
+// MagicLinkService implementează login-ul fără parolă: un link de unică folosință trimis pe email.
+//
+// Linkul poartă un token opac, salvat doar ca hash în one_time_tokens, și nu un token semnat (JWT/HMAC).
+// Alegerea e deliberată: un token semnat e valid până expiră, oricâte ori e folosit, pe când cel salvat
+// e consumat atomic la prima folosire, e invalidat când se cere un link nou și poate fi revocat ștergând
+// documentul. Verificarea oricum citește baza (userul, emailul curent), deci semnătura n-ar economisi nimic,
+// iar scurgerea cheii de semnare nu poate produce linkuri valide.
+type MagicLinkService struct {
+    Users   repository.UserRepository
+    Tokens  repository.OneTimeTokenRepository
+    Mailer  mailer.Mailer
+    Auth    *AuthService
+    BaseURL string
+    TTL     time.Duration
+}
+
+func NewMagicLinkService(users repository.UserRepository, tokens repository.OneTimeTokenRepository, m mailer.Mailer, auth *AuthService, baseURL string, ttl time.Duration) *MagicLinkService {
+    return &MagicLinkService{Users: users, Tokens: tokens, Mailer: m, Auth: auth, BaseURL: strings.TrimRight(baseURL, "/"), TTL: ttl}
+}
+
+// CheckRequest numără o cerere de link pentru email și IP; peste pragurile login-ului întoarce
+// *RateLimitError. Se apelează sincron, înainte de RequestLink, ca handler-ul să poată răspunde 429.
+func (s *MagicLinkService) CheckRequest(ctx context.Context, email, ip string) error {
+    return s.Auth.Throttle.Limit(ctx, magicLinkThrottleScope, email, ip)
+}
+
+// RequestLink trimite un link de login dacă emailul aparține unui cont. Emailurile necunoscute nu produc
+// eroare, iar depășirea limitelor întoarce *RateLimitError doar pentru log (handler-ul răspunde la fel).
+func (s *MagicLinkService) RequestLink(ctx context.Context, email string) error {
+    if !utils.IsValidEmail(email) {
+        return invalid("invalid email format")
+    }
+    u, err := s.Users.GetByEmail(ctx, email)
+    if err != nil {
+        logger.Infof("magic_link_unknown_email", logger.Fields{"email_hash": utils.EmailLogHash(email)})
+        return nil
+    }
+    if err := s.throttle(ctx, u); err != nil {
+        return err
+    }
+    now := time.Now()
+    // Un singur link valid odată
+    if _, err := s.Tokens.InvalidateForUser(ctx, u.ID, models.TokenPurposeMagicLink, now); err != nil {
+        return err
+    }
+    raw, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return err
+    }
+    t := models.OneTimeToken{
+        UserID:    u.ID,
+        Purpose:   models.TokenPurposeMagicLink,
+        TokenHash: utils.HashToken(raw),
+        Email:     u.Email,
+        CreatedAt: now,
+        ExpiresAt: now.Add(s.TTL),
+    }
+    if err := s.Tokens.Create(ctx, &t); err != nil {
+        return err
+    }
+    link := fmt.Sprintf("%s/magic-link?token=%s", s.BaseURL, url.QueryEscape(raw))
+    msg := mailer.Message{
+        To:      u.Email,
+        Subject: "Your login link",
+        Body: fmt.Sprintf("Hi %s,\n\nUse the link below to log in. It expires in %d minutes and can be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.\n",
+            u.Name, int(s.TTL.Minutes()), link),
+    }
+    if err := s.Mailer.Send(ctx, msg); err != nil {
+        return err
+    }
+    logger.Infof("magic_link_sent", logger.Fields{"user_id": u.ID.Hex()})
+    return nil
+}
+
+// Consume schimbă linkul pe o sesiune normală. Linkul e valabil doar pentru adresa căreia i-a fost
+// trimis; deschiderea lui confirmă și adresa. Cu 2FA activ întoarce *TwoFactorRequiredError, ca Login.
+func (s *MagicLinkService) Consume(ctx context.Context, raw string, client models.ClientInfo) (*models.AuthResponse, *models.TokenPair, error) {
+    if raw == "" {
+        return nil, nil, ErrInvalidMagicLink
+    }
+    now := time.Now()
+    t, err := s.Tokens.Consume(ctx, models.TokenPurposeMagicLink, utils.HashToken(raw), now)
+    if err != nil {
+        return nil, nil, ErrInvalidMagicLink
+    }
+    u, err := s.Users.GetByID(ctx, t.UserID)
+    if err != nil || !strings.EqualFold(u.Email, t.Email) {
+        return nil, nil, ErrInvalidMagicLink
+    }
+    if !u.EmailVerified {
+        if _, err := s.Users.UpdateFields(ctx, u.ID, map[string]interface{}{"emailVerified": true, "emailVerifiedAt": now}); err != nil {
+            return nil, nil, err
+        }
+        u.EmailVerified = true
+        u.EmailVerifiedAt = &now
+    }
+    if s.Auth.TwoFactor != nil && u.TOTPEnabled {
+        return nil, nil, s.Auth.TwoFactor.Challenge(u)
+    }
+    tokens, err := s.Auth.issueTokens(ctx, u, client)
+    if err != nil {
+        return nil, nil, err
+    }
+    logger.Infof("magic_link_login", logger.Fields{"user_id": u.ID.Hex()})
+    return authResponse(u), tokens, nil
+}
+
+func (s *MagicLinkService) throttle(ctx context.Context, u *models.User) error {
+    now := time.Now()
+    if last, err := s.Tokens.LatestForUser(ctx, u.ID, models.TokenPurposeMagicLink); err == nil {
+        if wait := last.CreatedAt.Add(magicLinkCooldown).Sub(now); wait > 0 {
+            return &RateLimitError{Msg: "login link sent recently; please wait", RetryAfter: wait}
+        }
+    }
+    n, err := s.Tokens.CountCreatedSince(ctx, u.ID, models.TokenPurposeMagicLink, now.Add(-time.Hour))
+    if err != nil {
+        return err
+    }
+    if n >= magicLinkMaxPerHour {
+        return &RateLimitError{Msg: "too many login links requested; try again later", RetryAfter: time.Hour}
+    }
+    return nil
+}
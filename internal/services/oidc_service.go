@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/models"
+	"API-GO/internal/oidc"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+    ErrUnknownProvider      = errors.New("unknown identity provider")
+    ErrInvalidOIDCState     = errors.New("invalid or expired login state")
+    ErrOIDCLoginFailed      = errors.New("identity provider login failed")
+    ErrOIDCEmailNotVerified = errors.New("identity provider did not return a verified email")
+    ErrNoLinkedAccount      = errors.New("no account matches this identity")
+    ErrIdentityConflict     = errors.New("account is already linked to another identity from this provider")
+)
+
+const defaultOIDCStateTTL = 10 * time.Minute
+
+// OIDCService autentifică prin furnizori OpenID Connect externi (authorization code + PKCE)
+// și emite apoi sesiunea noastră obișnuită prin AuthService.
+type OIDCService struct {
+    Providers map[string]*oidc.Provider
+    States    repository.OIDCStateRepository
+    Users     repository.UserRepository
+    Auth      *AuthService
+    StateTTL  time.Duration
+}
+
+func NewOIDCService(states repository.OIDCStateRepository, users repository.UserRepository, auth *AuthService, providers ...*oidc.Provider) *OIDCService {
+    s := &OIDCService{Providers: map[string]*oidc.Provider{}, States: states, Users: users, Auth: auth, StateTTL: defaultOIDCStateTTL}
+    for _, p := range providers {
+        s.Providers[p.Config.Name] = p
+    }
+    return s
+}
+
+// BeginLogin salvează state/nonce/verifier și întoarce URL-ul de autorizare plus state-ul în clar
+// (handlerul îl leagă de browser printr-un cookie).
+func (s *OIDCService) BeginLogin(ctx context.Context, provider string) (string, string, error) {
+    p, ok := s.Providers[provider]
+    if !ok {
+        return "", "", ErrUnknownProvider
+    }
+    state, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return "", "", err
+    }
+    nonce, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return "", "", err
+    }
+    verifier, err := oidc.NewCodeVerifier()
+    if err != nil {
+        return "", "", err
+    }
+    authURL, err := p.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+    if err != nil {
+        return "", "", err
+    }
+    now := time.Now()
+    st := models.OIDCState{
+        StateHash:    utils.HashToken(state),
+        Provider:     provider,
+        Nonce:        nonce,
+        CodeVerifier: verifier,
+        CreatedAt:    now,
+        ExpiresAt:    now.Add(s.StateTTL),
+    }
+    if err := s.States.Create(ctx, &st); err != nil {
+        return "", "", err
+    }
+    return authURL, state, nil
+}
+
+// CompleteLogin validează callback-ul, schimbă codul pe token-uri, verifică ID token-ul
+// și găsește (sau leagă) userul local. Cu 2FA activ întoarce *TwoFactorRequiredError, ca Login.
+func (s *OIDCService) CompleteLogin(ctx context.Context, provider, state, code string, client models.ClientInfo) (*models.AuthResponse, *models.TokenPair, error) {
+    p, ok := s.Providers[provider]
+    if !ok {
+        return nil, nil, ErrUnknownProvider
+    }
+    if state == "" || code == "" {
+        return nil, nil, ErrInvalidOIDCState
+    }
+    st, err := s.States.Consume(ctx, utils.HashToken(state), time.Now())
+    if err != nil {
+        return nil, nil, err
+    }
+    if st == nil || st.Provider != provider {
+        return nil, nil, ErrInvalidOIDCState
+    }
+    tok, err := p.Exchange(ctx, code, st.CodeVerifier)
+    if err != nil {
+        logger.Warnf("oidc_exchange_failed", logger.Fields{"provider": provider, "error": err.Error()})
+        return nil, nil, ErrOIDCLoginFailed
+    }
+    claims, err := p.VerifyIDToken(ctx, tok.IDToken, st.Nonce)
+    if err != nil {
+        logger.Warnf("oidc_id_token_rejected", logger.Fields{"provider": provider, "error": err.Error()})
+        return nil, nil, ErrOIDCLoginFailed
+    }
+    u, err := s.resolveUser(ctx, p, claims)
+    if err != nil {
+        return nil, nil, err
+    }
+    if s.Auth.TwoFactor != nil && u.TOTPEnabled {
+        return nil, nil, s.Auth.TwoFactor.Challenge(u)
+    }
+    tokens, err := s.Auth.issueTokens(ctx, u, client)
+    if err != nil {
+        return nil, nil, err
+    }
+    logger.Infof("oidc_login_success", logger.Fields{"provider": provider, "user_id": u.ID.Hex()})
+    return authResponse(u), tokens, nil
+}
+
+// resolveUser caută întâi identitatea deja legată; altfel leagă contul local cu același email,
+// doar dacă furnizorul confirmă că emailul e verificat.
+func (s *OIDCService) resolveUser(ctx context.Context, p *oidc.Provider, claims *oidc.IDTokenClaims) (*models.User, error) {
+    provider := p.Config.Name
+    if u, err := s.Users.GetByIdentity(ctx, provider, claims.Subject); err == nil {
+        return u, nil
+    }
+    if claims.Email == "" || !bool(claims.EmailVerified) {
+        return nil, ErrOIDCEmailNotVerified
+    }
+    now := time.Now()
+    identity := models.ExternalIdentity{Provider: provider, Subject: claims.Subject, Email: claims.Email, LinkedAt: now}
+    if u, err := s.Users.GetByEmail(ctx, claims.Email); err == nil {
+        linked, err := s.Users.AddIdentity(ctx, u.ID, identity)
+        if err != nil {
+            return nil, err
+        }
+        if !linked {
+            return nil, ErrIdentityConflict
+        }
+        // Furnizorul a confirmat adresa
+        if !u.EmailVerified {
+            if _, err := s.Users.UpdateFields(ctx, u.ID, map[string]interface{}{"emailVerified": true, "emailVerifiedAt": now}); err != nil {
+                return nil, err
+            }
+            u.EmailVerified = true
+        }
+        logger.Infof("oidc_identity_linked", logger.Fields{"provider": provider, "user_id": u.ID.Hex()})
+        return u, nil
+    }
+    if !p.Config.AllowSignup {
+        return nil, ErrNoLinkedAccount
+    }
+    name := strings.TrimSpace(claims.Name)
+    if name == "" {
+        name = strings.SplitN(claims.Email, "@", 2)[0]
+    }
+    // Fără parolă locală; userul își poate seta una prin /auth/forgot-password
+    u := models.User{
+        ID:              primitive.NewObjectID(),
+        Name:            name,
+        Email:           utils.NormalizeEmail(claims.Email),
+        Roles:           []string{models.RoleMember},
+        EmailVerified:   true,
+        EmailVerifiedAt: &now,
+        Identities:      []models.ExternalIdentity{identity},
+    }
+    if err := s.Users.Create(ctx, &u); err != nil {
+        return nil, fmt.Errorf("create user from %s identity: %w", provider, err)
+    }
+    logger.Infof("oidc_user_created", logger.Fields{"provider": provider, "user_id": u.ID.Hex()})
+    return &u, nil
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"API-GO/internal/models"
+	"API-GO/internal/oidc"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// stubUsers implements the UserRepository methods used by OIDCService; the others panic.
+type stubUsers struct {
+    repository.UserRepository
+    byEmail map[string]*models.User
+    linked  []models.ExternalIdentity
+}
+
+func (s *stubUsers) GetByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+    for _, u := range s.byEmail {
+        for _, id := range u.Identities {
+            if id.Provider == provider && id.Subject == subject {
+                return u, nil
+            }
+        }
+    }
+    return nil, mongo.ErrNoDocuments
+}
+
+func (s *stubUsers) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+    if u, ok := s.byEmail[utils.NormalizeEmail(email)]; ok {
+        return u, nil
+    }
+    return nil, mongo.ErrNoDocuments
+}
+
+func (s *stubUsers) AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.ExternalIdentity) (bool, error) {
+    s.linked = append(s.linked, identity)
+    return true, nil
+}
+
+func (s *stubUsers) UpdateFields(ctx context.Context, id primitive.ObjectID, fields map[string]interface{}) (bool, error) {
+    return true, nil
+}
+
+// fakeOIDCProvider is a local provider (discovery, JWKS, token endpoint) that issues
+// ID tokens with the given email claims for any code obtained through authorize.
+type fakeOIDCProvider struct {
+    srv           *httptest.Server
+    key           *utils.JWTKey
+    email         string
+    emailVerified bool
+
+    mu        sync.Mutex
+    challenge string
+    nonce     string
+}
+
+func newFakeOIDCProvider(t *testing.T, email string, verified bool) *fakeOIDCProvider {
+    t.Helper()
+    priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    key, err := utils.NewJWTKey("key-1", priv, &priv.PublicKey)
+    if err != nil {
+        t.Fatal(err)
+    }
+    f := &fakeOIDCProvider{key: key, email: email, emailVerified: verified}
+    mux := http.NewServeMux()
+    mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+        json.NewEncoder(w).Encode(oidc.Discovery{
+            Issuer:                f.srv.URL,
+            AuthorizationEndpoint: f.srv.URL + "/authorize",
+            TokenEndpoint:         f.srv.URL + "/token",
+            JWKSURI:               f.srv.URL + "/jwks",
+        })
+    })
+    mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+        jwk, _ := f.key.JWK()
+        json.NewEncoder(w).Encode(utils.JWKS{Keys: []utils.JWK{jwk}})
+    })
+    mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+        r.ParseForm()
+        f.mu.Lock()
+        defer f.mu.Unlock()
+        if oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != f.challenge {
+            w.WriteHeader(http.StatusBadRequest)
+            json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+            return
+        }
+        now := time.Now()
+        tok := jwt.NewWithClaims(f.key.Method, oidc.IDTokenClaims{
+            Email:         f.email,
+            EmailVerified: oidc.Bool(f.emailVerified),
+            Nonce:         f.nonce,
+            RegisteredClaims: jwt.RegisteredClaims{
+                Issuer:    f.srv.URL,
+                Subject:   "subject-1",
+                Audience:  jwt.ClaimStrings{"test-client"},
+                IssuedAt:  jwt.NewNumericDate(now),
+                ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+            },
+        })
+        tok.Header["kid"] = f.key.ID
+        signed, err := tok.SignedString(f.key.Private)
+        if err != nil {
+            t.Error(err)
+        }
+        json.NewEncoder(w).Encode(oidc.TokenResponse{IDToken: signed, TokenType: "Bearer"})
+    })
+    f.srv = httptest.NewServer(mux)
+    t.Cleanup(f.srv.Close)
+    return f
+}
+
+// authorize records the PKCE challenge and nonce of the authorization request and returns a code.
+func (f *fakeOIDCProvider) authorize(t *testing.T, authURL string) string {
+    t.Helper()
+    u, err := url.Parse(authURL)
+    if err != nil {
+        t.Fatal(err)
+    }
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.challenge = u.Query().Get("code_challenge")
+    f.nonce = u.Query().Get("nonce")
+    return "code-1"
+}
+
+func (f *fakeOIDCProvider) provider() *oidc.Provider {
+    return oidc.NewProvider(oidc.Config{Name: "test", Issuer: f.srv.URL, ClientID: "test-client", RedirectURL: "https://app.example/callback"}, f.srv.Client())
+}
+
+func TestOIDCLoginDoesNotLinkUnverifiedEmail(t *testing.T) {
+    existing := &models.User{ID: primitive.NewObjectID(), Name: "Ana", Email: "ana@example.com"}
+    tests := []struct {
+        name     string
+        email    string
+        verified bool
+    }{
+        {"unverified email", "ana@example.com", false},
+        {"unverified email in other case", "Ana@Example.com", false},
+        {"no email", "", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            users := &stubUsers{byEmail: map[string]*models.User{existing.Email: existing}}
+            f := newFakeOIDCProvider(t, tt.email, tt.verified)
+            // Auth is not reached: the login must stop before any session is issued
+            svc := NewOIDCService(repository.NewMemoryOIDCStateRepository(), users, nil, f.provider())
+            ctx := context.Background()
+
+            authURL, state, err := svc.BeginLogin(ctx, "test")
+            if err != nil {
+                t.Fatal(err)
+            }
+            code := f.authorize(t, authURL)
+            _, _, err = svc.CompleteLogin(ctx, "test", state, code, models.ClientInfo{})
+            if !errors.Is(err, ErrOIDCEmailNotVerified) {
+                t.Fatalf("err = %v, want ErrOIDCEmailNotVerified", err)
+            }
+            if len(users.linked) != 0 {
+                t.Fatalf("identity linked to the existing account: %+v", users.linked)
+            }
+        })
+    }
+}
+
+func TestOIDCLoginRejectsReusedState(t *testing.T) {
+    users := &stubUsers{byEmail: map[string]*models.User{}}
+    f := newFakeOIDCProvider(t, "ana@example.com", false)
+    svc := NewOIDCService(repository.NewMemoryOIDCStateRepository(), users, nil, f.provider())
+    ctx := context.Background()
+
+    authURL, state, err := svc.BeginLogin(ctx, "test")
+    if err != nil {
+        t.Fatal(err)
+    }
+    code := f.authorize(t, authURL)
+    if _, _, err := svc.CompleteLogin(ctx, "test", state, code, models.ClientInfo{}); !errors.Is(err, ErrOIDCEmailNotVerified) {
+        t.Fatalf("first callback: err = %v", err)
+    }
+    if _, _, err := svc.CompleteLogin(ctx, "test", state, code, models.ClientInfo{}); !errors.Is(err, ErrInvalidOIDCState) {
+        t.Fatalf("second callback: err = %v, want ErrInvalidOIDCState", err)
+    }
+}
+
+func TestResolveUserLinksVerifiedEmail(t *testing.T) {
+    existing := &models.User{ID: primitive.NewObjectID(), Name: "Ana", Email: "ana@example.com"}
+    users := &stubUsers{byEmail: map[string]*models.User{existing.Email: existing}}
+    p := oidc.NewProvider(oidc.Config{Name: "test", Issuer: "https://idp.example", ClientID: "test-client"}, nil)
+    svc := NewOIDCService(repository.NewMemoryOIDCStateRepository(), users, nil, p)
+
+    claims := &oidc.IDTokenClaims{Email: "ana@example.com", EmailVerified: true}
+    claims.Subject = "subject-1"
+    u, err := svc.resolveUser(context.Background(), p, claims)
+    if err != nil {
+        t.Fatalf("resolveUser: %v", err)
+    }
+    if u.ID != existing.ID || !u.EmailVerified {
+        t.Fatalf("unexpected user: %+v", u)
+    }
+    if len(users.linked) != 1 || users.linked[0].Provider != "test" || users.linked[0].Subject != "subject-1" {
+        t.Fatalf("linked identities = %+v", users.linked)
+    }
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/mailer"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// Cererile de resetare se limitează cu LoginThrottle, pe contoare separate de cele ale login-ului
+const passwordResetThrottleScope = "password_reset"
+
+// PasswordResetService implementează fluxul "am uitat parola" cu token-uri de unică folosință trimise pe email.
+type PasswordResetService struct {
+    Users   repository.UserRepository
+    Tokens  repository.OneTimeTokenRepository
+    Mailer  mailer.Mailer
+    Auth    *AuthService
+    BaseURL string
+    TTL     time.Duration
+    // Valabilitatea linkului din invitația trimisă unui cont creat de admin
+    InviteTTL time.Duration
+}
+
+func NewPasswordResetService(users repository.UserRepository, tokens repository.OneTimeTokenRepository, m mailer.Mailer, auth *AuthService, baseURL string, ttl time.Duration) *PasswordResetService {
+    return &PasswordResetService{Users: users, Tokens: tokens, Mailer: m, Auth: auth, BaseURL: strings.TrimRight(baseURL, "/"), TTL: ttl}
+}
+
+// CheckRequest numără o cerere de resetare pentru email și IP; peste pragurile login-ului întoarce
+// *RateLimitError. Se apelează sincron, înainte de RequestReset, ca handler-ul să poată răspunde 429.
+func (s *PasswordResetService) CheckRequest(ctx context.Context, email, ip string) error {
+    return s.Auth.Throttle.Limit(ctx, passwordResetThrottleScope, email, ip)
+}
+
+// RequestReset trimite un link de resetare dacă emailul există.
+// Nu întoarce eroare pentru emailuri necunoscute, ca să nu dezvăluie ce conturi există.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+    if !utils.IsValidEmail(email) {
+        return invalid("invalid email format")
+    }
+    u, err := s.Users.GetByEmail(ctx, email)
+    if err != nil {
+        logger.Infof("password_reset_unknown_email", logger.Fields{"email_hash": utils.EmailLogHash(email)})
+        return nil
+    }
+    link, err := s.newLink(ctx, u, s.TTL)
+    if err != nil {
+        return err
+    }
+    msg := mailer.Message{
+        To:      u.Email,
+        Subject: "Reset your password",
+        Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %d minutes and can be used once.\n\n%s\n\nIf you did not request this, you can ignore this email.\n",
+            u.Name, int(s.TTL.Minutes()), link),
+    }
+    if err := s.Mailer.Send(ctx, msg); err != nil {
+        return err
+    }
+    logger.Infof("password_reset_requested", logger.Fields{"user_id": u.ID.Hex()})
+    return nil
+}
+
+// Invite trimite unui cont creat de admin linkul prin care își alege parola.
+// Folosește același token ca resetarea, deci linkul duce tot la /reset-password.
+func (s *PasswordResetService) Invite(ctx context.Context, u *models.User) error {
+    ttl := s.InviteTTL
+    if ttl <= 0 {
+        ttl = s.TTL
+    }
+    link, err := s.newLink(ctx, u, ttl)
+    if err != nil {
+        return err
+    }
+    msg := mailer.Message{
+        To:      u.Email,
+        Subject: "Your account has been created",
+        Body: fmt.Sprintf("Hi %s,\n\nAn account has been created for you. Use the link below to choose your password. It expires in %d hours and can be used once.\n\n%s\n",
+            u.Name, int(ttl.Hours()), link),
+    }
+    if err := s.Mailer.Send(ctx, msg); err != nil {
+        return err
+    }
+    logger.Infof("user_invite_sent", logger.Fields{"user_id": u.ID.Hex()})
+    return nil
+}
+
+// newLink creează un token de resetare pentru u și întoarce linkul către frontend
+func (s *PasswordResetService) newLink(ctx context.Context, u *models.User, ttl time.Duration) (string, error) {
+    now := time.Now()
+    // Un singur link valid odată: cererile noi le invalidează pe cele vechi
+    if _, err := s.Tokens.InvalidateForUser(ctx, u.ID, models.TokenPurposePasswordReset, now); err != nil {
+        return "", err
+    }
+    raw, err := utils.GenerateOpaqueToken()
+    if err != nil {
+        return "", err
+    }
+    t := models.OneTimeToken{
+        UserID:    u.ID,
+        Purpose:   models.TokenPurposePasswordReset,
+        TokenHash: utils.HashToken(raw),
+        Email:     u.Email,
+        CreatedAt: now,
+        ExpiresAt: now.Add(ttl),
+    }
+    if err := s.Tokens.Create(ctx, &t); err != nil {
+        return "", err
+    }
+    return fmt.Sprintf("%s/reset-password?token=%s", s.BaseURL, url.QueryEscape(raw)), nil
+}
+
+// ResetPassword consumă token-ul, setează parola nouă și invalidează toate sesiunile existente.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, in models.ResetPasswordRequest) error {
+    if in.Token == "" || in.Password == "" {
+        return invalid("token and password are required")
+    }
+    if in.Password != in.PasswordConfirm {
+        return invalid("passwords do not match")
+    }
+    // Politica de parole are nevoie de numele și emailul userului; token-ul se consumă abia după validare
+    hash := utils.HashToken(in.Token)
+    pending, err := s.Tokens.Find(ctx, models.TokenPurposePasswordReset, hash, time.Now())
+    if err != nil {
+        return ErrInvalidResetToken
+    }
+    u, err := s.Users.GetByID(ctx, pending.UserID)
+    if err != nil {
+        return ErrInvalidResetToken
+    }
+    if err := s.Auth.ValidateNewPassword(in.Password, u.Name, u.Email); err != nil {
+        return err
+    }
+    hashed, err := s.Auth.Passwords.Hash(in.Password)
+    if err != nil {
+        return err
+    }
+    t, err := s.Tokens.Consume(ctx, models.TokenPurposePasswordReset, hash, time.Now())
+    if err != nil {
+        return ErrInvalidResetToken
+    }
+    fields := map[string]interface{}{"password": hashed}
+    // Linkul a ajuns în căsuța de email, deci adresa e confirmată (util mai ales pentru invitații)
+    if !u.EmailVerified && strings.EqualFold(t.Email, u.Email) {
+        fields["emailVerified"] = true
+        fields["emailVerifiedAt"] = time.Now()
+    }
+    if u.MustChangePassword {
+        clearTemporaryPassword(fields)
+    }
+    ok, err := s.Users.UpdateFields(ctx, t.UserID, fields)
+    if err != nil {
+        return err
+    }
+    if !ok {
+        return ErrInvalidResetToken
+    }
+    if err := s.Auth.LogoutEverywhere(ctx, t.UserID.Hex(), time.Now()); err != nil {
+        return err
+    }
+    logger.Infof("password_reset_completed", logger.Fields{"user_id": t.UserID.Hex()})
+    return nil
+}
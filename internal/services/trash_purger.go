@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"API-GO/internal/logger"
+)
+
+const defaultTrashPurgeInterval = time.Hour
+
+// TrashPurgeTarget e un repository cu ștergere soft (vezi repository.SoftDeleteRepository).
+type TrashPurgeTarget interface {
+    PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type trashTarget struct {
+    name string
+    repo TrashPurgeTarget
+}
+
+// TrashPurger șterge definitiv, periodic, documentele aflate în coș de mai mult de After.
+type TrashPurger struct {
+    After    time.Duration
+    Interval time.Duration
+    targets  []trashTarget
+}
+
+func NewTrashPurger(after time.Duration) *TrashPurger {
+    return &TrashPurger{After: after, Interval: defaultTrashPurgeInterval}
+}
+
+// Add înregistrează un coș; name apare în loguri.
+func (p *TrashPurger) Add(name string, repo TrashPurgeTarget) *TrashPurger {
+    p.targets = append(p.targets, trashTarget{name: name, repo: repo})
+    return p
+}
+
+// PurgeOnce golește o dată toate coșurile; o eroare la un coș nu le oprește pe celelalte.
+func (p *TrashPurger) PurgeOnce(ctx context.Context) {
+    before := time.Now().Add(-p.After)
+    for _, t := range p.targets {
+        n, err := t.repo.PurgeDeletedBefore(ctx, before)
+        if err != nil {
+            logger.Errorf("trash_purge_failed", logger.Fields{"collection": t.name, "error": err.Error()})
+            continue
+        }
+        if n > 0 {
+            logger.Infof("trash_purged", logger.Fields{"collection": t.name, "deleted": n, "before": before})
+        }
+    }
+}
+
+// Run golește coșurile la pornire și apoi la fiecare Interval, până la anularea ctx.
+func (p *TrashPurger) Run(ctx context.Context) {
+    ticker := time.NewTicker(p.Interval)
+    defer ticker.Stop()
+    for {
+        runCtx, cancel := context.WithTimeout(ctx, time.Minute)
+        p.PurgeOnce(runCtx)
+        cancel()
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+    ErrInvalidChallenge     = errors.New("invalid or expired two-factor challenge")
+    ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+    ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+    ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+)
+
+const (
+    twoFactorChallengePurpose = "2fa_login"
+    twoFactorChallengeTTL     = 5 * time.Minute
+    recoveryCodeCount         = 10
+)
+
+// TwoFactorRequiredError nu e un eșec propriu-zis: parola e corectă, dar login-ul continuă cu pasul TOTP.
+type TwoFactorRequiredError struct {
+    Challenge models.TwoFactorChallenge
+}
+
+func (e *TwoFactorRequiredError) Error() string { return "two-factor authentication required" }
+
+// TwoFactorService gestionează înrolarea TOTP, codurile de recuperare și al doilea pas de login.
+type TwoFactorService struct {
+    Users    repository.UserRepository
+    Settings repository.SettingsRepository
+    Auth     *AuthService
+    Issuer   string
+}
+
+func NewTwoFactorService(users repository.UserRepository, settings repository.SettingsRepository, auth *AuthService, issuer string) *TwoFactorService {
+    return &TwoFactorService{Users: users, Settings: settings, Auth: auth, Issuer: issuer}
+}
+
+// BeginSetup generează un secret nou (în așteptare până la confirmare) și URI-ul pentru codul QR.
+func (s *TwoFactorService) BeginSetup(ctx context.Context, userID string) (*models.TwoFactorSetupResponse, error) {
+    u, err := s.user(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if u.TOTPEnabled {
+        return nil, ErrTwoFactorEnabled
+    }
+    secret, err := utils.GenerateTOTPSecret()
+    if err != nil {
+        return nil, err
+    }
+    if _, err := s.Users.UpdateFields(ctx, u.ID, map[string]interface{}{"totpPendingSecret": secret}); err != nil {
+        return nil, err
+    }
+    return &models.TwoFactorSetupResponse{Secret: secret, OTPAuthURL: utils.TOTPURI(s.Issuer, u.Email, secret)}, nil
+}
+
+// ConfirmSetup activează 2FA dacă codul e valid pentru secretul în așteptare și întoarce codurile de recuperare.
+func (s *TwoFactorService) ConfirmSetup(ctx context.Context, userID, code string) ([]string, error) {
+    u, err := s.user(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if u.TOTPEnabled {
+        return nil, ErrTwoFactorEnabled
+    }
+    if u.TOTPPendingSecret == "" {
+        return nil, invalid("two-factor setup has not been started")
+    }
+    step, ok := utils.ValidateTOTP(u.TOTPPendingSecret, code, time.Now())
+    if !ok {
+        return nil, ErrInvalidTwoFactorCode
+    }
+    codes, hashes, err := newRecoveryCodes()
+    if err != nil {
+        return nil, err
+    }
+    fields := map[string]interface{}{
+        "totpEnabled":       true,
+        "totpSecret":        u.TOTPPendingSecret,
+        "totpPendingSecret": "",
+        "totpLastStep":      step,
+        "recoveryCodes":     hashes,
+    }
+    if _, err := s.Users.UpdateFields(ctx, u.ID, fields); err != nil {
+        return nil, err
+    }
+    logger.Infof("2fa_enabled", logger.Fields{"user_id": userID})
+    return codes, nil
+}
+
+// Disable dezactivează 2FA; cere parola și un cod TOTP (sau de recuperare) valid.
+// Parolele și codurile greșite se numără ca la login, altfel o sesiune furată le-ar putea ghici.
+func (s *TwoFactorService) Disable(ctx context.Context, userID string, in models.TwoFactorDisableRequest, client models.ClientInfo) error {
+    u, err := s.user(ctx, userID)
+    if err != nil {
+        return err
+    }
+    if !u.TOTPEnabled {
+        return ErrTwoFactorNotEnabled
+    }
+    if required, err := s.RequiredFor(ctx, u); err != nil {
+        return err
+    } else if required {
+        return invalid("two-factor authentication is required for your role")
+    }
+    if err := s.Auth.Throttle.Check(ctx, u.Email, client.IP); err != nil {
+        return err
+    }
+    if !s.Auth.Passwords.Verify(u.Password, in.Password) {
+        s.Auth.Throttle.Failure(ctx, u.Email, client.IP)
+        return invalid("password is incorrect")
+    }
+    if err := s.verifySessionCode(ctx, u, in.Code, in.Code, client); err != nil {
+        return err
+    }
+    fields := map[string]interface{}{
+        "totpEnabled":       false,
+        "totpSecret":        "",
+        "totpPendingSecret": "",
+        "recoveryCodes":     []string{},
+    }
+    if _, err := s.Users.UpdateFields(ctx, u.ID, fields); err != nil {
+        return err
+    }
+    logger.Infof("2fa_disabled", logger.Fields{"user_id": userID})
+    return nil
+}
+
+// RegenerateRecoveryCodes înlocuiește toate codurile de recuperare; cere un cod TOTP valid,
+// cu aceeași limită de încercări ca Disable.
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID, code string, client models.ClientInfo) ([]string, error) {
+    u, err := s.user(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if !u.TOTPEnabled {
+        return nil, ErrTwoFactorNotEnabled
+    }
+    if err := s.Auth.Throttle.Check(ctx, u.Email, client.IP); err != nil {
+        return nil, err
+    }
+    if err := s.verifySessionCode(ctx, u, code, "", client); err != nil {
+        return nil, err
+    }
+    codes, hashes, err := newRecoveryCodes()
+    if err != nil {
+        return nil, err
+    }
+    if _, err := s.Users.UpdateFields(ctx, u.ID, map[string]interface{}{"recoveryCodes": hashes}); err != nil {
+        return nil, err
+    }
+    return codes, nil
+}
+
+// Challenge emite token-ul de scurtă durată pentru pasul al doilea de login.
+func (s *TwoFactorService) Challenge(u *models.User) error {
+    token, exp, err := s.Auth.JWT.GenerateChallengeToken(u.ID.Hex(), twoFactorChallengePurpose, twoFactorChallengeTTL)
+    if err != nil {
+        return err
+    }
+    return &TwoFactorRequiredError{Challenge: models.TwoFactorChallenge{TwoFactorRequired: true, ChallengeToken: token, ExpiresAt: exp}}
+}
+
+// CompleteLogin verifică token-ul de challenge și codul, apoi emite sesiunea normală.
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, in models.TwoFactorLoginRequest, client models.ClientInfo) (*models.AuthResponse, *models.TokenPair, error) {
+    claims, err := s.Auth.JWT.ParseChallengeToken(in.ChallengeToken, twoFactorChallengePurpose)
+    if err != nil {
+        return nil, nil, ErrInvalidChallenge
+    }
+    u, err := s.user(ctx, claims.UserID)
+    if err != nil || !u.TOTPEnabled {
+        return nil, nil, ErrInvalidChallenge
+    }
+    // Codurile greșite se numără la fel ca parolele greșite, altfel TOTP-ul ar putea fi ghicit
+    if err := s.Auth.Throttle.Check(ctx, u.Email, client.IP); err != nil {
+        return nil, nil, err
+    }
+    if err := s.verifyCode(ctx, u, in.Code, in.RecoveryCode); err != nil {
+        logger.Warnf("2fa_login_failed", logger.Fields{"user_id": claims.UserID})
+        if errors.Is(err, ErrInvalidTwoFactorCode) {
+            s.Auth.Throttle.Failure(ctx, u.Email, client.IP)
+        }
+        return nil, nil, err
+    }
+    tokens, err := s.Auth.issueTokens(ctx, u, client)
+    if err != nil {
+        return nil, nil, err
+    }
+    s.Auth.Throttle.Success(ctx, u.Email)
+    logger.Infof("2fa_login_success", logger.Fields{"user_id": claims.UserID})
+    return authResponse(u), tokens, nil
+}
+
+// RequiredFor spune dacă rolurile userului impun 2FA conform setărilor curente.
+func (s *TwoFactorService) RequiredFor(ctx context.Context, u *models.User) (bool, error) {
+    settings, err := s.Settings.GetSecurity(ctx)
+    if err != nil {
+        return false, err
+    }
+    return u.HasAnyRole(settings.TwoFactorRequiredRoles...), nil
+}
+
+// SetupRequired spune dacă userul trebuie să-și activeze 2FA înainte de acces complet:
+// rolurile lui îl impun și nu l-a activat încă.
+func (s *TwoFactorService) SetupRequired(ctx context.Context, u *models.User) (bool, error) {
+    if s == nil || u.TOTPEnabled {
+        return false, nil
+    }
+    return s.RequiredFor(ctx, u)
+}
+
+// Policy întoarce setările de securitate curente.
+func (s *TwoFactorService) Policy(ctx context.Context) (*models.SecuritySettings, error) {
+    return s.Settings.GetSecurity(ctx)
+}
+
+// SetRequiredRoles stabilește rolurile pentru care 2FA e obligatoriu.
+func (s *TwoFactorService) SetRequiredRoles(ctx context.Context, roles []string, by string) (*models.SecuritySettings, error) {
+    clean := []string{}
+    seen := map[string]bool{}
+    for _, r := range roles {
+        if !models.IsValidRole(r) {
+            return nil, invalid("invalid role: " + r)
+        }
+        if !seen[r] {
+            seen[r] = true
+            clean = append(clean, r)
+        }
+    }
+    settings, err := s.Settings.GetSecurity(ctx)
+    if err != nil {
+        return nil, err
+    }
+    settings.TwoFactorRequiredRoles = clean
+    settings.UpdatedAt = time.Now()
+    settings.UpdatedBy = by
+    if err := s.Settings.SaveSecurity(ctx, settings); err != nil {
+        return nil, err
+    }
+    logger.Infof("2fa_policy_updated", logger.Fields{"roles": clean, "by": by})
+    return settings, nil
+}
+
+// verifyCode acceptă fie un cod TOTP nefolosit, fie un cod de recuperare (consumat la utilizare).
+// verifySessionCode e verifyCode pentru operațiile făcute dintr-o sesiune: un cod greșit se numără
+// în contorul de login al contului, iar unul corect îl golește, ca după un login reușit.
+func (s *TwoFactorService) verifySessionCode(ctx context.Context, u *models.User, code, recovery string, client models.ClientInfo) error {
+    if err := s.verifyCode(ctx, u, code, recovery); err != nil {
+        if errors.Is(err, ErrInvalidTwoFactorCode) {
+            s.Auth.Throttle.Failure(ctx, u.Email, client.IP)
+            logger.Warnf("2fa_session_code_failed", logger.Fields{"user_id": u.ID.Hex()})
+        }
+        return err
+    }
+    s.Auth.Throttle.Success(ctx, u.Email)
+    return nil
+}
+
+func (s *TwoFactorService) verifyCode(ctx context.Context, u *models.User, code, recovery string) error {
+    if code != "" {
+        if step, ok := utils.ValidateTOTP(u.TOTPSecret, code, time.Now()); ok {
+            fresh, err := s.Users.MarkTOTPStepUsed(ctx, u.ID, step)
+            if err != nil {
+                return err
+            }
+            if fresh {
+                return nil
+            }
+        }
+    }
+    if recovery != "" {
+        ok, err := s.Users.ConsumeRecoveryCode(ctx, u.ID, utils.HashRecoveryCode(recovery))
+        if err != nil {
+            return err
+        }
+        if ok {
+            logger.Infof("2fa_recovery_code_used", logger.Fields{"user_id": u.ID.Hex()})
+            return nil
+        }
+    }
+    return ErrInvalidTwoFactorCode
+}
+
+func (s *TwoFactorService) user(ctx context.Context, userID string) (*models.User, error) {
+    oid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, fmt.Errorf("invalid user id: %w", err)
+    }
+    return s.Users.GetByID(ctx, oid)
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+    codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+    if err != nil {
+        return nil, nil, err
+    }
+    hashes := make([]string, len(codes))
+    for i, c := range codes {
+        hashes[i] = utils.HashRecoveryCode(c)
+    }
+    return codes, hashes, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// totpUsers mirrors the atomic updates of MongoUserRepository for the 2FA state of one user.
+type totpUsers struct {
+    repository.UserRepository
+    user          *models.User
+    lastStep      int64
+    recoveryCodes []string
+}
+
+func (r *totpUsers) GetByID(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+    return r.user, nil
+}
+
+func (r *totpUsers) MarkTOTPStepUsed(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+    if step <= r.lastStep {
+        return false, nil
+    }
+    r.lastStep = step
+    return true, nil
+}
+
+func (r *totpUsers) ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hash string) (bool, error) {
+    i := slices.Index(r.recoveryCodes, hash)
+    if i < 0 {
+        return false, nil
+    }
+    r.recoveryCodes = slices.Delete(r.recoveryCodes, i, i+1)
+    return true, nil
+}
+
+func newTOTPUser(t *testing.T) *models.User {
+    t.Helper()
+    secret, err := utils.GenerateTOTPSecret()
+    if err != nil {
+        t.Fatal(err)
+    }
+    return &models.User{ID: primitive.NewObjectID(), TOTPEnabled: true, TOTPSecret: secret}
+}
+
+func TestVerifyCodeRejectsReusedStep(t *testing.T) {
+    users := &totpUsers{}
+    s := &TwoFactorService{Users: users}
+    u := newTOTPUser(t)
+    ctx := context.Background()
+
+    code, err := utils.TOTPCode(u.TOTPSecret, utils.TOTPStep(time.Now()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := s.verifyCode(ctx, u, code, ""); err != nil {
+        t.Fatalf("first use: %v", err)
+    }
+    if err := s.verifyCode(ctx, u, code, ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+        t.Fatalf("second use: err = %v, want ErrInvalidTwoFactorCode", err)
+    }
+    // A code from the previous step is still inside the window, but older than the one used
+    previous, _ := utils.TOTPCode(u.TOTPSecret, utils.TOTPStep(time.Now())-1)
+    if err := s.verifyCode(ctx, u, previous, ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+        t.Fatalf("earlier step: err = %v, want ErrInvalidTwoFactorCode", err)
+    }
+}
+
+func TestVerifyCodeRecoveryCodeSingleUse(t *testing.T) {
+    codes, hashes, err := newRecoveryCodes()
+    if err != nil {
+        t.Fatal(err)
+    }
+    users := &totpUsers{recoveryCodes: hashes}
+    s := &TwoFactorService{Users: users}
+    u := newTOTPUser(t)
+    ctx := context.Background()
+
+    if err := s.verifyCode(ctx, u, "", codes[0]); err != nil {
+        t.Fatalf("first use: %v", err)
+    }
+    if err := s.verifyCode(ctx, u, "", codes[0]); !errors.Is(err, ErrInvalidTwoFactorCode) {
+        t.Fatalf("second use: err = %v, want ErrInvalidTwoFactorCode", err)
+    }
+    if len(users.recoveryCodes) != recoveryCodeCount-1 {
+        t.Fatalf("%d recovery codes left, want %d", len(users.recoveryCodes), recoveryCodeCount-1)
+    }
+    // The other codes are unaffected
+    if err := s.verifyCode(ctx, u, "", codes[1]); err != nil {
+        t.Fatalf("another code: %v", err)
+    }
+    if err := s.verifyCode(ctx, u, "", "aaaaa-bbbbb"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+        t.Fatalf("unknown code: err = %v, want ErrInvalidTwoFactorCode", err)
+    }
+}
+
+func TestRegenerateRecoveryCodesThrottlesWrongCodes(t *testing.T) {
+    u := newTOTPUser(t)
+    u.Email = "ana@example.com"
+    users := &totpUsers{user: u}
+    s := &TwoFactorService{Users: users, Auth: &AuthService{Throttle: newTestThrottle()}}
+    ctx := context.Background()
+    client := models.ClientInfo{IP: "10.0.0.1"}
+
+    for i := 0; i < loginFreeFailures; i++ {
+        if _, err := s.RegenerateRecoveryCodes(ctx, u.ID.Hex(), "abcdef", client); !errors.Is(err, ErrInvalidTwoFactorCode) {
+            t.Fatalf("attempt %d: err = %v, want ErrInvalidTwoFactorCode", i+1, err)
+        }
+    }
+    // Even the right code has to wait once the account is throttled
+    code, err := utils.TOTPCode(u.TOTPSecret, utils.TOTPStep(time.Now()))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var rl *RateLimitError
+    if _, err := s.RegenerateRecoveryCodes(ctx, u.ID.Hex(), code, client); !errors.As(err, &rl) {
+        t.Fatalf("err = %v, want *RateLimitError", err)
+    }
+    // The failures count against the account, as for logins
+    if err := s.Auth.Throttle.Check(ctx, u.Email, "10.0.0.2"); !errors.As(err, &rl) {
+        t.Fatalf("login not throttled after wrong codes: %v", err)
+    }
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+    ErrUserNotFound           = errors.New("user not found")
+    ErrEmailExists            = errors.New("email already exists")
+    ErrPhoneExists            = errors.New("phone number already exists")
+    ErrStatusChangeNotAllowed = errors.New("you cannot change the status of your own account")
+)
+
+const (
+    temporaryPasswordLength = 16
+    // Cât timp e refolosită starea unui cont verificată de middleware
+    accountStatusCacheTTL = 30 * time.Second
+    // Peste acest număr de intrări, cele expirate sunt eliminate din cache
+    accountStatusCacheSweep = 10000
+)
+
+type accountStatusEntry struct {
+    active  bool
+    expires time.Time
+}
+
+// UserAdminService grupează operațiile adminului asupra conturilor: creare (cu invitație sau
+// parolă temporară) și schimbarea stării (suspendare, dezactivare, reactivare).
+// Implementează și middleware.AccountChecker, cu un cache scurt ca să nu citească userul la fiecare request.
+type UserAdminService struct {
+    Users repository.UserRepository
+    Auth  *AuthService
+    // Opțional: fără el se poate crea doar cu parolă temporară
+    Reset *PasswordResetService
+    // Cât rămâne valabilă parola temporară nefolosită; 0 = fără expirare
+    TemporaryPasswordTTL time.Duration
+
+    mu    sync.Mutex
+    cache map[string]accountStatusEntry
+}
+
+func NewUserAdminService(users repository.UserRepository, auth *AuthService, reset *PasswordResetService) *UserAdminService {
+    return &UserAdminService{Users: users, Auth: auth, Reset: reset, cache: map[string]accountStatusEntry{}}
+}
+
+// Create creează un cont în numele adminului. Cu TemporaryPassword parola generată e întoarsă
+// o singură dată și trebuie schimbată la primul login (expiră după TemporaryPasswordTTL);
+// altfel userul primește pe email linkul prin care își alege parola.
+func (s *UserAdminService) Create(ctx context.Context, actorID string, in models.AdminCreateUserRequest) (*models.AdminCreatedUser, error) {
+    name := strings.TrimSpace(in.Name)
+    email := utils.NormalizeEmail(in.Email)
+    phone := strings.TrimSpace(in.Phone)
+    if len(name) < 2 || len(name) > 50 {
+        return nil, invalid("name must be between 2 and 50 characters")
+    }
+    if !utils.IsValidEmail(email) {
+        return nil, invalid("invalid email format")
+    }
+    roles, err := cleanRoles(in.Roles)
+    if err != nil {
+        return nil, err
+    }
+    if phone != "" {
+        if phone, err = utils.NormalizePhone(phone); err != nil {
+            return nil, invalid(utils.PhoneFormatHint)
+        }
+    }
+    if !in.TemporaryPassword && s.Reset == nil {
+        return nil, invalid("invitations are not available; use temporaryPassword")
+    }
+    exists, err := s.Users.EmailExists(ctx, email)
+    if err != nil {
+        return nil, err
+    }
+    if exists {
+        return nil, ErrEmailExists
+    }
+    if phone != "" {
+        exists, err := s.Users.PhoneExists(ctx, phone)
+        if err != nil {
+            return nil, err
+        }
+        if exists {
+            return nil, ErrPhoneExists
+        }
+    }
+
+    u := models.User{
+        ID:    primitive.NewObjectID(),
+        Name:  name,
+        Email: email,
+        Phone: phone,
+        Roles: roles,
+    }
+    var temporary string
+    if in.TemporaryPassword {
+        temporary, err = s.temporaryPassword(name, email)
+        if err != nil {
+            return nil, err
+        }
+        if u.Password, err = s.Auth.Passwords.Hash(temporary); err != nil {
+            return nil, err
+        }
+        u.MustChangePassword = true
+        if s.TemporaryPasswordTTL > 0 {
+            expires := time.Now().Add(s.TemporaryPasswordTTL)
+            u.TemporaryPasswordExpiresAt = &expires
+        }
+    }
+    if err := s.Users.Create(ctx, &u); err != nil {
+        return nil, err
+    }
+    logger.Infof("user_created_by_admin", logger.Fields{"user_id": u.ID.Hex(), "by": actorID, "roles": roles, "invite": !in.TemporaryPassword})
+
+    out := &models.AdminCreatedUser{User: authResponse(&u), TemporaryPassword: temporary}
+    if in.TemporaryPassword {
+        // Emailul nu e confirmat de nimeni încă; se trimite linkul obișnuit de verificare
+        if s.Auth.EmailVerification != nil {
+            s.Auth.EmailVerification.SendAsync(u, false)
+        }
+        return out, nil
+    }
+    // Contul există deja; dacă emailul nu pleacă, adminul poate retrimite prin /auth/forgot-password
+    if err := s.Reset.Invite(ctx, &u); err != nil {
+        logger.Errorf("user_invite_failed", logger.Fields{"user_id": u.ID.Hex(), "error": err.Error()})
+        return out, nil
+    }
+    out.InviteSent = true
+    return out, nil
+}
+
+// SetStatus schimbă starea contului și o adaugă în istoric împreună cu motivul.
+// La suspendare sau dezactivare toate sesiunile userului se închid imediat.
+func (s *UserAdminService) SetStatus(ctx context.Context, actorID, targetID, status string, in models.StatusChangeRequest) (*models.StatusChange, error) {
+    if !models.IsValidUserStatus(status) {
+        return nil, invalid("invalid status: " + status)
+    }
+    reason := strings.TrimSpace(in.Reason)
+    if reason == "" || len(reason) > 500 {
+        return nil, invalid("reason is required (max 500 characters)")
+    }
+    if targetID == actorID {
+        return nil, ErrStatusChangeNotAllowed
+    }
+    oid, err := primitive.ObjectIDFromHex(targetID)
+    if err != nil {
+        return nil, ErrUserNotFound
+    }
+    u, err := s.Users.GetByID(ctx, oid)
+    if repository.IsNotFound(err) {
+        return nil, ErrUserNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    if u.EffectiveStatus() == status {
+        return nil, invalid("account is already " + status)
+    }
+    change := models.StatusChange{Status: status, Reason: reason, By: actorID, At: time.Now()}
+    ok, err := s.Users.SetStatus(ctx, oid, change)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        return nil, ErrUserNotFound
+    }
+    s.forget(targetID)
+    if status != models.UserStatusActive {
+        if err := s.Auth.LogoutEverywhere(ctx, targetID, change.At); err != nil {
+            return nil, err
+        }
+    }
+    logger.Infof("user_status_changed", logger.Fields{"user_id": targetID, "status": status, "previous": u.EffectiveStatus(), "by": actorID, "reason": reason})
+    return &change, nil
+}
+
+// AccountActive spune dacă userul există și are starea active (middleware.AccountChecker).
+func (s *UserAdminService) AccountActive(ctx context.Context, userID string) (bool, error) {
+    now := time.Now()
+    s.mu.Lock()
+    e, ok := s.cache[userID]
+    s.mu.Unlock()
+    if ok && now.Before(e.expires) {
+        return e.active, nil
+    }
+    oid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return false, nil
+    }
+    active := false
+    u, err := s.Users.GetByID(ctx, oid)
+    switch {
+    case err == nil:
+        active = u.EffectiveStatus() == models.UserStatusActive
+    case !repository.IsNotFound(err):
+        return false, err
+    }
+    s.mu.Lock()
+    if len(s.cache) >= accountStatusCacheSweep {
+        for k, v := range s.cache {
+            if !now.Before(v.expires) {
+                delete(s.cache, k)
+            }
+        }
+    }
+    s.cache[userID] = accountStatusEntry{active: active, expires: now.Add(accountStatusCacheTTL)}
+    s.mu.Unlock()
+    return active, nil
+}
+
+func (s *UserAdminService) forget(userID string) {
+    s.mu.Lock()
+    delete(s.cache, userID)
+    s.mu.Unlock()
+}
+
+// temporaryPassword generează o parolă care trece politica configurată
+func (s *UserAdminService) temporaryPassword(name, email string) (string, error) {
+    for i := 0; i < 5; i++ {
+        p, err := utils.GenerateTemporaryPassword(temporaryPasswordLength)
+        if err != nil {
+            return "", err
+        }
+        if s.Auth.ValidateNewPassword(p, name, email) == nil {
+            return p, nil
+        }
+    }
+    return "", errors.New("could not generate a temporary password that satisfies the password policy")
+}
+
+// cleanRoles validează și deduplică rolurile; fără roluri, userul e membru
+func cleanRoles(in []string) ([]string, error) {
+    if len(in) == 0 {
+        return []string{models.RoleMember}, nil
+    }
+    seen := map[string]bool{}
+    roles := make([]string, 0, len(in))
+    for _, role := range in {
+        if !models.IsValidRole(role) {
+            return nil, invalid("invalid role: " + role)
+        }
+        if !seen[role] {
+            seen[role] = true
+            roles = append(roles, role)
+        }
+    }
+    return roles, nil
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"API-GO/internal/logger"
+	"API-GO/internal/models"
+	"API-GO/internal/repository"
+	"API-GO/internal/utils"
+	"API-GO/internal/webauthn"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+    ErrInvalidWebAuthnChallenge = errors.New("invalid or expired passkey challenge")
+    ErrWebAuthnFailed           = errors.New("passkey verification failed")
+    ErrCredentialExists         = errors.New("this passkey is already registered")
+    ErrCredentialNotFound       = errors.New("passkey not found")
+)
+
+const (
+    defaultWebAuthnChallengeTTL = 5 * time.Minute
+    maxCredentialsPerUser       = 10
+)
+
+// WebAuthnService înregistrează passkey-uri și autentifică cu ele, emițând apoi sesiunea obișnuită prin AuthService.
+type WebAuthnService struct {
+    RP           *webauthn.RelyingParty
+    Credentials  repository.WebAuthnCredentialRepository
+    Challenges   repository.WebAuthnChallengeRepository
+    Users        repository.UserRepository
+    Auth         *AuthService
+    ChallengeTTL time.Duration
+}
+
+func NewWebAuthnService(rp *webauthn.RelyingParty, creds repository.WebAuthnCredentialRepository, challenges repository.WebAuthnChallengeRepository, users repository.UserRepository, auth *AuthService) *WebAuthnService {
+    return &WebAuthnService{RP: rp, Credentials: creds, Challenges: challenges, Users: users, Auth: auth, ChallengeTTL: defaultWebAuthnChallengeTTL}
+}
+
+// BeginRegistration întoarce opțiunile pentru navigator.credentials.create; cheile existente sunt excluse.
+func (s *WebAuthnService) BeginRegistration(ctx context.Context, userID string) (*webauthn.CreationOptions, error) {
+    u, err := s.user(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    creds, err := s.Credentials.ListForUser(ctx, u.ID)
+    if err != nil {
+        return nil, err
+    }
+    if len(creds) >= maxCredentialsPerUser {
+        return nil, invalid(fmt.Sprintf("you can register at most %d passkeys", maxCredentialsPerUser))
+    }
+    challenge, err := s.newChallenge(ctx, models.WebAuthnPurposeRegistration, u.ID)
+    if err != nil {
+        return nil, err
+    }
+    // user.id e id-ul intern (nu emailul), ca să nu expună date personale autentificatorului
+    user := webauthn.UserEntity{ID: u.ID[:], Name: u.Email, DisplayName: u.Name}
+    opts := s.RP.CreationOptions(challenge, user, descriptors(creds))
+    return &opts, nil
+}
+
+// FinishRegistration verifică răspunsul autentificatorului și salvează cheia.
+func (s *WebAuthnService) FinishRegistration(ctx context.Context, userID string, in models.WebAuthnRegistrationRequest) (*models.WebAuthnCredential, error) {
+    u, err := s.user(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    ch, err := s.consumeChallenge(ctx, in.Response.ClientDataJSON, models.WebAuthnPurposeRegistration)
+    if err != nil {
+        return nil, err
+    }
+    if ch.UserID != u.ID {
+        return nil, ErrInvalidWebAuthnChallenge
+    }
+    cred, err := s.RP.VerifyRegistration(in.RegistrationResponse, ch.Challenge)
+    if err != nil {
+        logger.Warnf("webauthn_registration_rejected", logger.Fields{"user_id": userID, "error": err.Error()})
+        return nil, ErrWebAuthnFailed
+    }
+    name := strings.TrimSpace(in.Name)
+    if name == "" {
+        name = "Passkey"
+    }
+    if len(name) > 100 {
+        return nil, invalid("name cannot exceed 100 characters")
+    }
+    c := models.WebAuthnCredential{
+        UserID:         u.ID,
+        CredentialID:   webauthn.URLEncoded(cred.ID).String(),
+        PublicKey:      cred.PublicKey,
+        Algorithm:      cred.Algorithm,
+        SignCount:      cred.SignCount,
+        AAGUID:         hex.EncodeToString(cred.AAGUID),
+        Transports:     cred.Transports,
+        BackupEligible: cred.BackupEligible,
+        BackedUp:       cred.BackedUp,
+        Name:           name,
+        CreatedAt:      time.Now(),
+    }
+    if err := s.Credentials.Create(ctx, &c); err != nil {
+        if errors.Is(err, repository.ErrDuplicateCredential) {
+            return nil, ErrCredentialExists
+        }
+        return nil, err
+    }
+    logger.Infof("webauthn_credential_registered", logger.Fields{"user_id": userID, "credential_id": c.ID.Hex()})
+    return &c, nil
+}
+
+// BeginLogin întoarce opțiunile pentru navigator.credentials.get. Cu email, doar cheile acelui cont sunt
+// permise; un email necunoscut primește aceleași opțiuni ca login-ul fără email, ca să nu dezvăluie conturile.
+func (s *WebAuthnService) BeginLogin(ctx context.Context, email string) (*webauthn.RequestOptions, error) {
+    var userID primitive.ObjectID
+    var allow []webauthn.CredentialDescriptor
+    if email != "" {
+        if u, err := s.Users.GetByEmail(ctx, email); err == nil {
+            creds, err := s.Credentials.ListForUser(ctx, u.ID)
+            if err != nil {
+                return nil, err
+            }
+            if len(creds) > 0 {
+                userID = u.ID
+                allow = descriptors(creds)
+            }
+        }
+    }
+    challenge, err := s.newChallenge(ctx, models.WebAuthnPurposeLogin, userID)
+    if err != nil {
+        return nil, err
+    }
+    opts := s.RP.RequestOptions(challenge, allow)
+    return &opts, nil
+}
+
+// FinishLogin verifică semnătura și contorul cheii, apoi emite sesiunea. 2FA (TOTP) se cere doar dacă
+// autentificatorul nu a verificat userul (PIN/biometric); altfel passkey-ul acoperă deja ambii factori.
+func (s *WebAuthnService) FinishLogin(ctx context.Context, in webauthn.AssertionResponse, client models.ClientInfo) (*models.AuthResponse, *models.TokenPair, error) {
+    ch, err := s.consumeChallenge(ctx, in.Response.ClientDataJSON, models.WebAuthnPurposeLogin)
+    if err != nil {
+        return nil, nil, err
+    }
+    cred, err := s.Credentials.GetByCredentialID(ctx, in.RawID.String())
+    if err != nil {
+        return nil, nil, err
+    }
+    if cred == nil {
+        return nil, nil, ErrWebAuthnFailed
+    }
+    if !ch.UserID.IsZero() && ch.UserID != cred.UserID {
+        return nil, nil, ErrWebAuthnFailed
+    }
+    if len(in.Response.UserHandle) > 0 && !bytes.Equal(in.Response.UserHandle, cred.UserID[:]) {
+        return nil, nil, ErrWebAuthnFailed
+    }
+    res, err := s.RP.VerifyAssertion(in, ch.Challenge, cred.PublicKey, cred.SignCount)
+    if err != nil {
+        if errors.Is(err, webauthn.ErrSignCountRegression) {
+            logger.Warnf("webauthn_sign_count_regression", logger.Fields{"user_id": cred.UserID.Hex(), "credential_id": cred.ID.Hex(), "stored": cred.SignCount})
+        } else {
+            logger.Warnf("webauthn_assertion_rejected", logger.Fields{"user_id": cred.UserID.Hex(), "error": err.Error()})
+        }
+        return nil, nil, ErrWebAuthnFailed
+    }
+    now := time.Now()
+    if err := s.Credentials.UpdateUsage(ctx, cred.ID, res.SignCount, res.BackedUp, now); err != nil {
+        return nil, nil, err
+    }
+    u, err := s.Users.GetByID(ctx, cred.UserID)
+    if err != nil {
+        return nil, nil, ErrWebAuthnFailed
+    }
+    if !u.EmailVerified && s.Auth.EmailVerification != nil && s.Auth.EmailVerification.Policy == models.EmailPolicyBlock {
+        return nil, nil, ErrEmailNotVerified
+    }
+    if s.Auth.TwoFactor != nil && u.TOTPEnabled && !res.UserVerified {
+        return nil, nil, s.Auth.TwoFactor.Challenge(u)
+    }
+    tokens, err := s.Auth.issueTokens(ctx, u, client)
+    if err != nil {
+        return nil, nil, err
+    }
+    logger.Infof("webauthn_login_success", logger.Fields{"user_id": u.ID.Hex(), "credential_id": cred.ID.Hex()})
+    return authResponse(u), tokens, nil
+}
+
+// ListCredentials întoarce passkey-urile userului.
+func (s *WebAuthnService) ListCredentials(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+    oid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, fmt.Errorf("invalid user id: %w", err)
+    }
+    return s.Credentials.ListForUser(ctx, oid)
+}
+
+// DeleteCredential șterge un passkey al userului.
+func (s *WebAuthnService) DeleteCredential(ctx context.Context, userID, id string) error {
+    uid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return fmt.Errorf("invalid user id: %w", err)
+    }
+    oid, err := primitive.ObjectIDFromHex(id)
+    if err != nil {
+        return ErrCredentialNotFound
+    }
+    ok, err := s.Credentials.Delete(ctx, uid, oid)
+    if err != nil {
+        return err
+    }
+    if !ok {
+        return ErrCredentialNotFound
+    }
+    logger.Infof("webauthn_credential_deleted", logger.Fields{"user_id": userID, "credential_id": id})
+    return nil
+}
+
+// newChallenge generează și salvează challenge-ul unei ceremonii.
+func (s *WebAuthnService) newChallenge(ctx context.Context, purpose string, userID primitive.ObjectID) ([]byte, error) {
+    challenge, err := webauthn.NewChallenge()
+    if err != nil {
+        return nil, err
+    }
+    now := time.Now()
+    ch := models.WebAuthnChallenge{
+        ChallengeHash: utils.HashToken(webauthn.URLEncoded(challenge).String()),
+        Purpose:       purpose,
+        UserID:        userID,
+        Challenge:     challenge,
+        CreatedAt:     now,
+        ExpiresAt:     now.Add(s.ChallengeTTL),
+    }
+    if err := s.Challenges.Create(ctx, &ch); err != nil {
+        return nil, err
+    }
+    return challenge, nil
+}
+
+// consumeChallenge găsește ceremonia după challenge-ul din clientDataJSON și o consumă (o singură încercare).
+func (s *WebAuthnService) consumeChallenge(ctx context.Context, clientDataJSON []byte, purpose string) (*models.WebAuthnChallenge, error) {
+    challenge, err := webauthn.ClientDataChallenge(clientDataJSON)
+    if err != nil {
+        return nil, ErrInvalidWebAuthnChallenge
+    }
+    ch, err := s.Challenges.Consume(ctx, utils.HashToken(webauthn.URLEncoded(challenge).String()), time.Now())
+    if err != nil {
+        return nil, err
+    }
+    if ch == nil || ch.Purpose != purpose {
+        return nil, ErrInvalidWebAuthnChallenge
+    }
+    return ch, nil
+}
+
+func (s *WebAuthnService) user(ctx context.Context, userID string) (*models.User, error) {
+    oid, err := primitive.ObjectIDFromHex(userID)
+    if err != nil {
+        return nil, fmt.Errorf("invalid user id: %w", err)
+    }
+    return s.Users.GetByID(ctx, oid)
+}
+
+func descriptors(creds []models.WebAuthnCredential) []webauthn.CredentialDescriptor {
+    out := make([]webauthn.CredentialDescriptor, 0, len(creds))
+    for _, c := range creds {
+        id, err := webauthn.DecodeURLEncoded(c.CredentialID)
+        if err != nil {
+            continue
+        }
+        out = append(out, webauthn.CredentialDescriptor{Type: "public-key", ID: id, Transports: c.Transports})
+    }
+    return out
+}
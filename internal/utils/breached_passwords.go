@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachedPasswordChecker reports whether a password appears in a corpus of breached passwords.
+type BreachedPasswordChecker interface {
+    IsBreached(password string) (bool, error)
+}
+
+// BreachedPasswordFile looks passwords up in a local copy of the Pwned Passwords SHA-1 data,
+// keyed the same way as the k-anonymity range API. Path is either:
+//   - a directory with one file per 5-character hash prefix ("ABCDE" or "ABCDE.txt") holding
+//     "SUFFIX:COUNT" lines, i.e. saved range responses; only that one file is read per lookup, or
+//   - a single file of "SHA1:COUNT" lines sorted by hash, searched with a binary search.
+type BreachedPasswordFile struct {
+    Path string
+    dir  bool
+}
+
+// OpenBreachedPasswordFile checks that path exists and detects its layout.
+func OpenBreachedPasswordFile(path string) (*BreachedPasswordFile, error) {
+    st, err := os.Stat(path)
+    if err != nil {
+        return nil, err
+    }
+    return &BreachedPasswordFile{Path: path, dir: st.IsDir()}, nil
+}
+
+func (b *BreachedPasswordFile) IsBreached(password string) (bool, error) {
+    sum := sha1.Sum([]byte(password))
+    hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+    if b.dir {
+        return b.lookupRange(hash[:5], hash[5:])
+    }
+    return b.lookupSorted(hash)
+}
+
+func (b *BreachedPasswordFile) lookupRange(prefix, suffix string) (bool, error) {
+    f, err := os.Open(filepath.Join(b.Path, prefix+".txt"))
+    if errors.Is(err, os.ErrNotExist) {
+        f, err = os.Open(filepath.Join(b.Path, prefix))
+    }
+    if errors.Is(err, os.ErrNotExist) {
+        // No range file means no known breached hash with this prefix
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    defer f.Close()
+    sc := bufio.NewScanner(f)
+    for sc.Scan() {
+        if h, breached := parseBreachedLine(sc.Text()); breached && strings.EqualFold(h, suffix) {
+            return true, nil
+        }
+    }
+    return false, sc.Err()
+}
+
+func (b *BreachedPasswordFile) lookupSorted(hash string) (bool, error) {
+    f, err := os.Open(b.Path)
+    if err != nil {
+        return false, err
+    }
+    defer f.Close()
+    st, err := f.Stat()
+    if err != nil {
+        return false, err
+    }
+    // Invariant: a matching line, if any, starts within [lo, hi)
+    lo, hi := int64(0), st.Size()
+    for lo < hi {
+        mid := lo + (hi-lo)/2
+        start, line, err := lineAt(f, mid)
+        if err != nil {
+            return false, err
+        }
+        if line == "" || start >= hi {
+            hi = mid
+            continue
+        }
+        h, breached := parseBreachedLine(line)
+        switch strings.Compare(strings.ToUpper(h), hash) {
+        case 0:
+            return breached, nil
+        case -1:
+            lo = start + int64(len(line)) + 1
+        default:
+            hi = mid
+        }
+    }
+    return false, nil
+}
+
+// lineAt returns the first line starting at or after off, with its offset, without the newline.
+// An empty line means end of file.
+func lineAt(f *os.File, off int64) (int64, string, error) {
+    start := off
+    if off > 0 {
+        start = off - 1
+    }
+    if _, err := f.Seek(start, io.SeekStart); err != nil {
+        return 0, "", err
+    }
+    r := bufio.NewReader(f)
+    if off > 0 {
+        // Skip the rest of the line containing off-1; if that byte is a newline a line starts at off
+        skipped, err := r.ReadString('\n')
+        if err == io.EOF {
+            return off, "", nil
+        }
+        if err != nil {
+            return 0, "", err
+        }
+        start += int64(len(skipped))
+    }
+    line, err := r.ReadString('\n')
+    if err != nil && err != io.EOF {
+        return 0, "", err
+    }
+    return start, strings.TrimSuffix(line, "\n"), nil
+}
+
+// parseBreachedLine splits "HASH:COUNT"; padding entries with a zero count are not breaches.
+func parseBreachedLine(line string) (string, bool) {
+    line = strings.TrimSpace(line)
+    h, count, _ := strings.Cut(line, ":")
+    return h, h != "" && count != "0"
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+    sum := sha1.Sum([]byte(password))
+    return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeSortedBreachFile writes "SHA1:COUNT" lines for passwords, sorted by hash, and returns
+// the passwords in file order.
+func writeSortedBreachFile(t *testing.T, passwords []string, newline string) (string, []string) {
+    t.Helper()
+    sorted := append([]string(nil), passwords...)
+    sort.Slice(sorted, func(i, j int) bool { return sha1Hex(sorted[i]) < sha1Hex(sorted[j]) })
+    var b strings.Builder
+    for i, p := range sorted {
+        fmt.Fprintf(&b, "%s:%d%s", sha1Hex(p), i+1, newline)
+    }
+    path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+    if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    return path, sorted
+}
+
+func openBreachFile(t *testing.T, path string) *BreachedPasswordFile {
+    t.Helper()
+    b, err := OpenBreachedPasswordFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return b
+}
+
+func TestBreachedPasswordSortedFile(t *testing.T) {
+    var passwords []string
+    for i := 0; i < 200; i++ {
+        passwords = append(passwords, fmt.Sprintf("password%d", i))
+    }
+    for _, newline := range []string{"\n", "\r\n"} {
+        path, sorted := writeSortedBreachFile(t, passwords, newline)
+        b := openBreachFile(t, path)
+        check := func(password string, want bool) {
+            t.Helper()
+            got, err := b.IsBreached(password)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != want {
+                t.Fatalf("IsBreached(%q) = %v, want %v (newline %q)", password, got, want, newline)
+            }
+        }
+        check(sorted[0], true)
+        check(sorted[len(sorted)-1], true)
+        // Every entry, so each position of the binary search is exercised
+        for _, p := range sorted {
+            check(p, true)
+        }
+        for i := 0; i < 200; i++ {
+            check(fmt.Sprintf("not-breached-%d", i), false)
+        }
+    }
+}
+
+func TestBreachedPasswordSortedFileEdges(t *testing.T) {
+    dir := t.TempDir()
+    write := func(name, content string) *BreachedPasswordFile {
+        path := filepath.Join(dir, name)
+        if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+            t.Fatal(err)
+        }
+        return openBreachFile(t, path)
+    }
+    hash := sha1Hex("hunter2")
+
+    if got, err := write("empty.txt", "").IsBreached("hunter2"); err != nil || got {
+        t.Fatalf("empty file: %v, %v", got, err)
+    }
+    if got, err := write("single.txt", hash+":17").IsBreached("hunter2"); err != nil || !got {
+        t.Fatalf("single line without newline: %v, %v", got, err)
+    }
+    if got, err := write("lower.txt", strings.ToLower(hash)+":17\n").IsBreached("hunter2"); err != nil || !got {
+        t.Fatalf("lowercase hash: %v, %v", got, err)
+    }
+    // Padding entries (count 0) are listed but are not breaches
+    if got, err := write("padding.txt", hash+":0\n").IsBreached("hunter2"); err != nil || got {
+        t.Fatalf("padding entry: %v, %v", got, err)
+    }
+}
+
+func TestBreachedPasswordRangeDirectory(t *testing.T) {
+    dir := t.TempDir()
+    hash := sha1Hex("hunter2")
+    other := sha1Hex("correct horse battery staple")
+    content := "0000000000000000000000000000000000A:3\r\n" + hash[5:] + ":17\r\n"
+    if err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(content), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    // Range files saved without an extension are accepted too
+    if err := os.WriteFile(filepath.Join(dir, other[:5]), []byte(other[5:]+":0\n"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    b := openBreachFile(t, dir)
+    tests := []struct {
+        password string
+        want     bool
+    }{
+        {"hunter2", true},
+        {"correct horse battery staple", false}, // padding entry
+        {"no range file for this one", false},
+    }
+    for _, tt := range tests {
+        got, err := b.IsBreached(tt.password)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got != tt.want {
+            t.Errorf("IsBreached(%q) = %v, want %v", tt.password, got, tt.want)
+        }
+    }
+}
+
+func TestOpenBreachedPasswordFileMissing(t *testing.T) {
+    if _, err := OpenBreachedPasswordFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+        t.Fatal("missing file opened without error")
+    }
+}
@@ -1,49 +1,259 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// JWTManager signs and verifies access tokens.
+// With SigningKey set tokens are signed asymmetrically and carry a "kid" header; otherwise
+// HS256 with Secret is used. Tokens without "kid" are still accepted via Secret when it is set,
+// and VerificationKeys lets previously active keys keep validating during rotation.
 type JWTManager struct {
     Secret         []byte
+    SigningKey     *JWTKey
+    // Public keys still accepted for verification (e.g. the previous signing key)
+    VerificationKeys []*JWTKey
     AccessTTL      time.Duration
     CookieName     string
     SecureCookies  bool
+    // Refresh tokens are opaque (not JWTs); the manager only carries their lifetime and cookie name.
+    RefreshTTL        time.Duration
+    RefreshCookieName string
+    // Readable cookie for the double-submit CSRF token; empty disables CSRF protection
+    CSRFCookieName string
 }
 
 type UserClaims struct {
+    UserID string `json:"uid"`
+    Email  string   `json:"email"`
+    Roles  []string `json:"roles,omitempty"`
+    EmailVerified bool `json:"email_verified"`
+    // Set while the user's role requires 2FA but no authenticator is enrolled yet
+    TwoFactorSetupRequired bool `json:"mfa_setup,omitempty"`
+    // Set while the user still has the temporary password an admin gave them
+    PasswordChangeRequired bool `json:"pwd_change,omitempty"`
+    // Session (refresh token family) the token was issued for
+    SessionID string `json:"sid,omitempty"`
+    // Admin acting as this user (impersonation); nil for the user's own tokens
+    Actor *TokenActor `json:"act,omitempty"`
+    // Set only for requests authenticated with an API key (never part of a JWT)
+    APIKeyID string   `json:"-"`
+    Scopes   []string `json:"-"`
+    jwt.RegisteredClaims
+}
+
+// TokenActor identifies who really holds an impersonation token (the RFC 8693 "act" claim).
+type TokenActor struct {
     UserID string `json:"uid"`
     Email  string `json:"email"`
+}
+
+// ChallengeClaims are carried by short-lived, single-purpose tokens (e.g. the 2FA login step).
+// The purpose is stored as the audience, which access tokens never have.
+type ChallengeClaims struct {
+    UserID string `json:"uid"`
     jwt.RegisteredClaims
 }
 
-func (m *JWTManager) GenerateToken(userID, email string) (string, time.Time, error) {
+// TokenSubject is the identity embedded in an access token.
+type TokenSubject struct {
+    UserID        string
+    Email         string
+    Roles         []string
+    EmailVerified bool
+    TwoFactorSetupRequired bool
+    PasswordChangeRequired bool
+    SessionID     string
+    Actor         *TokenActor
+}
+
+// HasAnyRole reports whether the claims carry at least one of the given roles.
+func (c *UserClaims) HasAnyRole(roles ...string) bool {
+    for _, have := range c.Roles {
+        for _, want := range roles {
+            if have == want {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// Impersonated reports whether the token was issued to an admin acting as the user.
+func (c *UserClaims) Impersonated() bool {
+    return c.Actor != nil
+}
+
+// SessionRevocationKey is the key under which a revoked session is stored next to revoked jtis.
+func SessionRevocationKey(sessionID string) string {
+    return "sid:" + sessionID
+}
+
+// HasScope reports whether an API key caller was granted scope; session tokens are not scoped.
+func (c *UserClaims) HasScope(scope string) bool {
+    if c.APIKeyID == "" {
+        return true
+    }
+    for _, s := range c.Scopes {
+        if s == scope {
+            return true
+        }
+    }
+    return false
+}
+
+func (m *JWTManager) GenerateToken(sub TokenSubject) (string, time.Time, error) {
+    return m.generateToken(sub, m.AccessTTL)
+}
+
+// GenerateImpersonationToken issues an access token for sub held by actor, valid for ttl.
+// It carries no session, so it cannot be refreshed.
+func (m *JWTManager) GenerateImpersonationToken(sub TokenSubject, actor TokenActor, ttl time.Duration) (string, time.Time, error) {
+    sub.Actor = &actor
+    sub.SessionID = ""
+    return m.generateToken(sub, ttl)
+}
+
+func (m *JWTManager) generateToken(sub TokenSubject, ttl time.Duration) (string, time.Time, error) {
     now := time.Now()
-    exp := now.Add(m.AccessTTL)
+    exp := now.Add(ttl)
     claims := UserClaims{
-        UserID: userID,
-        Email:  email,
+        UserID: sub.UserID,
+        Email:  sub.Email,
+        Roles:  sub.Roles,
+        EmailVerified: sub.EmailVerified,
+        TwoFactorSetupRequired: sub.TwoFactorSetupRequired,
+        PasswordChangeRequired: sub.PasswordChangeRequired,
+        SessionID: sub.SessionID,
+        Actor: sub.Actor,
         RegisteredClaims: jwt.RegisteredClaims{
+            ID:        uuid.NewString(),
             ExpiresAt: jwt.NewNumericDate(exp),
             IssuedAt:  jwt.NewNumericDate(now),
         },
     }
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    s, err := token.SignedString(m.Secret)
+    s, err := m.sign(claims)
     return s, exp, err
 }
 
 func (m *JWTManager) ParseToken(tokenStr string) (*UserClaims, error) {
-    token, err := jwt.ParseWithClaims(tokenStr, &UserClaims{}, func(t *jwt.Token) (interface{}, error) {
-        return m.Secret, nil
-    })
+    claims := &UserClaims{}
+    if err := m.parse(tokenStr, claims); err != nil {
+        return nil, err
+    }
+    // Challenge tokens carry an audience; they must never pass as access tokens
+    if len(claims.Audience) > 0 {
+        return nil, jwt.ErrTokenInvalidAudience
+    }
+    return claims, nil
+}
+
+// GenerateChallengeToken issues a short-lived token usable only for the given purpose.
+func (m *JWTManager) GenerateChallengeToken(userID, purpose string, ttl time.Duration) (string, time.Time, error) {
+    now := time.Now()
+    exp := now.Add(ttl)
+    claims := ChallengeClaims{
+        UserID: userID,
+        RegisteredClaims: jwt.RegisteredClaims{
+            ID:        uuid.NewString(),
+            Audience:  jwt.ClaimStrings{purpose},
+            ExpiresAt: jwt.NewNumericDate(exp),
+            IssuedAt:  jwt.NewNumericDate(now),
+        },
+    }
+    s, err := m.sign(claims)
+    return s, exp, err
+}
+
+// ParseChallengeToken validates a challenge token for the expected purpose.
+func (m *JWTManager) ParseChallengeToken(tokenStr, purpose string) (*ChallengeClaims, error) {
+    claims := &ChallengeClaims{}
+    token, err := jwt.ParseWithClaims(tokenStr, claims, m.keyFunc, jwt.WithValidMethods(m.validMethods()), jwt.WithAudience(purpose))
     if err != nil {
         return nil, err
     }
-    if claims, ok := token.Claims.(*UserClaims); ok && token.Valid {
-        return claims, nil
+    if !token.Valid || claims.UserID == "" {
+        return nil, jwt.ErrTokenInvalidClaims
+    }
+    return claims, nil
+}
+
+// JWKS returns the public keys currently accepted for verification.
+func (m *JWTManager) JWKS() (JWKS, error) {
+    set := JWKS{Keys: []JWK{}}
+    for _, k := range m.keys() {
+        jwk, err := k.JWK()
+        if err != nil {
+            return JWKS{}, err
+        }
+        set.Keys = append(set.Keys, jwk)
+    }
+    return set, nil
+}
+
+func (m *JWTManager) sign(claims jwt.Claims) (string, error) {
+    if m.SigningKey != nil {
+        token := jwt.NewWithClaims(m.SigningKey.Method, claims)
+        token.Header["kid"] = m.SigningKey.ID
+        return token.SignedString(m.SigningKey.Private)
+    }
+    if len(m.Secret) == 0 {
+        return "", errors.New("jwt: no signing key configured")
+    }
+    return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.Secret)
+}
+
+func (m *JWTManager) parse(tokenStr string, claims jwt.Claims) error {
+    token, err := jwt.ParseWithClaims(tokenStr, claims, m.keyFunc, jwt.WithValidMethods(m.validMethods()))
+    if err != nil {
+        return err
+    }
+    if !token.Valid {
+        return jwt.ErrTokenInvalidClaims
+    }
+    return nil
+}
+
+// keyFunc picks the verification key by "kid"; the algorithm must match the key's own.
+func (m *JWTManager) keyFunc(t *jwt.Token) (interface{}, error) {
+    kid, _ := t.Header["kid"].(string)
+    if kid == "" {
+        if len(m.Secret) == 0 || t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+            return nil, jwt.ErrTokenUnverifiable
+        }
+        return m.Secret, nil
+    }
+    for _, k := range m.keys() {
+        if k.ID == kid {
+            if t.Method.Alg() != k.Method.Alg() {
+                return nil, jwt.ErrTokenSignatureInvalid
+            }
+            return k.Public, nil
+        }
+    }
+    return nil, fmt.Errorf("%w: unknown kid %q", jwt.ErrTokenUnverifiable, kid)
+}
+
+func (m *JWTManager) keys() []*JWTKey {
+    var out []*JWTKey
+    if m.SigningKey != nil {
+        out = append(out, m.SigningKey)
+    }
+    return append(out, m.VerificationKeys...)
+}
+
+func (m *JWTManager) validMethods() []string {
+    var out []string
+    if len(m.Secret) > 0 {
+        out = append(out, jwt.SigningMethodHS256.Alg())
+    }
+    for _, k := range m.keys() {
+        out = append(out, k.Method.Alg())
     }
-    return nil, jwt.ErrTokenInvalidClaims
+    return out
 }
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTKey is an asymmetric key used to sign or verify access tokens.
+// Private is nil for verification-only keys (e.g. keys rotated out but still accepted).
+type JWTKey struct {
+    ID      string
+    Method  jwt.SigningMethod
+    Private crypto.PrivateKey
+    Public  crypto.PublicKey
+}
+
+// JWK is the public part of a JWTKey as published in the JWKS document (RFC 7517).
+type JWK struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    Alg string `json:"alg"`
+    N   string `json:"n,omitempty"`
+    E   string `json:"e,omitempty"`
+    Crv string `json:"crv,omitempty"`
+    X   string `json:"x,omitempty"`
+    Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+    Keys []JWK `json:"keys"`
+}
+
+// LoadJWTKeyFile reads a PEM key (private or public) from disk; see ParseJWTKeyPEM.
+func LoadJWTKeyFile(kid, path string) (*JWTKey, error) {
+    b, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    k, err := ParseJWTKeyPEM(kid, b)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    return k, nil
+}
+
+// ParseJWTKeyPEM parses an RSA, ECDSA (P-256/P-384/P-521) or Ed25519 key and picks the matching
+// algorithm (RS256, ES256/ES384/ES512, EdDSA). An empty kid is derived from the public key.
+func ParseJWTKeyPEM(kid string, data []byte) (*JWTKey, error) {
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, errors.New("no PEM block found")
+    }
+    var priv crypto.PrivateKey
+    var pub crypto.PublicKey
+    switch block.Type {
+    case "RSA PRIVATE KEY":
+        k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+        if err != nil {
+            return nil, err
+        }
+        priv = k
+    case "EC PRIVATE KEY":
+        k, err := x509.ParseECPrivateKey(block.Bytes)
+        if err != nil {
+            return nil, err
+        }
+        priv = k
+    case "PRIVATE KEY":
+        k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+        if err != nil {
+            return nil, err
+        }
+        priv = k
+    case "PUBLIC KEY":
+        k, err := x509.ParsePKIXPublicKey(block.Bytes)
+        if err != nil {
+            return nil, err
+        }
+        pub = k
+    case "RSA PUBLIC KEY":
+        k, err := x509.ParsePKCS1PublicKey(block.Bytes)
+        if err != nil {
+            return nil, err
+        }
+        pub = k
+    case "CERTIFICATE":
+        cert, err := x509.ParseCertificate(block.Bytes)
+        if err != nil {
+            return nil, err
+        }
+        pub = cert.PublicKey
+    default:
+        return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+    }
+    if priv != nil {
+        signer, ok := priv.(crypto.Signer)
+        if !ok {
+            return nil, errors.New("unsupported private key type")
+        }
+        pub = signer.Public()
+    }
+    return NewJWTKey(kid, priv, pub)
+}
+
+// NewJWTKey builds a JWTKey from already parsed keys; priv may be nil.
+func NewJWTKey(kid string, priv crypto.PrivateKey, pub crypto.PublicKey) (*JWTKey, error) {
+    var method jwt.SigningMethod
+    switch p := pub.(type) {
+    case *rsa.PublicKey:
+        method = jwt.SigningMethodRS256
+    case *ecdsa.PublicKey:
+        switch p.Curve {
+        case elliptic.P256():
+            method = jwt.SigningMethodES256
+        case elliptic.P384():
+            method = jwt.SigningMethodES384
+        case elliptic.P521():
+            method = jwt.SigningMethodES512
+        default:
+            return nil, errors.New("unsupported elliptic curve")
+        }
+    case ed25519.PublicKey:
+        method = jwt.SigningMethodEdDSA
+    default:
+        return nil, fmt.Errorf("unsupported public key type %T", pub)
+    }
+    if kid == "" {
+        der, err := x509.MarshalPKIXPublicKey(pub)
+        if err != nil {
+            return nil, err
+        }
+        sum := sha256.Sum256(der)
+        kid = base64.RawURLEncoding.EncodeToString(sum[:12])
+    }
+    return &JWTKey{ID: kid, Method: method, Private: priv, Public: pub}, nil
+}
+
+// JWK returns the public JSON Web Key for k.
+func (k *JWTKey) JWK() (JWK, error) {
+    out := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+    switch p := k.Public.(type) {
+    case *rsa.PublicKey:
+        out.Kty = "RSA"
+        out.N = b64(p.N.Bytes())
+        out.E = b64(big.NewInt(int64(p.E)).Bytes())
+    case *ecdsa.PublicKey:
+        ec, err := p.ECDH()
+        if err != nil {
+            return JWK{}, err
+        }
+        // Uncompressed point: 0x04 || X || Y, coordinates padded to the curve size
+        raw := ec.Bytes()
+        size := (len(raw) - 1) / 2
+        out.Kty = "EC"
+        out.Crv = p.Curve.Params().Name
+        out.X = b64(raw[1 : 1+size])
+        out.Y = b64(raw[1+size:])
+    case ed25519.PublicKey:
+        out.Kty = "OKP"
+        out.Crv = "Ed25519"
+        out.X = b64(p)
+    default:
+        return JWK{}, fmt.Errorf("unsupported public key type %T", k.Public)
+    }
+    return out, nil
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+// JWTKey parses a public JWK (e.g. from an identity provider's JWKS) into a verification-only key.
+func (j JWK) JWTKey() (*JWTKey, error) {
+    var pub crypto.PublicKey
+    switch j.Kty {
+    case "RSA":
+        n, err := base64.RawURLEncoding.DecodeString(j.N)
+        if err != nil {
+            return nil, fmt.Errorf("jwk %s: invalid n: %w", j.Kid, err)
+        }
+        e, err := base64.RawURLEncoding.DecodeString(j.E)
+        if err != nil || len(e) == 0 || len(e) > 4 {
+            return nil, fmt.Errorf("jwk %s: invalid e", j.Kid)
+        }
+        pub = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+    case "EC":
+        var curve elliptic.Curve
+        var check ecdh.Curve
+        switch j.Crv {
+        case "P-256":
+            curve, check = elliptic.P256(), ecdh.P256()
+        case "P-384":
+            curve, check = elliptic.P384(), ecdh.P384()
+        case "P-521":
+            curve, check = elliptic.P521(), ecdh.P521()
+        default:
+            return nil, fmt.Errorf("jwk %s: unsupported curve %q", j.Kid, j.Crv)
+        }
+        x, errX := base64.RawURLEncoding.DecodeString(j.X)
+        y, errY := base64.RawURLEncoding.DecodeString(j.Y)
+        if errX != nil || errY != nil {
+            return nil, fmt.Errorf("jwk %s: invalid coordinates", j.Kid)
+        }
+        size := (curve.Params().BitSize + 7) / 8
+        if len(x) != size || len(y) != size {
+            return nil, fmt.Errorf("jwk %s: invalid coordinate length", j.Kid)
+        }
+        // crypto/ecdh rejects points that are not on the curve
+        if _, err := check.NewPublicKey(append([]byte{4}, append(x, y...)...)); err != nil {
+            return nil, fmt.Errorf("jwk %s: %w", j.Kid, err)
+        }
+        pub = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+    case "OKP":
+        if j.Crv != "Ed25519" {
+            return nil, fmt.Errorf("jwk %s: unsupported curve %q", j.Kid, j.Crv)
+        }
+        x, err := base64.RawURLEncoding.DecodeString(j.X)
+        if err != nil || len(x) != ed25519.PublicKeySize {
+            return nil, fmt.Errorf("jwk %s: invalid x", j.Kid)
+        }
+        pub = ed25519.PublicKey(x)
+    default:
+        return nil, fmt.Errorf("jwk %s: unsupported key type %q", j.Kid, j.Kty)
+    }
+    return NewJWTKey(j.Kid, nil, pub)
+}
@@ -1,15 +1,265 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
 
-func HashPassword(pw string) (string, error) {
-    b, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.DefaultCost)
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+    PasswordAlgorithmBcrypt   = "bcrypt"
+    PasswordAlgorithmArgon2id = "argon2id"
+)
+
+// PasswordHasher hashes passwords into self-describing strings: the algorithm and its
+// parameters are encoded in the hash, so stored hashes stay verifiable after the
+// configuration changes.
+type PasswordHasher interface {
+    Hash(plain string) (string, error)
+    Verify(hashed, plain string) bool
+    // Recognizes reports whether hashed was produced by this algorithm.
+    Recognizes(hashed string) bool
+    // NeedsRehash reports whether hashed should be replaced by a fresh Hash of the same password.
+    NeedsRehash(hashed string) bool
+}
+
+// BcryptHasher produces standard bcrypt hashes ($2a$<cost>$...), the format used before argon2id.
+type BcryptHasher struct {
+    Cost int
+}
+
+func (h BcryptHasher) Hash(plain string) (string, error) {
+    b, err := bcrypt.GenerateFromPassword([]byte(plain), h.cost())
     if err != nil {
         return "", err
     }
     return string(b), nil
 }
 
-func CheckPassword(hashed, plain string) bool {
+func (h BcryptHasher) Verify(hashed, plain string) bool {
     return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(plain)) == nil
 }
+
+func (h BcryptHasher) Recognizes(hashed string) bool {
+    return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+
+func (h BcryptHasher) NeedsRehash(hashed string) bool {
+    cost, err := bcrypt.Cost([]byte(hashed))
+    return err != nil || cost != h.cost()
+}
+
+func (h BcryptHasher) cost() int {
+    if h.Cost == 0 {
+        return bcrypt.DefaultCost
+    }
+    return h.Cost
+}
+
+// Argon2idHasher produces PHC strings: $argon2id$v=19$m=<KiB>,t=<iterations>,p=<lanes>$<salt>$<key>.
+type Argon2idHasher struct {
+    Memory      uint32 // KiB
+    Iterations  uint32
+    Parallelism uint8
+    SaltLength  uint32
+    KeyLength   uint32
+}
+
+// NewArgon2idHasher uses a 16-byte salt and a 32-byte key; zero parameters fall back to the
+// OWASP baseline (19 MiB, 2 iterations, 1 lane).
+func NewArgon2idHasher(memoryKiB, iterations uint32, parallelism uint8) Argon2idHasher {
+    if memoryKiB == 0 {
+        memoryKiB = 19 * 1024
+    }
+    if iterations == 0 {
+        iterations = 2
+    }
+    if parallelism == 0 {
+        parallelism = 1
+    }
+    return Argon2idHasher{Memory: memoryKiB, Iterations: iterations, Parallelism: parallelism, SaltLength: 16, KeyLength: 32}
+}
+
+// argon2idParams is the decoded form of a stored argon2id hash.
+type argon2idParams struct {
+    version     int
+    memory      uint32
+    iterations  uint32
+    parallelism uint8
+    salt        []byte
+    key         []byte
+}
+
+func (h Argon2idHasher) Hash(plain string) (string, error) {
+    salt := make([]byte, h.SaltLength)
+    if _, err := rand.Read(salt); err != nil {
+        return "", err
+    }
+    key := argon2.IDKey([]byte(plain), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+    return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+        base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h Argon2idHasher) Verify(hashed, plain string) bool {
+    p, err := parseArgon2id(hashed)
+    if err != nil || p.version != argon2.Version {
+        return false
+    }
+    key := argon2.IDKey([]byte(plain), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+    return subtle.ConstantTimeCompare(key, p.key) == 1
+}
+
+func (h Argon2idHasher) Recognizes(hashed string) bool {
+    return strings.HasPrefix(hashed, "$argon2id$")
+}
+
+func (h Argon2idHasher) NeedsRehash(hashed string) bool {
+    p, err := parseArgon2id(hashed)
+    if err != nil {
+        return true
+    }
+    return p.version != argon2.Version || p.memory != h.Memory || p.iterations != h.Iterations ||
+        p.parallelism != h.Parallelism || uint32(len(p.salt)) != h.SaltLength || uint32(len(p.key)) != h.KeyLength
+}
+
+func parseArgon2id(hashed string) (*argon2idParams, error) {
+    parts := strings.Split(hashed, "$")
+    if len(parts) != 6 || parts[0] != "" || parts[1] != PasswordAlgorithmArgon2id {
+        return nil, fmt.Errorf("not an argon2id hash")
+    }
+    var p argon2idParams
+    if _, err := fmt.Sscanf(parts[2], "v=%d", &p.version); err != nil {
+        return nil, fmt.Errorf("argon2id: invalid version: %w", err)
+    }
+    if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+        return nil, fmt.Errorf("argon2id: invalid parameters: %w", err)
+    }
+    // Limits guard against a tampered hash turning a login into a memory/CPU bomb
+    if p.memory == 0 || p.memory > 4*1024*1024 || p.iterations == 0 || p.iterations > 64 || p.parallelism == 0 {
+        return nil, fmt.Errorf("argon2id: parameters out of range")
+    }
+    var err error
+    if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+        return nil, fmt.Errorf("argon2id: invalid salt: %w", err)
+    }
+    if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(p.key) == 0 {
+        return nil, fmt.Errorf("argon2id: invalid key")
+    }
+    return &p, nil
+}
+
+// MultiHasher hashes with Preferred and verifies hashes of any of the supported algorithms.
+// A hash from another algorithm always needs a rehash, which migrates users on their next login.
+type MultiHasher struct {
+    Preferred PasswordHasher
+    Others    []PasswordHasher
+}
+
+func NewMultiHasher(preferred PasswordHasher, others ...PasswordHasher) *MultiHasher {
+    return &MultiHasher{Preferred: preferred, Others: others}
+}
+
+// NewPasswordHasher builds the application hasher: algorithm (bcrypt or argon2id) is used for
+// new hashes, the other one is still accepted for verification.
+func NewPasswordHasher(algorithm string, bcryptHasher BcryptHasher, argonHasher Argon2idHasher) (*MultiHasher, error) {
+    switch algorithm {
+    case PasswordAlgorithmArgon2id:
+        return NewMultiHasher(argonHasher, bcryptHasher), nil
+    case PasswordAlgorithmBcrypt:
+        return NewMultiHasher(bcryptHasher, argonHasher), nil
+    default:
+        return nil, fmt.Errorf("unsupported password hash algorithm %q", algorithm)
+    }
+}
+
+// DefaultPasswordHasher keeps bcrypt at the default cost for new hashes and accepts argon2id.
+func DefaultPasswordHasher() *MultiHasher {
+    return NewMultiHasher(BcryptHasher{Cost: bcrypt.DefaultCost}, NewArgon2idHasher(0, 0, 0))
+}
+
+func (m *MultiHasher) Hash(plain string) (string, error) {
+    return m.Preferred.Hash(plain)
+}
+
+func (m *MultiHasher) Verify(hashed, plain string) bool {
+    if h := m.hasherFor(hashed); h != nil {
+        return h.Verify(hashed, plain)
+    }
+    return false
+}
+
+func (m *MultiHasher) Recognizes(hashed string) bool {
+    return m.hasherFor(hashed) != nil
+}
+
+func (m *MultiHasher) NeedsRehash(hashed string) bool {
+    if !m.Preferred.Recognizes(hashed) {
+        return true
+    }
+    return m.Preferred.NeedsRehash(hashed)
+}
+
+func (m *MultiHasher) hasherFor(hashed string) PasswordHasher {
+    if m.Preferred.Recognizes(hashed) {
+        return m.Preferred
+    }
+    for _, h := range m.Others {
+        if h.Recognizes(hashed) {
+            return h
+        }
+    }
+    return nil
+}
+
+// Character classes for temporary passwords; look-alikes (0/O, 1/l/I) are left out.
+const (
+    tempPasswordUpper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+    tempPasswordLower  = "abcdefghijkmnopqrstuvwxyz"
+    tempPasswordDigits = "23456789"
+    tempPasswordSymbol = "!@#$%&*?-_"
+)
+
+// GenerateTemporaryPassword returns a random password of the given length (at least 4) with at
+// least one uppercase letter, lowercase letter, digit and symbol, so it passes the usual policies.
+func GenerateTemporaryPassword(length int) (string, error) {
+    if length < 4 {
+        length = 4
+    }
+    classes := []string{tempPasswordUpper, tempPasswordLower, tempPasswordDigits, tempPasswordSymbol}
+    all := strings.Join(classes, "")
+    out := make([]byte, 0, length)
+    for i := 0; i < length; i++ {
+        set := all
+        if i < len(classes) {
+            set = classes[i]
+        }
+        c, err := randomIndex(len(set))
+        if err != nil {
+            return "", err
+        }
+        out = append(out, set[c])
+    }
+    // Fisher-Yates, so the guaranteed classes are not always at the start
+    for i := len(out) - 1; i > 0; i-- {
+        j, err := randomIndex(i + 1)
+        if err != nil {
+            return "", err
+        }
+        out[i], out[j] = out[j], out[i]
+    }
+    return string(out), nil
+}
+
+func randomIndex(n int) (int, error) {
+    v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+    if err != nil {
+        return 0, err
+    }
+    return int(v.Int64()), nil
+}
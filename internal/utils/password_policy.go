@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy describes the rules a new password must satisfy. Zero values disable a rule.
+type PasswordPolicy struct {
+    MinLength     int
+    MaxLength     int
+    RequireUpper  bool
+    RequireLower  bool
+    RequireDigit  bool
+    RequireSymbol bool
+    // MaxRepeated limits runs of the same character ("aaaa" is a run of 4).
+    MaxRepeated int
+    // DisallowPersonalInfo rejects passwords that contain the user's name or email.
+    DisallowPersonalInfo bool
+    // MinEntropyBits is a lower bound for PasswordEntropy.
+    MinEntropyBits float64
+}
+
+// DefaultPasswordPolicy keeps the historical rules (8+ characters, an uppercase letter and a digit)
+// and rejects passwords built from the user's name or email.
+func DefaultPasswordPolicy() PasswordPolicy {
+    return PasswordPolicy{
+        MinLength:            8,
+        MaxLength:            128,
+        RequireUpper:         true,
+        RequireDigit:         true,
+        DisallowPersonalInfo: true,
+    }
+}
+
+// Validate returns one message per violated rule. personal holds the user's name and email
+// (either may be empty); they only matter with DisallowPersonalInfo.
+func (p PasswordPolicy) Validate(password string, personal ...string) []string {
+    var errs []string
+    length := len([]rune(password))
+    if p.MinLength > 0 && length < p.MinLength {
+        errs = append(errs, fmt.Sprintf("password must be at least %d characters", p.MinLength))
+    }
+    if p.MaxLength > 0 && length > p.MaxLength {
+        errs = append(errs, fmt.Sprintf("password cannot exceed %d characters", p.MaxLength))
+    }
+    var upper, lower, digit, symbol bool
+    for _, r := range password {
+        switch {
+        case unicode.IsUpper(r):
+            upper = true
+        case unicode.IsLower(r):
+            lower = true
+        case unicode.IsDigit(r):
+            digit = true
+        default:
+            symbol = true
+        }
+    }
+    if p.RequireUpper && !upper {
+        errs = append(errs, "password must contain at least one uppercase letter")
+    }
+    if p.RequireLower && !lower {
+        errs = append(errs, "password must contain at least one lowercase letter")
+    }
+    if p.RequireDigit && !digit {
+        errs = append(errs, "password must contain at least one digit")
+    }
+    if p.RequireSymbol && !symbol {
+        errs = append(errs, "password must contain at least one symbol")
+    }
+    if p.MaxRepeated > 0 && longestRun(password) > p.MaxRepeated {
+        errs = append(errs, fmt.Sprintf("password cannot repeat the same character more than %d times in a row", p.MaxRepeated))
+    }
+    if p.DisallowPersonalInfo && containsPersonalInfo(password, personal) {
+        errs = append(errs, "password must not contain your name or email")
+    }
+    if p.MinEntropyBits > 0 && PasswordEntropy(password) < p.MinEntropyBits {
+        errs = append(errs, "password is too easy to guess; use a longer or more varied password")
+    }
+    return errs
+}
+
+// PasswordEntropy is a rough strength estimate in bits: the size of the character pool the
+// password draws from, counted only for characters that do not merely repeat or continue a
+// sequence of the previous one ("aaaa", "1234" and "abcd" add almost nothing).
+func PasswordEntropy(password string) float64 {
+    var pool float64
+    var upper, lower, digit, symbol, other bool
+    effective := 0
+    var prev rune = -1
+    for _, r := range password {
+        switch {
+        case r >= 'A' && r <= 'Z':
+            upper = true
+        case r >= 'a' && r <= 'z':
+            lower = true
+        case r >= '0' && r <= '9':
+            digit = true
+        case r < unicode.MaxASCII && unicode.IsPrint(r):
+            symbol = true
+        default:
+            other = true
+        }
+        if prev < 0 || (r != prev && r != prev+1 && r != prev-1) {
+            effective++
+        }
+        prev = r
+    }
+    if upper {
+        pool += 26
+    }
+    if lower {
+        pool += 26
+    }
+    if digit {
+        pool += 10
+    }
+    if symbol {
+        pool += 33
+    }
+    if other {
+        pool += 100
+    }
+    if pool == 0 {
+        return 0
+    }
+    return float64(effective) * math.Log2(pool)
+}
+
+func longestRun(s string) int {
+    longest, run := 0, 0
+    var prev rune = -1
+    for _, r := range s {
+        if r == prev {
+            run++
+        } else {
+            run = 1
+        }
+        if run > longest {
+            longest = run
+        }
+        prev = r
+    }
+    return longest
+}
+
+// containsPersonalInfo checks the email's local part and each name/email fragment of 3+ characters.
+func containsPersonalInfo(password string, personal []string) bool {
+    pw := strings.ToLower(password)
+    for _, p := range personal {
+        p = strings.ToLower(strings.TrimSpace(p))
+        if p == "" {
+            continue
+        }
+        if at := strings.LastIndex(p, "@"); at >= 0 {
+            p = p[:at]
+        }
+        parts := strings.FieldsFunc(p, func(r rune) bool {
+            return unicode.IsSpace(r) || r == '.' || r == '_' || r == '-' || r == '+'
+        })
+        for _, part := range append(parts, p) {
+            if len([]rune(part)) >= 3 && strings.Contains(pw, part) {
+                return true
+            }
+        }
+    }
+    return false
+}
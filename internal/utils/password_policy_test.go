@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyLengthBoundaries(t *testing.T) {
+    p := PasswordPolicy{MinLength: 8, MaxLength: 12}
+    tests := []struct {
+        password string
+        ok       bool
+    }{
+        {"1234567", false},
+        {"12345678", true},
+        {"123456789012", true},
+        {"1234567890123", false},
+        // Length is counted in characters, not bytes
+        {"ăîșțâăîș", true},
+        {"ăîșțâăî", false},
+    }
+    for _, tt := range tests {
+        if errs := p.Validate(tt.password); (len(errs) == 0) != tt.ok {
+            t.Errorf("Validate(%q) = %v, want ok=%v", tt.password, errs, tt.ok)
+        }
+    }
+}
+
+func TestPasswordPolicyCharacterClasses(t *testing.T) {
+    p := PasswordPolicy{RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+    if errs := p.Validate("Abc1!"); len(errs) != 0 {
+        t.Fatalf("Validate = %v, want no errors", errs)
+    }
+    if errs := p.Validate(""); len(errs) != 4 {
+        t.Fatalf("empty password: %d errors, want one per class: %v", len(errs), errs)
+    }
+    if errs := p.Validate("ABC1!"); len(errs) != 1 || !strings.Contains(errs[0], "lowercase") {
+        t.Fatalf("Validate = %v, want only the lowercase rule", errs)
+    }
+}
+
+func TestPasswordPolicyMaxRepeated(t *testing.T) {
+    p := PasswordPolicy{MaxRepeated: 3}
+    if errs := p.Validate("xaaay"); len(errs) != 0 {
+        t.Fatalf("run of 3: %v", errs)
+    }
+    if errs := p.Validate("xaaaay"); len(errs) != 1 {
+        t.Fatalf("run of 4: %v, want one error", errs)
+    }
+}
+
+func TestPasswordPolicyPersonalInfo(t *testing.T) {
+    p := PasswordPolicy{DisallowPersonalInfo: true}
+    tests := []struct {
+        password string
+        ok       bool
+    }{
+        {"Popescu2024", false},        // surname
+        {"xXion.popescuXx", false},    // email local part
+        {"ionelPass", false},          // "ion" from the name
+        {"io-secure-99", true},        // fragments under 3 characters are ignored
+        {"example.com-rocks", true},   // the email domain is not personal
+        {"totally-unrelated", true},
+    }
+    for _, tt := range tests {
+        errs := p.Validate(tt.password, "Ion Popescu", "ion.popescu@example.com")
+        if (len(errs) == 0) != tt.ok {
+            t.Errorf("Validate(%q) = %v, want ok=%v", tt.password, errs, tt.ok)
+        }
+    }
+    if errs := p.Validate("Popescu2024"); len(errs) != 0 {
+        t.Fatalf("no personal info given: %v", errs)
+    }
+}
+
+func TestPasswordEntropy(t *testing.T) {
+    tests := []struct {
+        password string
+        want     float64
+    }{
+        {"", 0},
+        {"a", math.Log2(26)},
+        // Repeats and sequences count once
+        {"aaaaaaaa", math.Log2(26)},
+        {"abcdefgh", math.Log2(26)},
+        {"12345678", math.Log2(10)},
+        {"hgfedcba", math.Log2(26)},
+        {"aceg", 4 * math.Log2(26)},
+        {"aZ9!", 4 * math.Log2(26+26+10+33)},
+        {"ș", math.Log2(100)},
+    }
+    for _, tt := range tests {
+        if got := PasswordEntropy(tt.password); math.Abs(got-tt.want) > 1e-9 {
+            t.Errorf("PasswordEntropy(%q) = %.3f, want %.3f", tt.password, got, tt.want)
+        }
+    }
+}
+
+func TestPasswordPolicyMinEntropyBoundary(t *testing.T) {
+    p := PasswordPolicy{MinEntropyBits: 2 * math.Log2(26)}
+    if errs := p.Validate("ac"); len(errs) != 0 {
+        t.Fatalf("entropy exactly at the minimum: %v", errs)
+    }
+    if errs := p.Validate("ab"); len(errs) != 1 {
+        t.Fatalf("sequence below the minimum: %v, want one error", errs)
+    }
+}
+
+func TestDefaultPasswordPolicy(t *testing.T) {
+    p := DefaultPasswordPolicy()
+    if errs := p.Validate("Secret123"); len(errs) != 0 {
+        t.Fatalf("Validate = %v", errs)
+    }
+    if errs := p.Validate("secret123"); len(errs) != 1 {
+        t.Fatalf("no uppercase: %v, want one error", errs)
+    }
+    if errs := p.Validate(strings.Repeat("Ab1", 43)); len(errs) != 1 {
+        t.Fatalf("129 characters: %v, want one error", errs)
+    }
+}
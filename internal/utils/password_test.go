@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2id keeps the tests fast; production parameters come from NewArgon2idHasher.
+func testArgon2id(memoryKiB, iterations uint32) Argon2idHasher {
+    return Argon2idHasher{Memory: memoryKiB, Iterations: iterations, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func TestArgon2idHashAndVerify(t *testing.T) {
+    h := testArgon2id(64, 1)
+    hashed, err := h.Hash("correct horse battery staple")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.HasPrefix(hashed, "$argon2id$v=19$m=64,t=1,p=1$") {
+        t.Fatalf("unexpected PHC string %q", hashed)
+    }
+    if !h.Verify(hashed, "correct horse battery staple") {
+        t.Fatal("correct password rejected")
+    }
+    if h.Verify(hashed, "correct horse battery stapler") {
+        t.Fatal("wrong password accepted")
+    }
+    other, _ := h.Hash("correct horse battery staple")
+    if other == hashed {
+        t.Fatal("two hashes of the same password share a salt")
+    }
+}
+
+func TestParseArgon2idRejectsMalformedHashes(t *testing.T) {
+    valid, err := testArgon2id(64, 1).Hash("password")
+    if err != nil {
+        t.Fatal(err)
+    }
+    parts := strings.Split(valid, "$")
+    salt, key := parts[4], parts[5]
+    tests := map[string]string{
+        "empty":                "",
+        "only separators":      "$$$$$",
+        "bcrypt":               "$2a$10$abcdefghijklmnopqrstuuN9cD2zT2b7pOa0C1xKq4f5vQ6rW7yXe",
+        "argon2i":              "$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + key,
+        "missing key":          "$argon2id$v=19$m=64,t=1,p=1$" + salt,
+        "extra field":          valid + "$extra",
+        "no version":           "$argon2id$m=64,t=1,p=1$" + salt + "$" + key + "$",
+        "bad version":          "$argon2id$v=x$m=64,t=1,p=1$" + salt + "$" + key,
+        "bad parameters":       "$argon2id$v=19$m=64;t=1;p=1$" + salt + "$" + key,
+        "negative memory":      "$argon2id$v=19$m=-64,t=1,p=1$" + salt + "$" + key,
+        "zero memory":          "$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key,
+        "huge memory":          "$argon2id$v=19$m=4194305,t=1,p=1$" + salt + "$" + key,
+        "memory overflow":      "$argon2id$v=19$m=99999999999,t=1,p=1$" + salt + "$" + key,
+        "zero iterations":      "$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+        "too many iterations":  "$argon2id$v=19$m=64,t=65,p=1$" + salt + "$" + key,
+        "zero parallelism":     "$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+        "parallelism overflow": "$argon2id$v=19$m=64,t=1,p=256$" + salt + "$" + key,
+        "bad salt":             "$argon2id$v=19$m=64,t=1,p=1$!!!$" + key,
+        "bad key":              "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$!!!",
+        "empty key":            "$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+    }
+    h := testArgon2id(64, 1)
+    for name, hashed := range tests {
+        t.Run(name, func(t *testing.T) {
+            if _, err := parseArgon2id(hashed); err == nil {
+                t.Fatalf("parseArgon2id(%q) returned no error", hashed)
+            }
+            if h.Verify(hashed, "password") {
+                t.Fatal("malformed hash verified")
+            }
+            if !h.NeedsRehash(hashed) {
+                t.Fatal("malformed hash does not need a rehash")
+            }
+        })
+    }
+}
+
+func TestArgon2idVerifyRejectsOtherVersion(t *testing.T) {
+    h := testArgon2id(64, 1)
+    hashed, _ := h.Hash("password")
+    old := strings.Replace(hashed, "$v=19$", "$v=16$", 1)
+    if h.Verify(old, "password") {
+        t.Fatal("hash with another argon2 version verified")
+    }
+    if !h.NeedsRehash(old) {
+        t.Fatal("hash with another argon2 version does not need a rehash")
+    }
+}
+
+func TestNeedsRehash(t *testing.T) {
+    argonCurrent := testArgon2id(64, 2)
+    argonOld := testArgon2id(32, 1)
+    bcryptCurrent := BcryptHasher{Cost: bcrypt.MinCost + 1}
+    bcryptOld := BcryptHasher{Cost: bcrypt.MinCost}
+
+    hash := func(h PasswordHasher) string {
+        s, err := h.Hash("password")
+        if err != nil {
+            t.Fatal(err)
+        }
+        return s
+    }
+    argonPreferred := NewMultiHasher(argonCurrent, bcryptCurrent)
+    bcryptPreferred := NewMultiHasher(bcryptCurrent, argonCurrent)
+
+    tests := []struct {
+        name   string
+        hasher *MultiHasher
+        hashed string
+        want   bool
+    }{
+        {"argon2id, current parameters", argonPreferred, hash(argonCurrent), false},
+        {"argon2id, old parameters", argonPreferred, hash(argonOld), true},
+        {"bcrypt while argon2id is preferred", argonPreferred, hash(bcryptCurrent), true},
+        {"bcrypt, current cost", bcryptPreferred, hash(bcryptCurrent), false},
+        {"bcrypt, old cost", bcryptPreferred, hash(bcryptOld), true},
+        {"argon2id while bcrypt is preferred", bcryptPreferred, hash(argonCurrent), true},
+        {"unknown format", argonPreferred, "plaintext", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := tt.hasher.NeedsRehash(tt.hashed); got != tt.want {
+                t.Fatalf("NeedsRehash = %v, want %v", got, tt.want)
+            }
+            // Hashes that need a rehash are still verified, so users migrate on their next login
+            if tt.hashed != "plaintext" && !tt.hasher.Verify(tt.hashed, "password") {
+                t.Fatal("password not verified")
+            }
+        })
+    }
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+    if _, err := NewPasswordHasher("md5", BcryptHasher{}, testArgon2id(64, 1)); err == nil {
+        t.Fatal("unsupported algorithm accepted")
+    }
+    h, err := NewPasswordHasher(PasswordAlgorithmArgon2id, BcryptHasher{Cost: bcrypt.MinCost}, testArgon2id(64, 1))
+    if err != nil {
+        t.Fatal(err)
+    }
+    hashed, _ := h.Hash("password")
+    if !strings.HasPrefix(hashed, "$argon2id$") {
+        t.Fatalf("new hash %q is not argon2id", hashed)
+    }
+}
+
+func TestGenerateTemporaryPassword(t *testing.T) {
+    for i := 0; i < 50; i++ {
+        p, err := GenerateTemporaryPassword(16)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if len(p) != 16 {
+            t.Fatalf("length = %d, want 16", len(p))
+        }
+        for _, class := range []string{tempPasswordUpper, tempPasswordLower, tempPasswordDigits, tempPasswordSymbol} {
+            if !strings.ContainsAny(p, class) {
+                t.Fatalf("%q has no character from %q", p, class)
+            }
+        }
+    }
+}
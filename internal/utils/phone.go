@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrInvalidPhone is returned by NormalizePhone for numbers that are not valid in their region.
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// PhoneFormatHint is the validation message for rejected phone numbers.
+const PhoneFormatHint = "invalid phone format (use the international format, e.g. +40722123456)"
+
+// phoneRegion holds the numbering rules NormalizePhone needs for one country.
+type phoneRegion struct {
+    callingCode string
+    // Prefix dialled before national numbers inside the country ("0" in most of Europe)
+    trunkPrefix string
+    // National significant number (without trunk prefix and calling code)
+    nsn *regexp.Regexp
+}
+
+// phoneRegions lists the regions with country-specific validation; numbers from other
+// countries are accepted in international form if they look like valid E.164.
+var phoneRegions = map[string]phoneRegion{
+    // Landlines (2, 3), mobiles (7) and the 6 range accepted before normalisation was introduced
+    "RO": {"40", "0", regexp.MustCompile(`^[2367]\d{8}$`)},
+    "MD": {"373", "0", regexp.MustCompile(`^[2-9]\d{7}$`)},
+    "BG": {"359", "0", regexp.MustCompile(`^[2-9]\d{6,8}$`)},
+    "HU": {"36", "06", regexp.MustCompile(`^[1-9]\d{7,8}$`)},
+    "AT": {"43", "0", regexp.MustCompile(`^[1-9]\d{3,12}$`)},
+    "DE": {"49", "0", regexp.MustCompile(`^[1-9]\d{5,13}$`)},
+    "FR": {"33", "0", regexp.MustCompile(`^[1-9]\d{8}$`)},
+    "NL": {"31", "0", regexp.MustCompile(`^[1-9]\d{8}$`)},
+    "GB": {"44", "0", regexp.MustCompile(`^[1-9]\d{8,9}$`)},
+    // Italy keeps the leading 0 of landlines in international form, so there is no trunk prefix
+    "IT": {"39", "", regexp.MustCompile(`^[03]\d{5,10}$`)},
+    "ES": {"34", "", regexp.MustCompile(`^[6-9]\d{8}$`)},
+    "PL": {"48", "", regexp.MustCompile(`^[1-9]\d{8}$`)},
+    // North American Numbering Plan, shared by the US and Canada
+    "US": {"1", "1", regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`)},
+    "CA": {"1", "1", regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`)},
+}
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+
+var defaultPhoneRegion atomic.Value
+
+func init() {
+    defaultPhoneRegion.Store("RO")
+}
+
+// SetDefaultPhoneRegion sets the region (ISO 3166 code, e.g. "RO") used for numbers written
+// without a country code.
+func SetDefaultPhoneRegion(region string) error {
+    region = strings.ToUpper(strings.TrimSpace(region))
+    if _, ok := phoneRegions[region]; !ok {
+        return fmt.Errorf("unsupported phone region %q (supported: %s)", region, strings.Join(PhoneRegions(), ", "))
+    }
+    defaultPhoneRegion.Store(region)
+    return nil
+}
+
+// DefaultPhoneRegion returns the region set by SetDefaultPhoneRegion ("RO" by default).
+func DefaultPhoneRegion() string {
+    return defaultPhoneRegion.Load().(string)
+}
+
+// PhoneRegions returns the regions with country-specific validation, sorted.
+func PhoneRegions() []string {
+    out := make([]string, 0, len(phoneRegions))
+    for r := range phoneRegions {
+        out = append(out, r)
+    }
+    sort.Strings(out)
+    return out
+}
+
+// NormalizePhone returns phone in E.164 form ("+40722123456"), reading national numbers
+// ("0722 123 456") in the default region.
+func NormalizePhone(phone string) (string, error) {
+    return NormalizePhoneInRegion(phone, DefaultPhoneRegion())
+}
+
+// NormalizePhoneInRegion is NormalizePhone with an explicit default region. Spaces, dots,
+// dashes and parentheses are ignored and the 00 international prefix is read as "+".
+func NormalizePhoneInRegion(phone, region string) (string, error) {
+    var b strings.Builder
+    for i, r := range strings.TrimSpace(phone) {
+        switch {
+        case r >= '0' && r <= '9':
+            b.WriteRune(r)
+        case r == '+' && i == 0:
+            b.WriteRune(r)
+        case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+        default:
+            return "", ErrInvalidPhone
+        }
+    }
+    digits := b.String()
+    if strings.HasPrefix(digits, "00") {
+        digits = "+" + digits[2:]
+    }
+    if !strings.HasPrefix(digits, "+") {
+        reg, ok := phoneRegions[strings.ToUpper(region)]
+        if !ok {
+            return "", ErrInvalidPhone
+        }
+        digits = "+" + reg.callingCode + strings.TrimPrefix(digits, reg.trunkPrefix)
+    }
+    if !e164Pattern.MatchString(digits) {
+        return "", ErrInvalidPhone
+    }
+    if reg, ok := regionForNumber(digits[1:]); ok && !reg.nsn.MatchString(digits[1+len(reg.callingCode):]) {
+        return "", ErrInvalidPhone
+    }
+    return digits, nil
+}
+
+// regionForNumber finds the known region whose calling code starts the international number.
+// Calling codes are prefix-free, so at most one matches.
+func regionForNumber(number string) (phoneRegion, bool) {
+    for n := 1; n <= 3 && n < len(number); n++ {
+        for _, reg := range phoneRegions {
+            if reg.callingCode == number[:n] {
+                return reg, true
+            }
+        }
+    }
+    return phoneRegion{}, false
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhoneInRegion(t *testing.T) {
+    tests := []struct {
+        name   string
+        phone  string
+        region string
+        want   string // empty means ErrInvalidPhone
+    }{
+        // Romanian numbers in every accepted notation
+        {"RO +40", "+40722123456", "RO", "+40722123456"},
+        {"RO +40 with separators", "+40 (722) 123-456", "RO", "+40722123456"},
+        {"RO 0040", "0040 722-123-456", "RO", "+40722123456"},
+        {"RO national", "0722 123 456", "RO", "+40722123456"},
+        {"RO national with dots", "0722.123.456", "RO", "+40722123456"},
+        {"RO 06 range", "0612345678", "RO", "+40612345678"},
+        {"RO 06 range +40", "+40612345678", "RO", "+40612345678"},
+        {"RO landline", "021 123 4567", "RO", "+40211234567"},
+        {"RO landline 03", "0312345678", "RO", "+40312345678"},
+        {"RO unknown prefix", "0812345678", "RO", ""},
+        {"RO too short", "072212345", "RO", ""},
+        {"RO too long", "07221234567", "RO", ""},
+        {"RO trunk prefix kept after +40", "+400722123456", "RO", ""},
+        {"RO 0040 with trunk prefix", "00400722123456", "RO", ""},
+
+        // Numbers from other regions, with a Romanian default
+        {"FR international", "+33 6 12 34 56 78", "RO", "+33612345678"},
+        {"FR 0033", "0033612345678", "RO", "+33612345678"},
+        {"FR too short", "+3361234567", "RO", ""},
+        {"US international", "+1 (415) 555-2671", "RO", "+14155552671"},
+        {"US area code starting with 0", "+10555552671", "RO", ""},
+        {"unlisted country", "+86 138 0013 8000", "RO", "+8613800138000"},
+        {"unlisted country too long", "+8613800138000123", "RO", ""},
+
+        // National numbers read in other regions
+        {"FR national", "06 12 34 56 78", "FR", "+33612345678"},
+        {"HU national, 06 trunk prefix", "06 30 123 4567", "HU", "+36301234567"},
+        {"IT keeps the leading 0", "06 1234 5678", "IT", "+390612345678"},
+        {"IT mobile", "333 123 4567", "IT", "+393331234567"},
+        {"US national", "(415) 555-2671", "US", "+14155552671"},
+        {"US with trunk prefix", "1 415 555 2671", "us", "+14155552671"},
+        {"GB national", "020 7946 0958", "GB", "+442079460958"},
+        {"RO number read as FR", "0722123456", "FR", "+33722123456"},
+        {"unknown region", "0722123456", "XX", ""},
+
+        // Malformed input
+        {"empty", "", "RO", ""},
+        {"letters", "0722abc456", "RO", ""},
+        {"plus inside", "07+22123456", "RO", ""},
+        {"slash", "0722/123456", "RO", ""},
+        {"only plus", "+", "RO", ""},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := NormalizePhoneInRegion(tt.phone, tt.region)
+            if tt.want == "" {
+                if !errors.Is(err, ErrInvalidPhone) {
+                    t.Fatalf("NormalizePhoneInRegion(%q, %s) = %q, %v; want ErrInvalidPhone", tt.phone, tt.region, got, err)
+                }
+                return
+            }
+            if err != nil || got != tt.want {
+                t.Fatalf("NormalizePhoneInRegion(%q, %s) = %q, %v; want %q", tt.phone, tt.region, got, err, tt.want)
+            }
+        })
+    }
+}
+
+func TestDefaultPhoneRegion(t *testing.T) {
+    t.Cleanup(func() { SetDefaultPhoneRegion("RO") })
+    if got, err := NormalizePhone("0722123456"); err != nil || got != "+40722123456" {
+        t.Fatalf("NormalizePhone = %q, %v; want +40722123456", got, err)
+    }
+    if err := SetDefaultPhoneRegion(" fr "); err != nil {
+        t.Fatal(err)
+    }
+    if got, err := NormalizePhone("0612345678"); err != nil || got != "+33612345678" {
+        t.Fatalf("NormalizePhone = %q, %v; want +33612345678", got, err)
+    }
+    if err := SetDefaultPhoneRegion("XX"); err == nil {
+        t.Fatal("unsupported region accepted")
+    }
+    if DefaultPhoneRegion() != "FR" {
+        t.Fatalf("default region = %s after a rejected change, want FR", DefaultPhoneRegion())
+    }
+}
+
+func TestIsValidPhone(t *testing.T) {
+    for phone, want := range map[string]bool{
+        "":             true, // optional
+        "0722123456":   true,
+        "0612345678":   true,
+        "+40712345678": true,
+        "0812345678":   false,
+        "12345":        false,
+    } {
+        if got := IsValidPhone(phone); got != want {
+            t.Errorf("IsValidPhone(%q) = %v, want %v", phone, got, want)
+        }
+    }
+}
@@ -21,6 +21,13 @@ type ListQuery struct {
 // allowedFields maps allowed field names to a simple type: "string" or "int".
 // allowedSort is a set (map[string]bool) of fields that can be sorted by.
 func ParseListQuery(r *http.Request, allowedFields map[string]string, allowedSort map[string]bool, defaultSort string, defaultLimit, maxLimit int64) ListQuery {
+    return ParseListQueryNormalized(r, allowedFields, allowedSort, defaultSort, defaultLimit, maxLimit, nil)
+}
+
+// ParseListQueryNormalized is ParseListQuery with normalizers for exact-match string filters:
+// field=value becomes field=normalize[field](value), so it matches values stored in canonical form.
+// Normalizing here, before q= wraps the filter in $and, keeps callers from walking the filter.
+func ParseListQueryNormalized(r *http.Request, allowedFields map[string]string, allowedSort map[string]bool, defaultSort string, defaultLimit, maxLimit int64, normalize map[string]func(string) string) ListQuery {
     q := r.URL.Query()
     filter := bson.M{}
 
@@ -33,6 +40,9 @@ func ParseListQuery(r *http.Request, allowedFields map[string]string, allowedSor
                     filter[field] = iv
                 }
             } else {
+                if n := normalize[field]; n != nil {
+                    val = n(val)
+                }
                 filter[field] = val
             }
         }
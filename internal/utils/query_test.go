@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseListQueryNormalizesExactMatches(t *testing.T) {
+    allowed := map[string]string{"email": "string", "phone": "string"}
+    normalize := map[string]func(string) string{"email": strings.ToLower}
+    tests := []struct {
+        name  string
+        query string
+        want  bson.M
+    }{
+        {"exact match", "email=Ana@X.ro", bson.M{"email": "ana@x.ro"}},
+        {"fields without a normalizer", "phone=0722", bson.M{"phone": "0722"}},
+        {"like is left alone", "email_like=Ana", bson.M{"email": bson.M{"$regex": "Ana", "$options": "i"}}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            r := httptest.NewRequest("GET", "/users?"+tt.query, nil)
+            q := ParseListQueryNormalized(r, allowed, nil, "", 20, 100, normalize)
+            if !reflect.DeepEqual(q.Filter, tt.want) {
+                t.Fatalf("filter = %v, want %v", q.Filter, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseListQueryNormalizesInsideGlobalSearch(t *testing.T) {
+    allowed := map[string]string{"email": "string"}
+    normalize := map[string]func(string) string{"email": strings.ToLower}
+    r := httptest.NewRequest("GET", "/users?email=Ana@X.ro&q=ana", nil)
+    q := ParseListQueryNormalized(r, allowed, nil, "", 20, 100, normalize)
+    and, ok := q.Filter["$and"].(bson.A)
+    if !ok || len(and) != 2 {
+        t.Fatalf("filter = %v, want an $and of the field filter and the search", q.Filter)
+    }
+    if got := and[0].(bson.M)["email"]; got != "ana@x.ro" {
+        t.Fatalf("email = %v, want ana@x.ro", got)
+    }
+}
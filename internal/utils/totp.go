@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters (RFC 6238 defaults, understood by every authenticator app).
+const (
+    totpPeriod = 30
+    totpDigits = 6
+    // Accept codes from one step before/after to tolerate clock drift
+    totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random 160-bit secret, base32-encoded without padding.
+func GenerateTOTPSecret() (string, error) {
+    b := make([]byte, 20)
+    if _, err := rand.Read(b); err != nil {
+        return "", err
+    }
+    return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPURI builds the otpauth:// URI rendered as a QR code by the client.
+func TOTPURI(issuer, account, secret string) string {
+    label := url.PathEscape(issuer + ":" + account)
+    q := url.Values{}
+    q.Set("secret", secret)
+    q.Set("issuer", issuer)
+    q.Set("algorithm", "SHA1")
+    q.Set("digits", fmt.Sprint(totpDigits))
+    q.Set("period", fmt.Sprint(totpPeriod))
+    return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// TOTPCode computes the code for the given time step.
+func TOTPCode(secret string, step int64) (string, error) {
+    key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+    if err != nil {
+        return "", err
+    }
+    var msg [8]byte
+    binary.BigEndian.PutUint64(msg[:], uint64(step))
+    mac := hmac.New(sha1.New, key)
+    mac.Write(msg[:])
+    sum := mac.Sum(nil)
+    off := sum[len(sum)-1] & 0x0f
+    bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+    return fmt.Sprintf("%0*d", totpDigits, bin%1000000), nil
+}
+
+// TOTPStep returns the time step for t.
+func TOTPStep(t time.Time) int64 {
+    return t.Unix() / totpPeriod
+}
+
+// ValidateTOTP checks code against the steps around now and returns the matching step,
+// which callers persist to reject replays of the same code.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+    code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+    if len(code) != totpDigits {
+        return 0, false
+    }
+    current := TOTPStep(now)
+    for d := int64(-totpSkew); d <= totpSkew; d++ {
+        want, err := TOTPCode(secret, current+d)
+        if err != nil {
+            return 0, false
+        }
+        if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+            return current + d, true
+        }
+    }
+    return 0, false
+}
+
+// GenerateRecoveryCodes returns n human-friendly single-use codes (xxxxx-xxxxx).
+func GenerateRecoveryCodes(n int) ([]string, error) {
+    enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+    codes := make([]string, 0, n)
+    for i := 0; i < n; i++ {
+        b := make([]byte, 7)
+        if _, err := rand.Read(b); err != nil {
+            return nil, err
+        }
+        s := strings.ToLower(enc.EncodeToString(b))[:10]
+        codes = append(codes, s[:5]+"-"+s[5:])
+    }
+    return codes, nil
+}
+
+// HashRecoveryCode normalises a recovery code (case, dashes, spaces) and hashes it.
+func HashRecoveryCode(code string) string {
+    c := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+    return HashToken(c)
+}
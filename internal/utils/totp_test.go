@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238 appendix B ("12345678901234567890").
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6238 lists 8-digit codes; the 6-digit code is their last six digits.
+var rfc6238Vectors = []struct {
+    unix int64
+    code string
+}{
+    {59, "287082"},
+    {1111111109, "081804"},
+    {1111111111, "050471"},
+    {1234567890, "005924"},
+    {2000000000, "279037"},
+    {20000000000, "353130"},
+}
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+    for _, v := range rfc6238Vectors {
+        got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(v.unix, 0)))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got != v.code {
+            t.Errorf("T=%d: code = %s, want %s", v.unix, got, v.code)
+        }
+    }
+}
+
+func TestTOTPCodeAcceptsLowercaseSecret(t *testing.T) {
+    got, err := TOTPCode(" "+strings.ToLower(rfc6238Secret)+" ", 1)
+    if err != nil || got != "287082" {
+        t.Fatalf("code = %q, %v; want 287082", got, err)
+    }
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+    now := time.Unix(1111111111, 0)
+    current := TOTPStep(now)
+    tests := []struct {
+        name   string
+        offset int64
+        ok     bool
+    }{
+        {"two steps behind", -2, false},
+        {"one step behind", -1, true},
+        {"current step", 0, true},
+        {"one step ahead", 1, true},
+        {"two steps ahead", 2, false},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            code, err := TOTPCode(rfc6238Secret, current+tt.offset)
+            if err != nil {
+                t.Fatal(err)
+            }
+            step, ok := ValidateTOTP(rfc6238Secret, code, now)
+            if ok != tt.ok {
+                t.Fatalf("ok = %v, want %v", ok, tt.ok)
+            }
+            // The matched step is what callers persist to reject the code the second time
+            if ok && step != current+tt.offset {
+                t.Fatalf("step = %d, want %d", step, current+tt.offset)
+            }
+        })
+    }
+}
+
+func TestValidateTOTPRejectsMalformedCodes(t *testing.T) {
+    now := time.Unix(1111111111, 0)
+    for _, code := range []string{"", "05047", "0504711", "abcdef", "050 47"} {
+        if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+            t.Errorf("code %q accepted", code)
+        }
+    }
+    if _, ok := ValidateTOTP(rfc6238Secret, " 050 471 ", now); !ok {
+        t.Error("code with spaces rejected")
+    }
+    if _, ok := ValidateTOTP("not base32!", "050471", now); ok {
+        t.Error("invalid secret accepted")
+    }
+}
+
+func TestRecoveryCodes(t *testing.T) {
+    codes, err := GenerateRecoveryCodes(10)
+    if err != nil {
+        t.Fatal(err)
+    }
+    seen := map[string]bool{}
+    for _, c := range codes {
+        if len(c) != 11 || c[5] != '-' {
+            t.Fatalf("unexpected recovery code format %q", c)
+        }
+        if seen[c] {
+            t.Fatalf("duplicate recovery code %q", c)
+        }
+        seen[c] = true
+        // Users may retype the code in another case or without the dash
+        if HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(c, "-", " "))) != HashRecoveryCode(c) {
+            t.Fatalf("hash of %q depends on case or separators", c)
+        }
+    }
+}
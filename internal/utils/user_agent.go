@@ -0,0 +1,47 @@
+package utils
+
+import "strings"
+
+// DescribeUserAgent returns a short, best-effort label such as "Chrome on Windows" for session lists.
+func DescribeUserAgent(ua string) string {
+    if ua == "" {
+        return "Unknown device"
+    }
+    browser := "Unknown client"
+    // Order matters: Edge and Opera also announce Chrome, Chrome also announces Safari
+    for _, b := range []struct{ token, name string }{
+        {"Edg/", "Edge"},
+        {"OPR/", "Opera"},
+        {"Firefox/", "Firefox"},
+        {"Chrome/", "Chrome"},
+        {"Safari/", "Safari"},
+        {"curl/", "curl"},
+        {"PostmanRuntime/", "Postman"},
+        {"Go-http-client/", "Go HTTP client"},
+        {"python-requests/", "Python requests"},
+    } {
+        if strings.Contains(ua, b.token) {
+            browser = b.name
+            break
+        }
+    }
+    os := ""
+    for _, o := range []struct{ token, name string }{
+        {"Android", "Android"},
+        {"iPhone", "iOS"},
+        {"iPad", "iPadOS"},
+        {"Windows", "Windows"},
+        {"Mac OS X", "macOS"},
+        {"CrOS", "ChromeOS"},
+        {"Linux", "Linux"},
+    } {
+        if strings.Contains(ua, o.token) {
+            os = o.name
+            break
+        }
+    }
+    if os == "" {
+        return browser
+    }
+    return browser + " on " + os
+}
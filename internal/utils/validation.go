@@ -17,22 +17,25 @@ func IsValidEmail(email string) bool {
     return re.MatchString(email)
 }
 
-// IsValidPhone verifică dacă numărul de telefon are un format valid
+// NormalizeEmail aduce emailul la forma salvată: fără spații la capete și cu litere mici
+func NormalizeEmail(email string) string {
+    return strings.ToLower(strings.TrimSpace(email))
+}
+
+// EmailLogHash întoarce un hash scurt al emailului normalizat, pentru loguri: cererile pentru aceeași
+// adresă pot fi corelate fără ca adresa (posibil a nimănui) să ajungă în log
+func EmailLogHash(email string) string {
+    return HashToken(NormalizeEmail(email))[:16]
+}
+
+// IsValidPhone verifică dacă numărul de telefon e valid; numerele fără prefix de țară
+// sunt citite în regiunea implicită (vezi NormalizePhone)
 func IsValidPhone(phone string) bool {
     if phone == "" {
         return true // telefonul e opțional
     }
-    
-    // Elimină spațiile și caracterele speciale
-    cleanPhone := strings.ReplaceAll(phone, " ", "")
-    cleanPhone = strings.ReplaceAll(cleanPhone, "-", "")
-    cleanPhone = strings.ReplaceAll(cleanPhone, "(", "")
-    cleanPhone = strings.ReplaceAll(cleanPhone, ")", "")
-    
-    // Verifică format pentru România: +40xxxxxxxxx sau 07xxxxxxxx
-    phoneRegex := `^(\+40|0040|0)[67]\d{8}$`
-    re := regexp.MustCompile(phoneRegex)
-    return re.MatchString(cleanPhone)
+    _, err := NormalizePhone(phone)
+    return err == nil
 }
 
 // ValidateUserInput validează toate câmpurile unui UserInput
@@ -67,22 +70,7 @@ func ValidateUserInput(input interface{}) []string {
         if phone, exists := user["phone"]; exists {
             if phoneStr, ok := phone.(string); ok && phoneStr != "" {
                 if !IsValidPhone(phoneStr) {
-                    errors = append(errors, "invalid phone format (use +40xxxxxxxxx or 07xxxxxxxx)")
-                }
-            }
-        }
-        
-        // Validare parolă
-        if password, exists := user["password"]; exists {
-            if passStr, ok := password.(string); ok && passStr != "" {
-                if len(passStr) < 8 {
-                    errors = append(errors, "password must be at least 8 characters")
-                }
-                if !hasUppercase(passStr) {
-                    errors = append(errors, "password must contain at least one uppercase letter")
-                }
-                if !hasDigit(passStr) {
-                    errors = append(errors, "password must contain at least one digit")
+                    errors = append(errors, PhoneFormatHint)
                 }
             }
         }
@@ -90,23 +78,3 @@ func ValidateUserInput(input interface{}) []string {
     
     return errors
 }
-
-// hasUppercase verifică dacă string-ul conține cel puțin o literă mare
-func hasUppercase(s string) bool {
-    for _, r := range s {
-        if r >= 'A' && r <= 'Z' {
-            return true
-        }
-    }
-    return false
-}
-
-// hasDigit verifică dacă string-ul conține cel puțin o cifră
-func hasDigit(s string) bool {
-    for _, r := range s {
-        if r >= '0' && r <= '9' {
-            return true
-        }
-    }
-    return false
-}
\ No newline at end of file
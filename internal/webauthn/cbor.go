@@ -0,0 +1,176 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxCBORDepth bounds nesting so a hostile attestation object cannot exhaust the stack.
+const maxCBORDepth = 16
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// decodeCBOR decodes one CBOR data item (RFC 8949) and returns it with the bytes that follow it.
+// Only the definite-length subset authenticators emit is supported. Values decode to int64,
+// []byte, string, []interface{}, map[interface{}]interface{} (int64 or string keys), bool,
+// float64 or nil; tags are dropped and their content returned.
+func decodeCBOR(b []byte) (interface{}, []byte, error) {
+    return decodeCBORItem(b, 0)
+}
+
+func decodeCBORItem(b []byte, depth int) (interface{}, []byte, error) {
+    if depth > maxCBORDepth {
+        return nil, nil, errors.New("cbor: nesting too deep")
+    }
+    if len(b) == 0 {
+        return nil, nil, errCBORTruncated
+    }
+    major, info := b[0]>>5, b[0]&0x1f
+    if major == 7 {
+        return decodeCBORSimple(b, info)
+    }
+    n, rest, err := cborArgument(b[1:], info)
+    if err != nil {
+        return nil, nil, err
+    }
+    switch major {
+    case 0:
+        if n > math.MaxInt64 {
+            return nil, nil, errors.New("cbor: integer overflow")
+        }
+        return int64(n), rest, nil
+    case 1:
+        if n > math.MaxInt64 {
+            return nil, nil, errors.New("cbor: integer overflow")
+        }
+        return -1 - int64(n), rest, nil
+    case 2, 3:
+        if n > uint64(len(rest)) {
+            return nil, nil, errCBORTruncated
+        }
+        if major == 2 {
+            return append([]byte(nil), rest[:n]...), rest[n:], nil
+        }
+        return string(rest[:n]), rest[n:], nil
+    case 4:
+        // Every item takes at least one byte, which also bounds the allocation
+        if n > uint64(len(rest)) {
+            return nil, nil, errCBORTruncated
+        }
+        arr := make([]interface{}, 0, n)
+        for i := uint64(0); i < n; i++ {
+            var v interface{}
+            if v, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+                return nil, nil, err
+            }
+            arr = append(arr, v)
+        }
+        return arr, rest, nil
+    case 5:
+        if n > uint64(len(rest)) {
+            return nil, nil, errCBORTruncated
+        }
+        m := make(map[interface{}]interface{}, n)
+        for i := uint64(0); i < n; i++ {
+            var k, v interface{}
+            if k, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+                return nil, nil, err
+            }
+            switch k.(type) {
+            case int64, string:
+            default:
+                return nil, nil, fmt.Errorf("cbor: unsupported map key type %T", k)
+            }
+            if v, rest, err = decodeCBORItem(rest, depth+1); err != nil {
+                return nil, nil, err
+            }
+            m[k] = v
+        }
+        return m, rest, nil
+    default: // 6: tag
+        return decodeCBORItem(rest, depth+1)
+    }
+}
+
+// cborArgument reads the length/value that follows the initial byte.
+func cborArgument(b []byte, info byte) (uint64, []byte, error) {
+    switch {
+    case info < 24:
+        return uint64(info), b, nil
+    case info == 24:
+        if len(b) < 1 {
+            return 0, nil, errCBORTruncated
+        }
+        return uint64(b[0]), b[1:], nil
+    case info == 25:
+        if len(b) < 2 {
+            return 0, nil, errCBORTruncated
+        }
+        return uint64(binary.BigEndian.Uint16(b)), b[2:], nil
+    case info == 26:
+        if len(b) < 4 {
+            return 0, nil, errCBORTruncated
+        }
+        return uint64(binary.BigEndian.Uint32(b)), b[4:], nil
+    case info == 27:
+        if len(b) < 8 {
+            return 0, nil, errCBORTruncated
+        }
+        return binary.BigEndian.Uint64(b), b[8:], nil
+    default:
+        return 0, nil, errors.New("cbor: indefinite lengths are not supported")
+    }
+}
+
+func decodeCBORSimple(b []byte, info byte) (interface{}, []byte, error) {
+    rest := b[1:]
+    switch info {
+    case 20:
+        return false, rest, nil
+    case 21:
+        return true, rest, nil
+    case 22, 23:
+        return nil, rest, nil
+    case 25:
+        if len(rest) < 2 {
+            return nil, nil, errCBORTruncated
+        }
+        return halfToFloat(binary.BigEndian.Uint16(rest)), rest[2:], nil
+    case 26:
+        if len(rest) < 4 {
+            return nil, nil, errCBORTruncated
+        }
+        return float64(math.Float32frombits(binary.BigEndian.Uint32(rest))), rest[4:], nil
+    case 27:
+        if len(rest) < 8 {
+            return nil, nil, errCBORTruncated
+        }
+        return math.Float64frombits(binary.BigEndian.Uint64(rest)), rest[8:], nil
+    default:
+        return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+    }
+}
+
+func halfToFloat(h uint16) float64 {
+    exp := int(h>>10) & 0x1f
+    mant := float64(h & 0x3ff)
+    var v float64
+    switch exp {
+    case 0:
+        v = math.Ldexp(mant, -24)
+    case 31:
+        if mant == 0 {
+            v = math.Inf(1)
+        } else {
+            v = math.NaN()
+        }
+    default:
+        v = math.Ldexp(mant+1024, exp-25)
+    }
+    if h&0x8000 != 0 {
+        return -v
+    }
+    return v
+}
@@ -0,0 +1,109 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers accepted for credentials, in order of preference.
+const (
+    AlgES256 int64 = -7
+    AlgEdDSA int64 = -8
+    AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms is advertised in pubKeyCredParams.
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters (RFC 9052/9053).
+const (
+    coseKty = 1
+    coseAlg = 3
+    coseCrv = -1
+    coseX   = -2 // also the RSA modulus n
+    coseY   = -3 // also the RSA exponent e
+
+    coseKtyOKP = 1
+    coseKtyEC2 = 2
+    coseKtyRSA = 3
+
+    coseCrvP256    = 1
+    coseCrvEd25519 = 6
+)
+
+// parseCOSEKey turns a COSE_Key into a Go public key and its algorithm.
+func parseCOSEKey(raw []byte) (crypto.PublicKey, int64, error) {
+    v, rest, err := decodeCBOR(raw)
+    if err != nil {
+        return nil, 0, err
+    }
+    if len(rest) != 0 {
+        return nil, 0, errors.New("cose: trailing data after key")
+    }
+    m, ok := v.(map[interface{}]interface{})
+    if !ok {
+        return nil, 0, errors.New("cose: key is not a map")
+    }
+    kty, _ := m[int64(coseKty)].(int64)
+    alg, _ := m[int64(coseAlg)].(int64)
+    switch {
+    case kty == coseKtyEC2 && alg == AlgES256:
+        crv, _ := m[int64(coseCrv)].(int64)
+        x, _ := m[int64(coseX)].([]byte)
+        y, _ := m[int64(coseY)].([]byte)
+        if crv != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+            return nil, 0, errors.New("cose: invalid P-256 key")
+        }
+        // crypto/ecdh rejects points that are not on the curve
+        if _, err := ecdh.P256().NewPublicKey(append([]byte{4}, append(x, y...)...)); err != nil {
+            return nil, 0, fmt.Errorf("cose: %w", err)
+        }
+        return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, alg, nil
+    case kty == coseKtyOKP && alg == AlgEdDSA:
+        crv, _ := m[int64(coseCrv)].(int64)
+        x, _ := m[int64(coseX)].([]byte)
+        if crv != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+            return nil, 0, errors.New("cose: invalid Ed25519 key")
+        }
+        return ed25519.PublicKey(x), alg, nil
+    case kty == coseKtyRSA && alg == AlgRS256:
+        n, _ := m[int64(coseX)].([]byte)
+        e, _ := m[int64(coseY)].([]byte)
+        if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+            return nil, 0, errors.New("cose: invalid RSA key")
+        }
+        return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, alg, nil
+    default:
+        return nil, 0, fmt.Errorf("cose: unsupported key type %d / algorithm %d", kty, alg)
+    }
+}
+
+// verifySignature checks sig over data with a key from parseCOSEKey.
+func verifySignature(pub crypto.PublicKey, alg int64, data, sig []byte) error {
+    switch k := pub.(type) {
+    case *ecdsa.PublicKey:
+        sum := sha256.Sum256(data)
+        if !ecdsa.VerifyASN1(k, sum[:], sig) {
+            return errors.New("invalid ECDSA signature")
+        }
+        return nil
+    case ed25519.PublicKey:
+        if !ed25519.Verify(k, data, sig) {
+            return errors.New("invalid Ed25519 signature")
+        }
+        return nil
+    case *rsa.PublicKey:
+        sum := sha256.Sum256(data)
+        return rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig)
+    default:
+        return fmt.Errorf("unsupported key type %T for algorithm %d", pub, alg)
+    }
+}
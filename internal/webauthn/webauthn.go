@@ -0,0 +1,435 @@
+// Package webauthn implements the server side of WebAuthn (passkey) registration and
+// authentication ceremonies with the standard library only. Attestation is not verified:
+// registrations request and accept the "none" attestation format.
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// User verification requirements (WebAuthn UserVerificationRequirement).
+const (
+    UserVerificationRequired    = "required"
+    UserVerificationPreferred   = "preferred"
+    UserVerificationDiscouraged = "discouraged"
+)
+
+// Authenticator data flags.
+const (
+    flagUserPresent    = 0x01
+    flagUserVerified   = 0x04
+    flagBackupEligible = 0x08
+    flagBackedUp       = 0x10
+    flagAttestedData   = 0x40
+    flagExtensionData  = 0x80
+)
+
+// challengeLength is the size of generated challenges in bytes.
+const challengeLength = 32
+
+// maxCredentialIDLength is the limit from the WebAuthn specification.
+const maxCredentialIDLength = 1023
+
+// ErrVerification wraps every reason a ceremony response is rejected.
+var ErrVerification = errors.New("webauthn: verification failed")
+
+// ErrSignCountRegression means the authenticator's signature counter did not increase, which
+// can indicate a cloned authenticator.
+var ErrSignCountRegression = fmt.Errorf("%w: signature counter did not increase", ErrVerification)
+
+func verificationError(format string, args ...interface{}) error {
+    return fmt.Errorf("%w: %s", ErrVerification, fmt.Sprintf(format, args...))
+}
+
+// URLEncoded is binary data carried as unpadded base64url in JSON, as in the WebAuthn JSON
+// serialization. Padded and standard base64 are accepted on input.
+type URLEncoded []byte
+
+func (u URLEncoded) MarshalJSON() ([]byte, error) {
+    return json.Marshal(base64.RawURLEncoding.EncodeToString(u))
+}
+
+func (u *URLEncoded) UnmarshalJSON(data []byte) error {
+    var s string
+    if err := json.Unmarshal(data, &s); err != nil {
+        return err
+    }
+    b, err := DecodeURLEncoded(s)
+    if err != nil {
+        return err
+    }
+    *u = b
+    return nil
+}
+
+// String returns the unpadded base64url form, used as the credential's lookup key.
+func (u URLEncoded) String() string { return base64.RawURLEncoding.EncodeToString(u) }
+
+// DecodeURLEncoded decodes base64url (or standard base64), with or without padding.
+func DecodeURLEncoded(s string) ([]byte, error) {
+    s = strings.TrimRight(s, "=")
+    s = strings.NewReplacer("+", "-", "/", "_").Replace(s)
+    return base64.RawURLEncoding.DecodeString(s)
+}
+
+// Config describes the relying party (this application).
+type Config struct {
+    // RPID is the domain credentials are scoped to, e.g. "example.com"
+    RPID   string
+    RPName string
+    // Origins are the exact origins (scheme://host[:port]) the browser may report
+    Origins []string
+    Timeout time.Duration
+    // UserVerification is one of the UserVerification* constants; "required" rejects
+    // responses without the UV flag
+    UserVerification string
+}
+
+// RelyingParty builds ceremony options and verifies the authenticator responses.
+type RelyingParty struct {
+    Config Config
+}
+
+func New(cfg Config) *RelyingParty {
+    if cfg.Timeout == 0 {
+        cfg.Timeout = 5 * time.Minute
+    }
+    if cfg.UserVerification == "" {
+        cfg.UserVerification = UserVerificationPreferred
+    }
+    return &RelyingParty{Config: cfg}
+}
+
+// NewChallenge returns a random challenge for one ceremony.
+func NewChallenge() ([]byte, error) {
+    b := make([]byte, challengeLength)
+    if _, err := rand.Read(b); err != nil {
+        return nil, err
+    }
+    return b, nil
+}
+
+// RelyingPartyEntity, UserEntity and the types below mirror PublicKeyCredentialCreationOptionsJSON
+// and PublicKeyCredentialRequestOptionsJSON, so browsers can use
+// PublicKeyCredential.parseCreationOptionsFromJSON / parseRequestOptionsFromJSON directly.
+type RelyingPartyEntity struct {
+    ID   string `json:"id"`
+    Name string `json:"name"`
+}
+
+type UserEntity struct {
+    ID          URLEncoded `json:"id"`
+    Name        string     `json:"name"`
+    DisplayName string     `json:"displayName"`
+}
+
+type CredentialParameter struct {
+    Type string `json:"type"`
+    Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+    Type       string     `json:"type"`
+    ID         URLEncoded `json:"id"`
+    Transports []string   `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+    ResidentKey      string `json:"residentKey"`
+    UserVerification string `json:"userVerification"`
+}
+
+type CreationOptions struct {
+    RP                     RelyingPartyEntity     `json:"rp"`
+    User                   UserEntity             `json:"user"`
+    Challenge              URLEncoded             `json:"challenge"`
+    PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+    Timeout                int64                  `json:"timeout"`
+    ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+    AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+    Attestation            string                 `json:"attestation"`
+}
+
+type RequestOptions struct {
+    Challenge        URLEncoded             `json:"challenge"`
+    Timeout          int64                  `json:"timeout"`
+    RPID             string                 `json:"rpId"`
+    AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+    UserVerification string                 `json:"userVerification"`
+}
+
+// CreationOptions returns the options for navigator.credentials.create. exclude lists the
+// user's existing credentials so the same authenticator is not registered twice.
+func (rp *RelyingParty) CreationOptions(challenge []byte, user UserEntity, exclude []CredentialDescriptor) CreationOptions {
+    params := make([]CredentialParameter, len(SupportedAlgorithms))
+    for i, alg := range SupportedAlgorithms {
+        params[i] = CredentialParameter{Type: "public-key", Alg: alg}
+    }
+    if exclude == nil {
+        exclude = []CredentialDescriptor{}
+    }
+    return CreationOptions{
+        RP:                     RelyingPartyEntity{ID: rp.Config.RPID, Name: rp.Config.RPName},
+        User:                   user,
+        Challenge:              challenge,
+        PubKeyCredParams:       params,
+        Timeout:                rp.Config.Timeout.Milliseconds(),
+        ExcludeCredentials:     exclude,
+        AuthenticatorSelection: AuthenticatorSelection{ResidentKey: "preferred", UserVerification: rp.Config.UserVerification},
+        Attestation:            "none",
+    }
+}
+
+// RequestOptions returns the options for navigator.credentials.get. An empty allow list lets
+// the browser offer any discoverable credential (passkey) for this RP.
+func (rp *RelyingParty) RequestOptions(challenge []byte, allow []CredentialDescriptor) RequestOptions {
+    if allow == nil {
+        allow = []CredentialDescriptor{}
+    }
+    return RequestOptions{
+        Challenge:        challenge,
+        Timeout:          rp.Config.Timeout.Milliseconds(),
+        RPID:             rp.Config.RPID,
+        AllowCredentials: allow,
+        UserVerification: rp.Config.UserVerification,
+    }
+}
+
+// RegistrationResponse is the RegistrationResponseJSON sent by the browser after create().
+type RegistrationResponse struct {
+    ID       string     `json:"id"`
+    RawID    URLEncoded `json:"rawId"`
+    Type     string     `json:"type"`
+    Response struct {
+        ClientDataJSON    URLEncoded `json:"clientDataJSON"`
+        AttestationObject URLEncoded `json:"attestationObject"`
+        Transports        []string   `json:"transports,omitempty"`
+    } `json:"response"`
+}
+
+// AssertionResponse is the AuthenticationResponseJSON sent by the browser after get().
+type AssertionResponse struct {
+    ID       string     `json:"id"`
+    RawID    URLEncoded `json:"rawId"`
+    Type     string     `json:"type"`
+    Response struct {
+        ClientDataJSON    URLEncoded `json:"clientDataJSON"`
+        AuthenticatorData URLEncoded `json:"authenticatorData"`
+        Signature         URLEncoded `json:"signature"`
+        UserHandle        URLEncoded `json:"userHandle,omitempty"`
+    } `json:"response"`
+}
+
+// Credential is a verified new credential, ready to be stored.
+type Credential struct {
+    ID             []byte
+    PublicKey      []byte // COSE_Key as sent by the authenticator
+    Algorithm      int64
+    SignCount      uint32
+    AAGUID         []byte
+    Transports     []string
+    UserVerified   bool
+    BackupEligible bool
+    BackedUp       bool
+}
+
+// AssertionResult is what a successful assertion reports about the authenticator.
+type AssertionResult struct {
+    SignCount    uint32
+    UserVerified bool
+    BackedUp     bool
+}
+
+type clientData struct {
+    Type        string `json:"type"`
+    Challenge   string `json:"challenge"`
+    Origin      string `json:"origin"`
+    CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// ClientDataChallenge extracts the challenge from clientDataJSON, so the server can find the
+// pending ceremony before verifying the response.
+func ClientDataChallenge(clientDataJSON []byte) ([]byte, error) {
+    var cd clientData
+    if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+        return nil, verificationError("invalid clientDataJSON")
+    }
+    b, err := DecodeURLEncoded(cd.Challenge)
+    if err != nil || len(b) == 0 {
+        return nil, verificationError("invalid challenge")
+    }
+    return b, nil
+}
+
+// VerifyRegistration checks a create() response against the challenge issued for it.
+func (rp *RelyingParty) VerifyRegistration(resp RegistrationResponse, challenge []byte) (*Credential, error) {
+    if resp.Type != "public-key" {
+        return nil, verificationError("unexpected credential type %q", resp.Type)
+    }
+    if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+        return nil, err
+    }
+    v, rest, err := decodeCBOR(resp.Response.AttestationObject)
+    if err != nil || len(rest) != 0 {
+        return nil, verificationError("invalid attestation object")
+    }
+    att, ok := v.(map[interface{}]interface{})
+    if !ok {
+        return nil, verificationError("invalid attestation object")
+    }
+    format, _ := att["fmt"].(string)
+    stmt, _ := att["attStmt"].(map[interface{}]interface{})
+    rawAuthData, _ := att["authData"].([]byte)
+    // Only "none" is requested; its statement is always empty
+    if format != "none" || len(stmt) != 0 {
+        return nil, verificationError("unsupported attestation format %q", format)
+    }
+    ad, err := rp.parseAuthenticatorData(rawAuthData)
+    if err != nil {
+        return nil, err
+    }
+    if ad.flags&flagAttestedData == 0 || ad.credentialID == nil {
+        return nil, verificationError("missing attested credential data")
+    }
+    if len(resp.RawID) > 0 && subtle.ConstantTimeCompare(resp.RawID, ad.credentialID) != 1 {
+        return nil, verificationError("credential id mismatch")
+    }
+    _, alg, err := parseCOSEKey(ad.publicKey)
+    if err != nil {
+        return nil, verificationError("%v", err)
+    }
+    return &Credential{
+        ID:             ad.credentialID,
+        PublicKey:      ad.publicKey,
+        Algorithm:      alg,
+        SignCount:      ad.signCount,
+        AAGUID:         ad.aaguid,
+        Transports:     resp.Response.Transports,
+        UserVerified:   ad.flags&flagUserVerified != 0,
+        BackupEligible: ad.flags&flagBackupEligible != 0,
+        BackedUp:       ad.flags&flagBackedUp != 0,
+    }, nil
+}
+
+// VerifyAssertion checks a get() response against the challenge and the stored credential
+// (its COSE public key and last signature counter).
+func (rp *RelyingParty) VerifyAssertion(resp AssertionResponse, challenge, publicKey []byte, storedCount uint32) (*AssertionResult, error) {
+    if resp.Type != "public-key" {
+        return nil, verificationError("unexpected credential type %q", resp.Type)
+    }
+    if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+        return nil, err
+    }
+    ad, err := rp.parseAuthenticatorData(resp.Response.AuthenticatorData)
+    if err != nil {
+        return nil, err
+    }
+    pub, alg, err := parseCOSEKey(publicKey)
+    if err != nil {
+        return nil, verificationError("stored key: %v", err)
+    }
+    clientHash := sha256.Sum256(resp.Response.ClientDataJSON)
+    signed := append(append([]byte(nil), resp.Response.AuthenticatorData...), clientHash[:]...)
+    if err := verifySignature(pub, alg, signed, resp.Response.Signature); err != nil {
+        return nil, verificationError("%v", err)
+    }
+    // Authenticators without a counter always send 0
+    if (ad.signCount != 0 || storedCount != 0) && ad.signCount <= storedCount {
+        return nil, ErrSignCountRegression
+    }
+    return &AssertionResult{
+        SignCount:    ad.signCount,
+        UserVerified: ad.flags&flagUserVerified != 0,
+        BackedUp:     ad.flags&flagBackedUp != 0,
+    }, nil
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, typ string, challenge []byte) error {
+    var cd clientData
+    if err := json.Unmarshal(raw, &cd); err != nil {
+        return verificationError("invalid clientDataJSON")
+    }
+    if cd.Type != typ {
+        return verificationError("unexpected client data type %q", cd.Type)
+    }
+    got, err := DecodeURLEncoded(cd.Challenge)
+    if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+        return verificationError("challenge mismatch")
+    }
+    if cd.CrossOrigin {
+        return verificationError("cross-origin requests are not allowed")
+    }
+    for _, o := range rp.Config.Origins {
+        if cd.Origin == o {
+            return nil
+        }
+    }
+    return verificationError("unexpected origin %q", cd.Origin)
+}
+
+type authenticatorData struct {
+    flags        byte
+    signCount    uint32
+    aaguid       []byte
+    credentialID []byte
+    publicKey    []byte
+}
+
+// parseAuthenticatorData checks the RP ID hash and the user presence/verification flags.
+func (rp *RelyingParty) parseAuthenticatorData(b []byte) (*authenticatorData, error) {
+    if len(b) < 37 {
+        return nil, verificationError("authenticator data too short")
+    }
+    rpHash := sha256.Sum256([]byte(rp.Config.RPID))
+    if subtle.ConstantTimeCompare(b[:32], rpHash[:]) != 1 {
+        return nil, verificationError("RP ID hash mismatch")
+    }
+    ad := &authenticatorData{flags: b[32], signCount: binary.BigEndian.Uint32(b[33:37])}
+    if ad.flags&flagUserPresent == 0 {
+        return nil, verificationError("user not present")
+    }
+    if rp.Config.UserVerification == UserVerificationRequired && ad.flags&flagUserVerified == 0 {
+        return nil, verificationError("user not verified")
+    }
+    rest := b[37:]
+    if ad.flags&flagAttestedData != 0 {
+        if len(rest) < 18 {
+            return nil, verificationError("attested credential data too short")
+        }
+        ad.aaguid = append([]byte(nil), rest[:16]...)
+        n := int(binary.BigEndian.Uint16(rest[16:18]))
+        rest = rest[18:]
+        if n == 0 || n > maxCredentialIDLength || len(rest) < n {
+            return nil, verificationError("invalid credential id length")
+        }
+        ad.credentialID = append([]byte(nil), rest[:n]...)
+        rest = rest[n:]
+        // The COSE key has no length prefix: decode it to find where it ends
+        _, after, err := decodeCBOR(rest)
+        if err != nil {
+            return nil, verificationError("invalid credential public key")
+        }
+        ad.publicKey = append([]byte(nil), rest[:len(rest)-len(after)]...)
+        rest = after
+    }
+    if ad.flags&flagExtensionData != 0 {
+        _, after, err := decodeCBOR(rest)
+        if err != nil {
+            return nil, verificationError("invalid extension data")
+        }
+        rest = after
+    }
+    if len(rest) != 0 {
+        return nil, verificationError("trailing authenticator data")
+    }
+    return ad, nil
+}
@@ -0,0 +1,306 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+    testRPID   = "example.com"
+    testOrigin = "https://example.com"
+)
+
+// cborPair keeps map entries in a fixed order, as authenticators encode them.
+type cborPair struct {
+    key   interface{}
+    value interface{}
+}
+
+// encodeCBOR is the small subset of CBOR the software authenticator needs.
+func encodeCBOR(v interface{}) []byte {
+    switch x := v.(type) {
+    case int:
+        if x < 0 {
+            return cborHead(1, uint64(-1-x))
+        }
+        return cborHead(0, uint64(x))
+    case []byte:
+        return append(cborHead(2, uint64(len(x))), x...)
+    case string:
+        return append(cborHead(3, uint64(len(x))), x...)
+    case []cborPair:
+        out := cborHead(5, uint64(len(x)))
+        for _, p := range x {
+            out = append(out, encodeCBOR(p.key)...)
+            out = append(out, encodeCBOR(p.value)...)
+        }
+        return out
+    }
+    panic("encodeCBOR: unsupported type")
+}
+
+func cborHead(major byte, n uint64) []byte {
+    switch {
+    case n < 24:
+        return []byte{major<<5 | byte(n)}
+    case n < 256:
+        return []byte{major<<5 | 24, byte(n)}
+    default:
+        b := []byte{major<<5 | 25, 0, 0}
+        binary.BigEndian.PutUint16(b[1:], uint16(n))
+        return b
+    }
+}
+
+// softAuthenticator is an ES256 authenticator implemented in software.
+type softAuthenticator struct {
+    key       *ecdsa.PrivateKey
+    credID    []byte
+    signCount uint32
+    // Overrides used to build invalid responses
+    rpID  string
+    flags byte
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+    t.Helper()
+    key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    if err != nil {
+        t.Fatal(err)
+    }
+    id := make([]byte, 16)
+    rand.Read(id)
+    return &softAuthenticator{key: key, credID: id, rpID: testRPID, flags: flagUserPresent | flagUserVerified}
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+    x := make([]byte, 32)
+    y := make([]byte, 32)
+    a.key.PublicKey.X.FillBytes(x)
+    a.key.PublicKey.Y.FillBytes(y)
+    return encodeCBOR([]cborPair{
+        {coseKty, coseKtyEC2},
+        {coseAlg, int(AlgES256)},
+        {coseCrv, coseCrvP256},
+        {coseX, x},
+        {coseY, y},
+    })
+}
+
+func (a *softAuthenticator) authData(attested bool) []byte {
+    rpHash := sha256.Sum256([]byte(a.rpID))
+    b := append([]byte(nil), rpHash[:]...)
+    flags := a.flags
+    if attested {
+        flags |= flagAttestedData
+    }
+    b = append(b, flags)
+    b = binary.BigEndian.AppendUint32(b, a.signCount)
+    if attested {
+        b = append(b, make([]byte, 16)...) // AAGUID
+        b = binary.BigEndian.AppendUint16(b, uint16(len(a.credID)))
+        b = append(b, a.credID...)
+        b = append(b, a.coseKey()...)
+    }
+    return b
+}
+
+func clientDataJSON(typ string, challenge []byte, origin string) []byte {
+    b, _ := json.Marshal(map[string]interface{}{
+        "type":      typ,
+        "challenge": base64.RawURLEncoding.EncodeToString(challenge),
+        "origin":    origin,
+    })
+    return b
+}
+
+func (a *softAuthenticator) register(challenge []byte, origin string) RegistrationResponse {
+    var resp RegistrationResponse
+    resp.ID = URLEncoded(a.credID).String()
+    resp.RawID = a.credID
+    resp.Type = "public-key"
+    resp.Response.ClientDataJSON = clientDataJSON("webauthn.create", challenge, origin)
+    resp.Response.AttestationObject = encodeCBOR([]cborPair{
+        {"fmt", "none"},
+        {"attStmt", []cborPair{}},
+        {"authData", a.authData(true)},
+    })
+    return resp
+}
+
+func (a *softAuthenticator) assert(t *testing.T, challenge []byte, origin string) AssertionResponse {
+    t.Helper()
+    a.signCount++
+    var resp AssertionResponse
+    resp.ID = URLEncoded(a.credID).String()
+    resp.RawID = a.credID
+    resp.Type = "public-key"
+    resp.Response.ClientDataJSON = clientDataJSON("webauthn.get", challenge, origin)
+    resp.Response.AuthenticatorData = a.authData(false)
+    clientHash := sha256.Sum256(resp.Response.ClientDataJSON)
+    digest := sha256.Sum256(append(append([]byte(nil), resp.Response.AuthenticatorData...), clientHash[:]...))
+    sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+    if err != nil {
+        t.Fatal(err)
+    }
+    resp.Response.Signature = sig
+    return resp
+}
+
+func testRP() *RelyingParty {
+    return New(Config{RPID: testRPID, RPName: "Test", Origins: []string{testOrigin}})
+}
+
+func mustChallenge(t *testing.T) []byte {
+    t.Helper()
+    c, err := NewChallenge()
+    if err != nil {
+        t.Fatal(err)
+    }
+    return c
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+    rp := testRP()
+    auth := newSoftAuthenticator(t)
+
+    challenge := mustChallenge(t)
+    cred, err := rp.VerifyRegistration(auth.register(challenge, testOrigin), challenge)
+    if err != nil {
+        t.Fatalf("VerifyRegistration: %v", err)
+    }
+    if !bytes.Equal(cred.ID, auth.credID) || cred.Algorithm != AlgES256 || !cred.UserVerified {
+        t.Fatalf("unexpected credential: %+v", cred)
+    }
+
+    challenge = mustChallenge(t)
+    res, err := rp.VerifyAssertion(auth.assert(t, challenge, testOrigin), challenge, cred.PublicKey, cred.SignCount)
+    if err != nil {
+        t.Fatalf("VerifyAssertion: %v", err)
+    }
+    if res.SignCount != auth.signCount {
+        t.Fatalf("sign count = %d, want %d", res.SignCount, auth.signCount)
+    }
+}
+
+func TestRegistrationRejected(t *testing.T) {
+    rp := testRP()
+    tests := []struct {
+        name   string
+        origin string
+        modify func(a *softAuthenticator)
+    }{
+        {name: "wrong origin", origin: "https://evil.example"},
+        {name: "wrong RP ID hash", origin: testOrigin, modify: func(a *softAuthenticator) { a.rpID = "evil.example" }},
+        {name: "missing UP flag", origin: testOrigin, modify: func(a *softAuthenticator) { a.flags = flagUserVerified }},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            auth := newSoftAuthenticator(t)
+            if tt.modify != nil {
+                tt.modify(auth)
+            }
+            challenge := mustChallenge(t)
+            _, err := rp.VerifyRegistration(auth.register(challenge, tt.origin), challenge)
+            if !errors.Is(err, ErrVerification) {
+                t.Fatalf("err = %v, want ErrVerification", err)
+            }
+        })
+    }
+}
+
+func TestAssertionRejected(t *testing.T) {
+    rp := testRP()
+    auth := newSoftAuthenticator(t)
+    challenge := mustChallenge(t)
+    cred, err := rp.VerifyRegistration(auth.register(challenge, testOrigin), challenge)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    t.Run("wrong origin", func(t *testing.T) {
+        c := mustChallenge(t)
+        if _, err := rp.VerifyAssertion(auth.assert(t, c, "https://evil.example"), c, cred.PublicKey, 0); !errors.Is(err, ErrVerification) {
+            t.Fatalf("err = %v, want ErrVerification", err)
+        }
+    })
+    t.Run("wrong RP ID hash", func(t *testing.T) {
+        other := *auth
+        other.rpID = "evil.example"
+        c := mustChallenge(t)
+        if _, err := rp.VerifyAssertion(other.assert(t, c, testOrigin), c, cred.PublicKey, 0); !errors.Is(err, ErrVerification) {
+            t.Fatalf("err = %v, want ErrVerification", err)
+        }
+    })
+    t.Run("missing UP flag", func(t *testing.T) {
+        other := *auth
+        other.flags = flagUserVerified
+        c := mustChallenge(t)
+        if _, err := rp.VerifyAssertion(other.assert(t, c, testOrigin), c, cred.PublicKey, 0); !errors.Is(err, ErrVerification) {
+            t.Fatalf("err = %v, want ErrVerification", err)
+        }
+    })
+    t.Run("sign count regression", func(t *testing.T) {
+        c := mustChallenge(t)
+        resp := auth.assert(t, c, testOrigin)
+        if _, err := rp.VerifyAssertion(resp, c, cred.PublicKey, auth.signCount); !errors.Is(err, ErrSignCountRegression) {
+            t.Fatalf("err = %v, want ErrSignCountRegression", err)
+        }
+    })
+    t.Run("challenge mismatch", func(t *testing.T) {
+        resp := auth.assert(t, mustChallenge(t), testOrigin)
+        if _, err := rp.VerifyAssertion(resp, mustChallenge(t), cred.PublicKey, 0); !errors.Is(err, ErrVerification) {
+            t.Fatalf("err = %v, want ErrVerification", err)
+        }
+    })
+    t.Run("bad signature", func(t *testing.T) {
+        c := mustChallenge(t)
+        resp := auth.assert(t, c, testOrigin)
+        resp.Response.Signature[len(resp.Response.Signature)-1] ^= 0xff
+        if _, err := rp.VerifyAssertion(resp, c, cred.PublicKey, 0); !errors.Is(err, ErrVerification) {
+            t.Fatalf("err = %v, want ErrVerification", err)
+        }
+    })
+}
+
+func TestDecodeCBORRejectsMalformedInput(t *testing.T) {
+    valid := encodeCBOR([]cborPair{{"fmt", "none"}, {"authData", []byte{1, 2, 3}}})
+    for i := 0; i < len(valid); i++ {
+        if _, _, err := decodeCBOR(valid[:i]); err == nil {
+            t.Fatalf("truncated input of %d bytes decoded without error", i)
+        }
+    }
+    // A byte string claiming more bytes than there are
+    if _, _, err := decodeCBOR([]byte{0x5a, 0xff, 0xff, 0xff, 0xff}); err == nil {
+        t.Fatal("oversized byte string decoded without error")
+    }
+    // Arrays nested one level deeper than allowed
+    deep := bytes.Repeat([]byte{0x81}, maxCBORDepth+1)
+    deep = append(deep, 0x00)
+    if _, _, err := decodeCBOR(deep); err == nil {
+        t.Fatal("too deeply nested input decoded without error")
+    }
+    ok := append(bytes.Repeat([]byte{0x81}, maxCBORDepth), 0x00)
+    if _, _, err := decodeCBOR(ok); err != nil {
+        t.Fatalf("nesting at the limit: %v", err)
+    }
+}
+
+func TestRegistrationRejectsTruncatedAttestation(t *testing.T) {
+    rp := testRP()
+    auth := newSoftAuthenticator(t)
+    challenge := mustChallenge(t)
+    resp := auth.register(challenge, testOrigin)
+    resp.Response.AttestationObject = resp.Response.AttestationObject[:len(resp.Response.AttestationObject)-10]
+    if _, err := rp.VerifyRegistration(resp, challenge); !errors.Is(err, ErrVerification) {
+        t.Fatalf("err = %v, want ErrVerification", err)
+    }
+}